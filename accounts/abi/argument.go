@@ -34,10 +34,11 @@ type Argument struct {
 type Arguments []Argument
 
 type ArgumentMarshaling struct {
-	Name       string
-	Type       string
-	Components []ArgumentMarshaling
-	Indexed    bool
+	Name         string
+	Type         string
+	InternalType string
+	Components   []ArgumentMarshaling
+	Indexed      bool
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface
@@ -48,7 +49,7 @@ func (argument *Argument) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("argument json err: %v", err)
 	}
 
-	argument.Type, err = NewType(arg.Type, arg.Components)
+	argument.Type, err = newType(arg.Type, arg.InternalType, arg.Components)
 	if err != nil {
 		return err
 	}
@@ -169,7 +170,14 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues interfac
 	elem := reflect.ValueOf(v).Elem()
 
 	if elem.Kind() == reflect.Struct {
+		// A struct destination is usually a wrapper around the single returned
+		// value, but for tuple returns it may also be the returned struct itself.
 		fieldmap, err := mapArgNamesToStructFields([]string{argument.Name}, elem)
+		if argument.Type.T == TupleTy {
+			if field := elem.FieldByName(fieldmap[argument.Name]); err != nil || !field.IsValid() || field.Kind() != reflect.Struct {
+				return unpack(&argument.Type, elem.Addr().Interface(), marshalledValues)
+			}
+		}
 		if err != nil {
 			return err
 		}
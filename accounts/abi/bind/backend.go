@@ -62,6 +62,20 @@ type PendingContractCaller interface {
 	PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error)
 }
 
+// BatchContractCaller defines methods to perform multiple contract calls in a
+// single round trip. CallBatch will try to discover this interface and fall back
+// to executing the calls one by one if the backend does not support it.
+type BatchContractCaller interface {
+	// HeaderByNumber returns a block header from the current canonical chain. If
+	// number is nil, the latest known header is returned. It is used to pin all
+	// the calls of a batch to the same block.
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	// BatchCallContract executes multiple Ethereum contract calls against the same
+	// block, returning the outputs and errors of the individual calls. The error
+	// return is only set if the batch as a whole failed.
+	BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error)
+}
+
 // ContractTransactor defines the methods needed to allow operating with contract
 // on a write only basis. Beside the transacting method, the remainder are helpers
 // used when the user does not provide some needed values, but rather leaves it up
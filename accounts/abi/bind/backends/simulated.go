@@ -45,7 +45,20 @@ import (
 // This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
 var _ bind.ContractBackend = (*SimulatedBackend)(nil)
 
-var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
+// This nil assignment ensures compile time that SimulatedBackend implements bind.BatchContractCaller.
+var _ bind.BatchContractCaller = (*SimulatedBackend)(nil)
+
+// These nil assignments ensure compile time that SimulatedBackend implements the
+// chain, transaction and state reader interfaces.
+var (
+	_ ethereum.ChainReader       = (*SimulatedBackend)(nil)
+	_ ethereum.TransactionReader = (*SimulatedBackend)(nil)
+	_ ethereum.ChainStateReader  = (*SimulatedBackend)(nil)
+)
+
+var errBlockDoesNotExist = errors.New("block does not exist in blockchain")
+var errTransactionDoesNotExist = errors.New("transaction does not exist")
+var errPendingBlockDirty = errors.New("pending block contains transactions")
 var errGasEstimationFailed = errors.New("gas required exceeds allowance or always failing transaction")
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
@@ -77,7 +90,7 @@ func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBac
 		config:     genesis.Config,
 		events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
 	}
-	backend.rollback()
+	backend.rollback(blockchain.CurrentBlock())
 	return backend
 }
 
@@ -90,7 +103,8 @@ func (b *SimulatedBackend) Commit() {
 	if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
 		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
 	}
-	b.rollback()
+	// Keep building on the committed block, even if it's on a side chain
+	b.rollback(b.pendingBlock)
 }
 
 // Rollback aborts all pending transactions, reverting to the last committed state.
@@ -98,26 +112,71 @@ func (b *SimulatedBackend) Rollback() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.rollback()
+	b.rollback(b.pendingParent())
+}
+
+// Fork creates a side-chain that can be used to simulate reorgs. The pending
+// block is discarded and rebuilt on top of the given parent block, so that all
+// subsequent commits extend the fork instead of the current chain head. Once the
+// fork accumulates more difficulty than the canonical chain, it becomes the new
+// head and the logs of the dropped blocks are sent to subscribers as removed.
+//
+// The pending block needs to be empty, otherwise an error is returned.
+func (b *SimulatedBackend) Fork(ctx context.Context, parent common.Hash) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pendingBlock.Transactions()) != 0 {
+		return errPendingBlockDirty
+	}
+	block := b.blockchain.GetBlockByHash(parent)
+	if block == nil {
+		return errBlockDoesNotExist
+	}
+	b.rollback(block)
+	return nil
 }
 
-func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(int, *core.BlockGen) {})
+// rollback discards the pending block and state, starting a fresh empty block
+// on top of the given parent.
+func (b *SimulatedBackend) rollback(parent *types.Block) {
+	blocks, _ := core.GenerateChain(b.config, parent, ethash.NewFaker(), b.database, 1, func(int, *core.BlockGen) {})
 	statedb, _ := b.blockchain.State()
 
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
 }
 
+// pendingParent returns the block the pending block is built on top of.
+func (b *SimulatedBackend) pendingParent() *types.Block {
+	return b.blockchain.GetBlock(b.pendingBlock.ParentHash(), b.pendingBlock.NumberU64()-1)
+}
+
+// pendingTimeOffset returns the number of seconds the timestamp of the pending
+// block was shifted by compared to the default block time.
+func (b *SimulatedBackend) pendingTimeOffset(parent *types.Block) int64 {
+	return new(big.Int).Sub(b.pendingBlock.Time(), parent.Time()).Int64() - 10
+}
+
+// stateByBlockNumber retrieves the state of the canonical chain at the given
+// block number. If blockNumber is nil, the state of the latest block is returned.
+func (b *SimulatedBackend) stateByBlockNumber(ctx context.Context, blockNumber *big.Int) (*state.StateDB, error) {
+	block, err := b.blockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return b.blockchain.StateAt(block.Root())
+}
+
 // CodeAt returns the code associated with a certain account in the blockchain.
 func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetCode(contract), nil
 }
 
@@ -126,10 +185,10 @@ func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Addres
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetBalance(contract), nil
 }
 
@@ -138,10 +197,10 @@ func (b *SimulatedBackend) NonceAt(ctx context.Context, contract common.Address,
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return 0, errBlockNumberUnsupported
+	statedb, err := b.stateByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return 0, err
 	}
-	statedb, _ := b.blockchain.State()
 	return statedb.GetNonce(contract), nil
 }
 
@@ -150,10 +209,10 @@ func (b *SimulatedBackend) StorageAt(ctx context.Context, contract common.Addres
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	statedb, err := b.stateByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	statedb, _ := b.blockchain.State()
 	val := statedb.GetState(contract, key)
 	return val[:], nil
 }
@@ -164,6 +223,120 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common
 	return receipt, nil
 }
 
+// TransactionByHash checks the pool of pending transactions in addition to the
+// blockchain. The isPending return value indicates whether the transaction has
+// been mined yet. Note that the transaction may not be part of the canonical
+// chain even if it's not pending.
+func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if tx := b.pendingBlock.Transaction(txHash); tx != nil {
+		return tx, true, nil
+	}
+	if tx, _, _, _ := rawdb.ReadTransaction(b.database, txHash); tx != nil {
+		return tx, false, nil
+	}
+	return nil, false, ethereum.NotFound
+}
+
+// BlockByHash retrieves a block based on the block hash.
+func (b *SimulatedBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.blockByHash(ctx, hash)
+}
+
+// blockByHash retrieves a block based on the block hash without acquiring the lock.
+func (b *SimulatedBackend) blockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock, nil
+	}
+	if block := b.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, errBlockDoesNotExist
+}
+
+// BlockByNumber retrieves a block from the current canonical chain. If number
+// is nil, the latest known block is returned.
+func (b *SimulatedBackend) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.blockByNumber(ctx, number)
+}
+
+// blockByNumber retrieves a block from the current canonical chain without
+// acquiring the lock. If number is nil, the latest known block is returned.
+func (b *SimulatedBackend) blockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if number == nil || number.Cmp(b.blockchain.CurrentBlock().Number()) == 0 {
+		return b.blockchain.CurrentBlock(), nil
+	}
+	if block := b.blockchain.GetBlockByNumber(number.Uint64()); block != nil {
+		return block, nil
+	}
+	return nil, errBlockDoesNotExist
+}
+
+// HeaderByHash returns a block header from the current canonical chain.
+func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock.Header(), nil
+	}
+	if header := b.blockchain.GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	return nil, errBlockDoesNotExist
+}
+
+// HeaderByNumber returns a block header from the current canonical chain. If
+// number is nil, the latest known header is returned.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil || number.Cmp(b.blockchain.CurrentBlock().Number()) == 0 {
+		return b.blockchain.CurrentHeader(), nil
+	}
+	if header := b.blockchain.GetHeaderByNumber(number.Uint64()); header != nil {
+		return header, nil
+	}
+	return nil, errBlockDoesNotExist
+}
+
+// TransactionCount returns the number of transactions in a given block.
+func (b *SimulatedBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByHash(ctx, blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint(block.Transactions().Len()), nil
+}
+
+// TransactionInBlock returns the transaction for a specific block at a specific index.
+func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if uint(len(txs)) <= index {
+		return nil, errTransactionDoesNotExist
+	}
+	return txs[index], nil
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -177,17 +350,42 @@ func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallM
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
-		return nil, errBlockNumberUnsupported
+	block, err := b.blockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
 	}
-	state, err := b.blockchain.State()
+	state, err := b.blockchain.StateAt(block.Root())
 	if err != nil {
 		return nil, err
 	}
-	rval, _, _, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), state)
+	rval, _, _, err := b.callContract(ctx, call, block, state)
 	return rval, err
 }
 
+// BatchCallContract executes multiple contract calls against the same block,
+// returning the outputs and errors of the individual calls.
+func (b *SimulatedBackend) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	block, err := b.blockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		outputs = make([][]byte, len(calls))
+		errs    = make([]error, len(calls))
+	)
+	for i, call := range calls {
+		state, err := b.blockchain.StateAt(block.Root())
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs[i], _, _, errs[i] = b.callContract(ctx, call, block, state)
+	}
+	return outputs, errs, nil
+}
+
 // PendingCallContract executes a contract call on the pending state.
 func (b *SimulatedBackend) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
 	b.mu.Lock()
@@ -306,7 +504,14 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+	var (
+		parent = b.pendingParent()
+		offset = b.pendingTimeOffset(parent)
+	)
+	blocks, _ := core.GenerateChain(b.config, parent, ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+		if offset != 0 {
+			block.OffsetTime(offset)
+		}
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTxWithChain(b.blockchain, tx)
 		}
@@ -387,15 +592,54 @@ func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query ethere
 	}), nil
 }
 
-// AdjustTime adds a time shift to the simulated clock.
+// SubscribeNewHead returns an event subscription for a new header imported as
+// the head of the canonical chain.
+func (b *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	// Subscribe to new chain heads
+	sink := make(chan *types.Header)
+	sub := b.events.SubscribeNewHeads(sink)
+
+	// Forward the headers to the user supplied channel
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-sink:
+				select {
+				case ch <- head:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// AdjustTime adds a time shift to the simulated clock. The shift is applied to
+// the timestamp of the pending block, on top of any previous adjustments, and
+// is retained by all subsequently committed blocks. Pending transactions are
+// re-executed with the new timestamp.
 func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+
+	var (
+		parent = b.pendingParent()
+		offset = b.pendingTimeOffset(parent) + int64(adjustment.Seconds())
+	)
+	blocks, _ := core.GenerateChain(b.config, parent, ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+		if offset != 0 {
+			block.OffsetTime(offset)
+		}
 		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
+			block.AddTxWithChain(b.blockchain, tx)
 		}
-		block.OffsetTime(int64(adjustment.Seconds()))
 	})
 	statedb, _ := b.blockchain.State()
 
@@ -0,0 +1,211 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1000000000000000000)
+)
+
+// logCode is a contract creation code emitting a single empty log (LOG0) and
+// deploying no runtime code.
+var logCode = common.FromHex("60006000a000")
+
+func newTestBackend() *SimulatedBackend {
+	return NewSimulatedBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}}, 10000000)
+}
+
+// sendTx signs and submits a contract creation transaction to the backend.
+func sendTx(t *testing.T, sim *SimulatedBackend, nonce uint64, code []byte) *types.Transaction {
+	tx, err := types.SignTx(types.NewContractCreation(nonce, new(big.Int), 100000, big.NewInt(1), code), types.HomesteadSigner{}, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that blocks, headers and transactions can be retrieved from the simulated
+// chain, and that state can be queried at historical blocks.
+func TestSimulatedChainReader(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	tx := sendTx(t, sim, 0, logCode)
+	if found, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || !pending || found.Hash() != tx.Hash() {
+		t.Fatalf("pending transaction mismatch: have %v/%v/%v, want %x/true/nil", found, pending, err, tx.Hash())
+	}
+	sim.Commit()
+
+	if found, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || pending || found.Hash() != tx.Hash() {
+		t.Fatalf("mined transaction mismatch: have %v/%v/%v, want %x/false/nil", found, pending, err, tx.Hash())
+	}
+	if _, _, err := sim.TransactionByHash(ctx, common.Hash{1}); err != ethereum.NotFound {
+		t.Fatalf("unknown transaction error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	block, err := sim.BlockByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve head block: %v", err)
+	}
+	if block.NumberU64() != 1 {
+		t.Fatalf("head block number mismatch: have %d, want 1", block.NumberU64())
+	}
+	if byNumber, err := sim.BlockByNumber(ctx, big.NewInt(1)); err != nil || byNumber.Hash() != block.Hash() {
+		t.Fatalf("block by number mismatch: have %v/%v, want %x", byNumber, err, block.Hash())
+	}
+	if byHash, err := sim.BlockByHash(ctx, block.Hash()); err != nil || byHash.Hash() != block.Hash() {
+		t.Fatalf("block by hash mismatch: have %v/%v, want %x", byHash, err, block.Hash())
+	}
+	if header, err := sim.HeaderByHash(ctx, block.Hash()); err != nil || header.Hash() != block.Hash() {
+		t.Fatalf("header by hash mismatch: have %v/%v, want %x", header, err, block.Hash())
+	}
+	if _, err := sim.BlockByNumber(ctx, big.NewInt(2)); err != errBlockDoesNotExist {
+		t.Fatalf("future block error mismatch: have %v, want %v", err, errBlockDoesNotExist)
+	}
+	if count, err := sim.TransactionCount(ctx, block.Hash()); err != nil || count != 1 {
+		t.Fatalf("transaction count mismatch: have %d/%v, want 1", count, err)
+	}
+	if found, err := sim.TransactionInBlock(ctx, block.Hash(), 0); err != nil || found.Hash() != tx.Hash() {
+		t.Fatalf("transaction in block mismatch: have %v/%v, want %x", found, err, tx.Hash())
+	}
+	if _, err := sim.TransactionInBlock(ctx, block.Hash(), 1); err != errTransactionDoesNotExist {
+		t.Fatalf("missing transaction error mismatch: have %v, want %v", err, errTransactionDoesNotExist)
+	}
+	// Query the account state before and after the transaction
+	if balance, err := sim.BalanceAt(ctx, testAddr, big.NewInt(0)); err != nil || balance.Cmp(testBalance) != 0 {
+		t.Fatalf("genesis balance mismatch: have %v/%v, want %v", balance, err, testBalance)
+	}
+	if nonce, err := sim.NonceAt(ctx, testAddr, big.NewInt(0)); err != nil || nonce != 0 {
+		t.Fatalf("genesis nonce mismatch: have %d/%v, want 0", nonce, err)
+	}
+	if nonce, err := sim.NonceAt(ctx, testAddr, big.NewInt(1)); err != nil || nonce != 1 {
+		t.Fatalf("head nonce mismatch: have %d/%v, want 1", nonce, err)
+	}
+}
+
+// Tests that time adjustments are applied to the pending block and retained when
+// further transactions are added to it.
+func TestSimulatedAdjustTime(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	parent, _ := sim.HeaderByNumber(ctx, nil)
+	if err := sim.AdjustTime(100 * time.Second); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	if err := sim.AdjustTime(50 * time.Second); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	sendTx(t, sim, 0, logCode)
+	sim.Commit()
+
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if diff := new(big.Int).Sub(head.Time, parent.Time).Uint64(); diff != 160 {
+		t.Fatalf("block time difference mismatch: have %d, want %d", diff, 160)
+	}
+}
+
+// Tests that forking the simulated chain and extending the side chain past the
+// canonical one results in a reorg, announcing the new head and the removed logs.
+func TestSimulatedFork(t *testing.T) {
+	sim := newTestBackend()
+	ctx := context.Background()
+
+	logs := make(chan types.Log, 4)
+	logSub, err := sim.SubscribeFilterLogs(ctx, ethereum.FilterQuery{}, logs)
+	if err != nil {
+		t.Fatalf("failed to subscribe to logs: %v", err)
+	}
+	defer logSub.Unsubscribe()
+
+	heads := make(chan *types.Header, 4)
+	headSub, err := sim.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe to heads: %v", err)
+	}
+	defer headSub.Unsubscribe()
+
+	genesis, _ := sim.BlockByNumber(ctx, big.NewInt(0))
+
+	// Mine a block with a log into the canonical chain
+	tx := sendTx(t, sim, 0, logCode)
+	sim.Commit()
+
+	canonical, _ := sim.BlockByNumber(ctx, nil)
+	select {
+	case head := <-heads:
+		if head.Hash() != canonical.Hash() {
+			t.Fatalf("new head mismatch: have %x, want %x", head.Hash(), canonical.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("new head not announced")
+	}
+	select {
+	case log := <-logs:
+		if log.Removed || log.TxHash != tx.Hash() {
+			t.Fatalf("log mismatch: have %v, want mined log of %x", log, tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("mined log not announced")
+	}
+	// Fork off of the genesis block and outgrow the canonical chain
+	if err := sim.Fork(ctx, genesis.Hash()); err != nil {
+		t.Fatalf("failed to fork chain: %v", err)
+	}
+	sim.Commit()
+	sim.Commit()
+
+	head, _ := sim.BlockByNumber(ctx, nil)
+	if head.NumberU64() != 2 {
+		t.Fatalf("head number mismatch after reorg: have %d, want 2", head.NumberU64())
+	}
+	if block, _ := sim.BlockByNumber(ctx, big.NewInt(1)); block.Hash() == canonical.Hash() {
+		t.Fatalf("canonical block not reorged out")
+	}
+	select {
+	case log := <-logs:
+		if !log.Removed || log.TxHash != tx.Hash() {
+			t.Fatalf("log mismatch: have %v, want removed log of %x", log, tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("removed log not announced")
+	}
+	if _, _, err := sim.TransactionByHash(ctx, tx.Hash()); err != ethereum.NotFound {
+		t.Fatalf("reorged transaction error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	// Forking with pending transactions should be rejected
+	sendTx(t, sim, 0, logCode)
+	if err := sim.Fork(ctx, genesis.Hash()); err != errPendingBlockDirty {
+		t.Fatalf("dirty fork error mismatch: have %v, want %v", err, errPendingBlockDirty)
+	}
+}
@@ -326,18 +326,33 @@ func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]inter
 
 // UnpackLog unpacks a retrieved log into the provided output structure.
 func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	ev, ok := c.abi.Events[event]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", event)
+	}
+	if !ev.Anonymous && (len(log.Topics) == 0 || log.Topics[0] != ev.Id()) {
+		return errors.New("event signature mismatch")
+	}
 	if len(log.Data) > 0 {
 		if err := c.abi.Unpack(out, event, log.Data); err != nil {
 			return err
 		}
 	}
 	var indexed abi.Arguments
-	for _, arg := range c.abi.Events[event].Inputs {
+	for i, arg := range ev.Inputs {
 		if arg.Indexed {
+			// Anonymous indexed fields are named by position in the bindings
+			if arg.Name == "" {
+				arg.Name = fmt.Sprintf("arg%d", i)
+			}
 			indexed = append(indexed, arg)
 		}
 	}
-	return parseTopics(out, indexed, log.Topics[1:])
+	topics := log.Topics
+	if !ev.Anonymous {
+		topics = topics[1:]
+	}
+	return parseTopics(out, indexed, topics)
 }
 
 // ensureContext is a helper method to ensure a context is not nil, even if the
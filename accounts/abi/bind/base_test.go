@@ -3,12 +3,14 @@ package bind_test
 import (
 	"context"
 	"math/big"
+	"strings"
 	"testing"
 
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type mockCaller struct {
@@ -62,3 +64,48 @@ func TestPassingBlockNumber(t *testing.T) {
 		t.Fatalf("CodeAt() was passed a block number when it should not have been")
 	}
 }
+
+// Tests that logs of anonymous events are unpacked without expecting a
+// signature topic, while the signature of named events is still checked.
+func TestUnpackAnonymousLog(t *testing.T) {
+	const definition = `[
+		{"anonymous":true,"inputs":[{"indexed":false,"name":"value","type":"uint256"}],"name":"Anon","type":"event"},
+		{"anonymous":true,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"AnonIndexed","type":"event"},
+		{"anonymous":false,"inputs":[{"indexed":false,"name":"value","type":"uint256"}],"name":"Named","type":"event"}
+	]`
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	bc := bind.NewBoundContract(common.Address{}, parsed, nil, nil, nil)
+	data := common.LeftPadBytes(big.NewInt(42).Bytes(), 32)
+
+	// Anonymous events without indexed arguments have no topics at all
+	var anon struct{ Value *big.Int }
+	if err := bc.UnpackLog(&anon, "Anon", types.Log{Data: data}); err != nil {
+		t.Fatalf("failed to unpack anonymous log: %v", err)
+	}
+	if anon.Value.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("value mismatch: have %v, want 42", anon.Value)
+	}
+	// Indexed arguments of anonymous events start at the first topic
+	from := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	var indexed struct {
+		From  common.Address
+		Value *big.Int
+	}
+	if err := bc.UnpackLog(&indexed, "AnonIndexed", types.Log{Topics: []common.Hash{from.Hash()}, Data: data}); err != nil {
+		t.Fatalf("failed to unpack anonymous indexed log: %v", err)
+	}
+	if indexed.From != from || indexed.Value.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("fields mismatch: have %x/%v, want %x/42", indexed.From, indexed.Value, from)
+	}
+	// Named events must carry their signature
+	var named struct{ Value *big.Int }
+	if err := bc.UnpackLog(&named, "Named", types.Log{Data: data}); err == nil {
+		t.Errorf("unpacked named event log without signature topic")
+	}
+	if err := bc.UnpackLog(&named, "Named", types.Log{Topics: []common.Hash{parsed.Events["Named"].Id()}, Data: data}); err != nil {
+		t.Errorf("failed to unpack named log: %v", err)
+	}
+}
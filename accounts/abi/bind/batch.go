@@ -0,0 +1,165 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// errBatchNotExecuted is returned by a batched call's error accessor if the
+	// batch it was queued into hasn't been executed yet.
+	errBatchNotExecuted = errors.New("batch not executed")
+
+	// errBatchPending is returned if a batch is requested to be executed on the
+	// pending state, which cannot be pinned to a single block.
+	errBatchPending = errors.New("batched calls don't support the pending state")
+)
+
+// CallBatch accumulates read only contract calls to be executed in a single round
+// trip to the backend, all of them against the same block.
+type CallBatch struct {
+	caller ContractCaller
+	calls  []*BatchedCall
+}
+
+// BatchedCall is a contract call queued into a CallBatch. The result it was
+// queued with is filled in and its error set when the batch is executed.
+type BatchedCall struct {
+	address  common.Address // Address of the contract to call
+	contract *BoundContract // Contract binding to unpack the result with
+	method   string         // Name of the contract method to call
+	input    []byte         // Packed input of the call, nil if packing failed
+	result   interface{}    // Output to unpack the call result into
+	err      error          // Error the call failed with
+}
+
+// Err returns the error the call failed with, or nil if it succeeded and its
+// result is available.
+func (c *BatchedCall) Err() error {
+	return c.err
+}
+
+// NewCallBatch creates a new batch of contract calls to be executed via the given
+// backend. If the backend implements BatchContractCaller, all calls are sent in
+// a single request, otherwise they are executed one by one.
+func NewCallBatch(caller ContractCaller) *CallBatch {
+	return &CallBatch{caller: caller}
+}
+
+// Len returns the number of calls queued into the batch.
+func (b *CallBatch) Len() int {
+	return len(b.calls)
+}
+
+// BatchCall queues the (constant) contract method with params as input values
+// into a batch, setting the output to result when the batch is executed. The
+// result type might be a single field for simple returns, a slice of interfaces
+// for anonymous returns and a struct for named returns.
+func (c *BoundContract) BatchCall(batch *CallBatch, result interface{}, method string, params ...interface{}) *BatchedCall {
+	call := &BatchedCall{
+		address:  c.address,
+		contract: c,
+		method:   method,
+		result:   result,
+		err:      errBatchNotExecuted,
+	}
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		call.err = err
+	} else {
+		call.input = input
+	}
+	batch.calls = append(batch.calls, call)
+	return call
+}
+
+// Execute runs all the queued calls against the same block and unpacks their
+// results. If no block number is specified in opts, the batch is pinned to the
+// latest block if the backend supports it. The returned error is only set if the
+// batch as a whole failed, the outcome of individual calls is reported by their
+// own Err methods.
+func (b *CallBatch) Execute(opts *CallOpts) error {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	if opts.Pending {
+		return errBatchPending
+	}
+	// Gather all the calls that could be packed successfully
+	var (
+		calls []*BatchedCall
+		msgs  []ethereum.CallMsg
+	)
+	for _, call := range b.calls {
+		if call.input == nil {
+			continue
+		}
+		calls = append(calls, call)
+		msgs = append(msgs, ethereum.CallMsg{From: opts.From, To: &call.address, Data: call.input})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	// Execute the calls, in a single request if supported by the backend
+	var (
+		ctx     = ensureContext(opts.Context)
+		number  = opts.BlockNumber
+		outputs [][]byte
+		errs    []error
+	)
+	if batcher, ok := b.caller.(BatchContractCaller); ok {
+		if number == nil {
+			head, err := batcher.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return err
+			}
+			number = head.Number
+		}
+		var err error
+		if outputs, errs, err = batcher.BatchCallContract(ctx, msgs, number); err != nil {
+			return err
+		}
+	} else {
+		outputs, errs = make([][]byte, len(msgs)), make([]error, len(msgs))
+		for i, msg := range msgs {
+			outputs[i], errs[i] = b.caller.CallContract(ctx, msg, number)
+		}
+	}
+	// Unpack the individual results into their requested outputs
+	for i, call := range calls {
+		if call.err = errs[i]; call.err != nil {
+			continue
+		}
+		if len(outputs[i]) == 0 {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			if code, err := b.caller.CodeAt(ctx, call.address, number); err != nil {
+				call.err = err
+				continue
+			} else if len(code) == 0 {
+				call.err = ErrNoCode
+				continue
+			}
+		}
+		call.err = call.contract.abi.Unpack(call.result, call.method, outputs[i])
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// echoCaller is a contract caller without batch support, returning the input of
+// every call without the method selector.
+type echoCaller struct {
+	calls   int
+	numbers []*big.Int
+}
+
+func (ec *echoCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (ec *echoCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	ec.calls++
+	ec.numbers = append(ec.numbers, blockNumber)
+	return call.Data[4:], nil
+}
+
+// Tests that batches fall back to individual calls on backends not supporting
+// batching, and that per-call failures are reported individually.
+func TestCallBatchFallback(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"constant":true,"inputs":[{"name":"a","type":"uint256"}],"name":"echo","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":true,"inputs":[],"name":"empty","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	var (
+		caller   = new(echoCaller)
+		contract = bind.NewBoundContract(common.Address{1}, parsed, caller, nil, nil)
+		batch    = bind.NewCallBatch(caller)
+		number   = big.NewInt(42)
+	)
+	var one, two, none *big.Int
+	oneCall := contract.BatchCall(batch, &one, "echo", big.NewInt(1))
+	twoCall := contract.BatchCall(batch, &two, "echo", big.NewInt(2))
+	badCall := contract.BatchCall(batch, &none, "echo", "not a number")
+	emptyCall := contract.BatchCall(batch, &none, "empty")
+
+	if batch.Len() != 4 {
+		t.Fatalf("batch length mismatch: have %d, want 4", batch.Len())
+	}
+	if err := badCall.Err(); err == nil {
+		t.Fatalf("invalid call packed successfully")
+	}
+	if err := batch.Execute(&bind.CallOpts{Pending: true}); err == nil {
+		t.Fatalf("pending batch executed")
+	}
+	if err := batch.Execute(&bind.CallOpts{BlockNumber: number}); err != nil {
+		t.Fatalf("failed to execute batch: %v", err)
+	}
+	if caller.calls != 3 {
+		t.Errorf("backend call count mismatch: have %d, want 3", caller.calls)
+	}
+	for i, n := range caller.numbers {
+		if n != number {
+			t.Errorf("call %d: block number mismatch: have %v, want %v", i, n, number)
+		}
+	}
+	if err := oneCall.Err(); err != nil || one.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("first call mismatch: have %v/%v, want 1/nil", one, err)
+	}
+	if err := twoCall.Err(); err != nil || two.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("second call mismatch: have %v/%v, want 2/nil", two, err)
+	}
+	if err := emptyCall.Err(); err != bind.ErrNoCode {
+		t.Errorf("empty call error mismatch: have %v, want %v", err, bind.ErrNoCode)
+	}
+}
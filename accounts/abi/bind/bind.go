@@ -22,12 +22,13 @@ package bind
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"go/format"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
-	"unicode"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 )
@@ -45,9 +46,17 @@ const (
 // to be used as is in client code, but rather as an intermediate struct which
 // enforces compile time type safety and naming convention opposed to having to
 // manually maintain hard coded strings that break on runtime.
-func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
+//
+// The libs map associates unlinked library placeholders in the bytecodes with
+// the type names of the libraries they reference. Deploy methods of contracts
+// referencing any of them will deploy the libraries first and link them in,
+// so the libraries themselves need to be bound into the same package.
+func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang, libs map[string]string) (string, error) {
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct) // Struct types shared between all contracts
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -55,13 +64,13 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 		if err != nil {
 			return "", err
 		}
-		// Strip any whitespace from the JSON ABI
-		strippedABI := strings.Map(func(r rune) rune {
-			if unicode.IsSpace(r) {
-				return -1
-			}
-			return r
-		}, abis[i])
+		// Strip any insignificant whitespace from the JSON ABI, retaining the
+		// ones within strings (e.g. internal types like "struct Foo.Bar")
+		stripped := new(bytes.Buffer)
+		if err := json.Compact(stripped, []byte(abis[i])); err != nil {
+			return "", err
+		}
+		strippedABI := stripped.String()
 
 		// Extract the call and transact methods; events; and sort them alphabetically
 		var (
@@ -117,20 +126,35 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			// Append the event to the accumulator list
 			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
 		}
+		// Register all tuple types in a deterministic order so that generated
+		// struct names don't depend on template rendering or map iteration.
+		registerStructs(evmABI, structs, lang)
+
+		// Gather all the libraries the bytecode needs to be linked against
+		bytecode := strings.TrimSpace(bytecodes[i])
+
+		libraries := make(map[string]string)
+		for pattern, name := range libs {
+			if strings.Contains(bytecode, pattern) {
+				libraries[pattern] = name
+			}
+		}
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
 			InputABI:    strings.Replace(strippedABI, "\"", "\\\"", -1),
-			InputBin:    strings.TrimSpace(bytecodes[i]),
+			InputBin:    bytecode,
 			Constructor: evmABI.Constructor,
 			Calls:       calls,
 			Transacts:   transacts,
 			Events:      events,
+			Libraries:   libraries,
 		}
 	}
 	// Generate the contract template data content and render it
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
@@ -157,9 +181,40 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 	return buffer.String(), nil
 }
 
+// registerStructs walks all the arguments of a contract's constructor, methods
+// and events, registering a struct definition for every tuple type found.
+func registerStructs(evmABI abi.ABI, structs map[string]*tmplStruct, lang Lang) {
+	register := func(args abi.Arguments) {
+		for _, arg := range args {
+			if hasStruct(arg.Type) {
+				bindStructType[lang](arg.Type, structs)
+			}
+		}
+	}
+	register(evmABI.Constructor.Inputs)
+
+	methods := make([]string, 0, len(evmABI.Methods))
+	for name := range evmABI.Methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+	for _, name := range methods {
+		register(evmABI.Methods[name].Inputs)
+		register(evmABI.Methods[name].Outputs)
+	}
+	events := make([]string, 0, len(evmABI.Events))
+	for name := range evmABI.Events {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	for _, name := range events {
+		register(evmABI.Events[name].Inputs)
+	}
+}
+
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language types.
-var bindType = map[Lang]func(kind abi.Type) string{
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
 	LangGo:   bindTypeGo,
 	LangJava: bindTypeJava,
 }
@@ -199,7 +254,10 @@ func arrayBindingGo(inner string, arraySizes []string) string {
 // bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
 // from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. *big.Int).
-func bindTypeGo(kind abi.Type) string {
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hasStruct(kind) {
+		return bindStructTypeGo(kind, structs)
+	}
 	stringKind := kind.String()
 	innerLen, innerMapping := bindUnnestedTypeGo(stringKind)
 	return arrayBindingGo(wrapArray(stringKind, innerLen, innerMapping))
@@ -247,7 +305,10 @@ func arrayBindingJava(inner string, arraySizes []string) string {
 // bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
 // from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type) string {
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hasStruct(kind) {
+		return bindStructTypeJava(kind, structs)
+	}
 	stringKind := kind.String()
 	innerLen, innerMapping := bindUnnestedTypeJava(stringKind)
 	return arrayBindingJava(wrapArray(stringKind, innerLen, innerMapping))
@@ -310,29 +371,131 @@ func bindUnnestedTypeJava(stringKind string) (int, string) {
 
 // bindTopicType is a set of type binders that convert Solidity types to some
 // supported programming language topic types.
-var bindTopicType = map[Lang]func(kind abi.Type) string{
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
 	LangGo:   bindTopicTypeGo,
 	LangJava: bindTopicTypeJava,
 }
 
 // bindTypeGo converts a Solidity topic type to a Go one. It is almost the same
-// funcionality as for simple types, but dynamic types get converted to hashes.
-func bindTopicTypeGo(kind abi.Type) string {
-	bound := bindTypeGo(kind)
-	if bound == "string" || bound == "[]byte" {
-		bound = "common.Hash"
+// funcionality as for simple types, but dynamic and composite types get converted
+// to hashes.
+func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	if isHashedTopic(kind) {
+		return "common.Hash"
 	}
-	return bound
+	return bindTypeGo(kind, structs)
 }
 
 // bindTypeGo converts a Solidity topic type to a Java one. It is almost the same
-// funcionality as for simple types, but dynamic types get converted to hashes.
-func bindTopicTypeJava(kind abi.Type) string {
-	bound := bindTypeJava(kind)
-	if bound == "String" || bound == "Bytes" {
-		bound = "Hash"
+// funcionality as for simple types, but dynamic and composite types get converted
+// to hashes.
+func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	if isHashedTopic(kind) {
+		return "Hash"
+	}
+	return bindTypeJava(kind, structs)
+}
+
+// isHashedTopic returns whether an indexed event argument of the given type is
+// stored as the Keccak256 hash of its value in the log topics.
+func isHashedTopic(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	default:
+		return false
+	}
+}
+
+// bindStructType is a set of type binders that convert Solidity tuple types to
+// some supported programming language struct definitions.
+var bindStructType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   bindStructTypeGo,
+	LangJava: bindStructTypeJava,
+}
+
+// bindStructTypeGo converts a Solidity tuple type to a Go one, recording the
+// struct definition (if not yet known) in the given structs map. Arrays and
+// slices of tuples are converted to arrays and slices of the generated struct.
+func bindStructTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		id := kind.TupleRawName + kind.String()
+		if s, exist := structs[id]; exist {
+			return s.Name
+		}
+		var fields []*tmplField
+		for i, elem := range kind.TupleElems {
+			fields = append(fields, &tmplField{Type: bindTypeGo(*elem, structs), Name: capitalise(kind.TupleRawNames[i]), SolKind: *elem})
+		}
+		name := structName(kind, structs)
+		structs[id] = &tmplStruct{Name: name, Fields: fields}
+		return name
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindStructTypeGo(*kind.Elem, structs)
+	case abi.SliceTy:
+		return "[]" + bindStructTypeGo(*kind.Elem, structs)
+	default:
+		return bindTypeGo(kind, structs)
+	}
+}
+
+// bindStructTypeJava converts a Solidity tuple type to a Java one, recording
+// the struct definition (if not yet known) in the given structs map.
+func bindStructTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		id := kind.TupleRawName + kind.String()
+		if s, exist := structs[id]; exist {
+			return s.Name
+		}
+		var fields []*tmplField
+		for i, elem := range kind.TupleElems {
+			fields = append(fields, &tmplField{Type: bindTypeJava(*elem, structs), Name: decapitalise(kind.TupleRawNames[i]), SolKind: *elem})
+		}
+		name := structName(kind, structs)
+		structs[id] = &tmplStruct{Name: name, Fields: fields}
+		return name
+	case abi.ArrayTy, abi.SliceTy:
+		return bindStructTypeJava(*kind.Elem, structs) + "[]"
+	default:
+		return bindTypeJava(kind, structs)
+	}
+}
+
+// structName picks a unique name for the struct generated from a tuple type.
+// The source-level struct name is used if the ABI contains it, otherwise the
+// struct gets a sequential auto-generated one.
+func structName(kind abi.Type, structs map[string]*tmplStruct) string {
+	taken := make(map[string]bool)
+	for _, s := range structs {
+		taken[s.Name] = true
+	}
+	base := capitalise(kind.TupleRawName)
+	if base != "" && !taken[base] {
+		return base
+	}
+	if base == "" {
+		base = "Struct"
+	}
+	for i := 0; ; i++ {
+		if name := fmt.Sprintf("%s%d", base, i); !taken[name] {
+			return name
+		}
+	}
+}
+
+// hasStruct returns an indicator whether the given type is a struct, or an
+// array or slice of structs.
+func hasStruct(kind abi.Type) bool {
+	switch kind.T {
+	case abi.SliceTy, abi.ArrayTy:
+		return hasStruct(*kind.Elem)
+	case abi.TupleTy:
+		return true
+	default:
+		return false
 	}
-	return bound
 }
 
 // namedType is a set of functions that transform language specific types to
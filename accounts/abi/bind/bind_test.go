@@ -36,6 +36,7 @@ var bindTests = []struct {
 	abi      string
 	imports  string
 	tester   string
+	libs     map[string]string
 }{
 	// Test that the binding is available in combined and separate forms too
 	{
@@ -55,6 +56,7 @@ var bindTests = []struct {
 				t.Fatalf("transactor binding (%v) nil or error (%v) not nil", b, nil)
 			}
 		`,
+		nil,
 	},
 	// Test that all the official sample contracts bind correctly
 	{
@@ -68,6 +70,7 @@ var bindTests = []struct {
 				t.Fatalf("binding (%v) nil or error (%v) not nil", b, nil)
 			}
 		`,
+		nil,
 	},
 	{
 		`Crowdsale`,
@@ -80,6 +83,7 @@ var bindTests = []struct {
 				t.Fatalf("binding (%v) nil or error (%v) not nil", b, nil)
 			}
 		`,
+		nil,
 	},
 	{
 		`DAO`,
@@ -92,6 +96,7 @@ var bindTests = []struct {
 				t.Fatalf("binding (%v) nil or error (%v) not nil", b, nil)
 			}
 		`,
+		nil,
 	},
 	// Test that named and anonymous inputs are handled correctly
 	{
@@ -125,6 +130,7 @@ var bindTests = []struct {
 
 			 fmt.Println(err)
 		 }`,
+		nil,
 	},
 	// Test that named and anonymous outputs are handled correctly
 	{
@@ -161,6 +167,7 @@ var bindTests = []struct {
 
 			 fmt.Println(str1, str2, res.Str1, res.Str2, err)
 		 }`,
+		nil,
 	},
 	// Tests that named, anonymous and indexed events are handled correctly
 	{
@@ -226,6 +233,7 @@ var bindTests = []struct {
 		 if _, ok := reflect.TypeOf(&EventChecker{}).MethodByName("FilterAnonymous"); ok {
 		 	t.Errorf("binding has disallowed method (FilterAnonymous)")
 		 }`,
+		nil,
 	},
 	// Test that contract interactions (deploy, transact and call) generate working code
 	{
@@ -282,6 +290,7 @@ var bindTests = []struct {
 				t.Fatalf("Transact string mismatch: have '%s', want 'Transact string'", str)
 			}
 		`,
+		nil,
 	},
 	// Tests that plain values can be properly returned and deserialized
 	{
@@ -322,6 +331,7 @@ var bindTests = []struct {
 				t.Fatalf("Retrieved value mismatch: have %v/%v, want %v/%v", str, num, "Hi", 1)
 			}
 		`,
+		nil,
 	},
 	// Tests that tuples can be properly returned and deserialized
 	{
@@ -362,6 +372,7 @@ var bindTests = []struct {
 				t.Fatalf("Retrieved value mismatch: have %v/%v, want %v/%v", res.A, res.B, "Hi", 1)
 			}
 		`,
+		nil,
 	},
 	// Tests that arrays/slices can be properly returned and deserialized.
 	// Only addresses are tested, remainder just compiled to keep the test small.
@@ -414,6 +425,7 @@ var bindTests = []struct {
 					t.Fatalf("Slice return mismatch: have %v, want %v", out, []common.Address{auth.From, common.Address{}})
 			}
 		`,
+		nil,
 	},
 	// Tests that anonymous default methods can be correctly invoked
 	{
@@ -459,6 +471,7 @@ var bindTests = []struct {
 				t.Fatalf("Address mismatch: have %v, want %v", caller, auth.From)
 			}
 		`,
+		nil,
 	},
 	// Tests that non-existent contracts are reported as such (though only simulator test)
 	{
@@ -492,6 +505,7 @@ var bindTests = []struct {
 				t.Fatalf("Error mismatch: have %v, want %v", err, bind.ErrNoCode)
 			}
 		`,
+		nil,
 	},
 	// Tests that gas estimation works for contracts with weird gas mechanics too.
 	{
@@ -542,6 +556,7 @@ var bindTests = []struct {
 				t.Fatalf("Field mismatch: have %v, want %v", field, "automatic")
 			}
 		`,
+		nil,
 	},
 	// Test that constant functions can be called from an (optional) specified address
 	{
@@ -590,6 +605,7 @@ var bindTests = []struct {
 				}
 			}
 		`,
+		nil,
 	},
 	// Tests that methods and returns with underscores inside work correctly.
 	{
@@ -664,6 +680,7 @@ var bindTests = []struct {
 
 			fmt.Println(a, b, err)
 		`,
+		nil,
 	},
 	// Tests that logs can be successfully filtered and decoded.
 	{
@@ -757,6 +774,17 @@ var bindTests = []struct {
 			if sit.Event.Value.Uint64() != 33 || !sit.Event.Flag {
 				t.Errorf("simple log content mismatch: have %v, want {33, true}", sit.Event)
 			}
+			// Ensure raw logs can be parsed into typed events directly too
+			parsed, err := eventer.ParseSimpleEvent(sit.Event.Raw)
+			if err != nil {
+				t.Fatalf("failed to parse simple event: %v", err)
+			}
+			if parsed.Addr != sit.Event.Addr || parsed.Id != sit.Event.Id || parsed.Flag != sit.Event.Flag || parsed.Value.Cmp(sit.Event.Value) != 0 {
+				t.Errorf("parsed log content mismatch: have %v, want %v", parsed, sit.Event)
+			}
+			if _, err := eventer.ParseNodataEvent(sit.Event.Raw); err == nil {
+				t.Errorf("parsed simple event as nodata event")
+			}
 
 			if sit.Next() {
 				t.Errorf("unexpected simple event found: %+v", sit.Event)
@@ -845,6 +873,7 @@ var bindTests = []struct {
 			case <-time.After(250 * time.Millisecond):
 			}
 		`,
+		nil,
 	},
 	{
 		`DeeplyNestedArray`,
@@ -920,6 +949,194 @@ var bindTests = []struct {
 				t.Fatalf("Retrieved value does not match expected value! got: %d, expected: %d. %v", retrievedArr[4][3][2], testArr[4][3][2], err)
 			}
 		`,
+		nil,
+	},
+	// Tests that tuple types are bound to named Go structs which round-trip through
+	// the generated methods. The contract is a hand assembled echo, returning its
+	// input sans method selector, as input and output encodings match.
+	{
+		`Structer`,
+		`
+			pragma experimental ABIEncoderV2;
+
+			contract Structer {
+				struct Point { uint256 x; uint256 y; }
+				struct Path  { string name; Point[] points; }
+				struct Flag  { bool flag; } // internalType stripped from the ABI
+
+				event Moved(Point from, Point to);
+
+				constructor(Point memory origin) public {}
+
+				function echo(Path memory path) public view returns (Path memory) { return path; }
+				function pair(Point[2] memory a, Flag memory b) public view returns (Point[2] memory, Flag memory) { return (a, b); }
+				function add(Point memory p) public {}
+			}
+		`,
+		`600e600c600039600e6000f336600490038060046000376000f3`,
+		`[{"inputs":[{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point","name":"origin","type":"tuple"}],"payable":false,"stateMutability":"nonpayable","type":"constructor"},{"constant":true,"inputs":[{"components":[{"internalType":"string","name":"name","type":"string"},{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point[]","name":"points","type":"tuple[]"}],"internalType":"struct Structer.Path","name":"path","type":"tuple"}],"name":"echo","outputs":[{"components":[{"internalType":"string","name":"name","type":"string"},{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point[]","name":"points","type":"tuple[]"}],"internalType":"struct Structer.Path","name":"","type":"tuple"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point[2]","name":"a","type":"tuple[2]"},{"components":[{"name":"flag","type":"bool"}],"name":"b","type":"tuple"}],"name":"pair","outputs":[{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point[2]","name":"a","type":"tuple[2]"},{"components":[{"name":"flag","type":"bool"}],"name":"b","type":"tuple"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":false,"inputs":[{"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point","name":"p","type":"tuple"}],"name":"add","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},{"anonymous":false,"inputs":[{"indexed":false,"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point","name":"from","type":"tuple"},{"indexed":false,"components":[{"internalType":"uint256","name":"x","type":"uint256"},{"internalType":"uint256","name":"y","type":"uint256"}],"internalType":"struct Structer.Point","name":"to","type":"tuple"}],"name":"Moved","type":"event"}]`,
+		`
+			"math/big"
+			"reflect"
+
+			"github.com/ethereum/go-ethereum/accounts/abi/bind"
+			"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+			"github.com/ethereum/go-ethereum/common"
+			"github.com/ethereum/go-ethereum/core"
+			"github.com/ethereum/go-ethereum/crypto"
+		`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}}, 10000000)
+
+			// Deploy the echo contract, passing a struct to the constructor
+			_, _, structer, err := DeployStructer(auth, sim, StructerPoint{X: big.NewInt(1), Y: big.NewInt(2)})
+			if err != nil {
+				t.Fatalf("Failed to deploy structer contract: %v", err)
+			}
+			sim.Commit()
+
+			// Round-trip a struct containing a slice of nested structs
+			path := StructerPath{Name: "diagonal", Points: []StructerPoint{{X: big.NewInt(1), Y: big.NewInt(1)}, {X: big.NewInt(2), Y: big.NewInt(2)}}}
+			if res, err := structer.Echo(nil, path); err != nil {
+				t.Fatalf("Failed to echo path: %v", err)
+			} else if !reflect.DeepEqual(res, path) {
+				t.Fatalf("Path mismatch: have %v, want %v", res, path)
+			}
+			// Round-trip an array of structs and an anonymous struct as named results
+			points := [2]StructerPoint{{X: big.NewInt(3), Y: big.NewInt(4)}, {X: big.NewInt(5), Y: big.NewInt(6)}}
+			flag := Struct0{Flag: true}
+
+			res, err := structer.Pair(nil, points, flag)
+			if err != nil {
+				t.Fatalf("Failed to echo pair: %v", err)
+			}
+			if !reflect.DeepEqual(res.A, points) {
+				t.Fatalf("Points mismatch: have %v, want %v", res.A, points)
+			}
+			if res.B != flag {
+				t.Fatalf("Flag mismatch: have %v, want %v", res.B, flag)
+			}
+			// Ensure structs can be passed in transactions and appear in events
+			if _, err := structer.Add(auth, StructerPoint{X: big.NewInt(7), Y: big.NewInt(8)}); err != nil {
+				t.Fatalf("Failed to add point: %v", err)
+			}
+			sim.Commit()
+
+			var _ = StructerMoved{From: StructerPoint{}, To: StructerPoint{}}
+
+			// Batch a few calls together, including one to a missing contract
+			batch := bind.NewCallBatch(sim)
+
+			echo := structer.BatchEcho(batch, path)
+			pair := structer.BatchPair(batch, points, flag)
+
+			missing, err := NewStructerCaller(common.Address{0xde, 0xad}, sim)
+			if err != nil {
+				t.Fatalf("Failed to bind missing contract: %v", err)
+			}
+			fail := missing.BatchEcho(batch, path)
+
+			if _, err := echo(); err == nil {
+				t.Fatalf("Batched result available before execution")
+			}
+			if err := batch.Execute(nil); err != nil {
+				t.Fatalf("Failed to execute batch: %v", err)
+			}
+			if res, err := echo(); err != nil {
+				t.Fatalf("Failed to echo batched path: %v", err)
+			} else if !reflect.DeepEqual(res, path) {
+				t.Fatalf("Batched path mismatch: have %v, want %v", res, path)
+			}
+			if res, err := pair(); err != nil {
+				t.Fatalf("Failed to echo batched pair: %v", err)
+			} else if !reflect.DeepEqual(res.A, points) || res.B != flag {
+				t.Fatalf("Batched pair mismatch: have %v, want %v/%v", res, points, flag)
+			}
+			if _, err := fail(); err != bind.ErrNoCode {
+				t.Fatalf("Missing contract error mismatch: have %v, want %v", err, bind.ErrNoCode)
+			}
+		`,
+		nil,
+	},
+	// Tests that contracts referencing libraries deploy and link them automatically.
+	// Both bytecodes are hand assembled: the library echoes its call data, and the
+	// contract returns the linked library address for any call.
+	{
+		`Linker`,
+		`library Linker {}`,
+		`600e600c600039600e6000f336600490038060046000376000f3`,
+		`[]`,
+		`
+			"math/big"
+
+			"github.com/ethereum/go-ethereum/accounts/abi/bind"
+			"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+			"github.com/ethereum/go-ethereum/core"
+			"github.com/ethereum/go-ethereum/crypto"
+		`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}}, 10000000)
+
+			if _, _, _, err := DeployLinker(auth, sim); err != nil {
+				t.Fatalf("Failed to deploy library: %v", err)
+			}
+		`,
+		nil,
+	},
+	{
+		`LinkedUser`,
+		`
+			import "linker.sol";
+
+			contract LinkedUser {
+				function lib() public view returns (address) { return address(Linker); }
+			}
+		`,
+		`601d600c600039601d6000f373__$6fa44e9a6daed42157bf4204bdef2ddba2$__60005260206000f3`,
+		`[{"constant":true,"inputs":[],"name":"lib","outputs":[{"name":"","type":"address"}],"payable":false,"stateMutability":"view","type":"function"}]`,
+		`
+			"bytes"
+			"context"
+			"math/big"
+
+			"github.com/ethereum/go-ethereum/accounts/abi/bind"
+			"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+			"github.com/ethereum/go-ethereum/common"
+			"github.com/ethereum/go-ethereum/core"
+			"github.com/ethereum/go-ethereum/crypto"
+		`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}}, 10000000)
+
+			// Deploy the contract, which should deploy and link the library too
+			_, _, user, err := DeployLinkedUser(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy linked contract: %v", err)
+			}
+			sim.Commit()
+
+			lib, err := user.Lib(nil)
+			if err != nil {
+				t.Fatalf("Failed to retrieve linked library: %v", err)
+			}
+			code, err := sim.CodeAt(context.Background(), lib, nil)
+			if err != nil {
+				t.Fatalf("Failed to retrieve library code: %v", err)
+			}
+			if want := common.FromHex("36600490038060046000376000f3"); !bytes.Equal(code, want) {
+				t.Fatalf("Library code mismatch: have %x, want %x", code, want)
+			}
+		`,
+		map[string]string{"__$6fa44e9a6daed42157bf4204bdef2ddba2$__": "Linker"},
 	},
 }
 
@@ -945,7 +1162,7 @@ func TestBindings(t *testing.T) {
 	// Generate the test suite for all the contracts
 	for i, tt := range bindTests {
 		// Generate the binding and create a Go source file in the workspace
-		bind, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", LangGo)
+		bind, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", LangGo, tt.libs)
 		if err != nil {
 			t.Fatalf("test %d: failed to generate binding: %v", i, err)
 		}
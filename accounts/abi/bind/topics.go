@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -32,68 +33,135 @@ func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
 	topics := make([][]common.Hash, len(query))
 	for i, filter := range query {
 		for _, rule := range filter {
-			var topic common.Hash
-
-			// Try to generate the topic based on simple types
-			switch rule := rule.(type) {
-			case common.Hash:
-				copy(topic[:], rule[:])
-			case common.Address:
-				copy(topic[common.HashLength-common.AddressLength:], rule[:])
-			case *big.Int:
-				blob := rule.Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case bool:
-				if rule {
-					topic[common.HashLength-1] = 1
-				}
-			case int8:
-				blob := big.NewInt(int64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case int16:
-				blob := big.NewInt(int64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case int32:
-				blob := big.NewInt(int64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case int64:
-				blob := big.NewInt(rule).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case uint8:
-				blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case uint16:
-				blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case uint32:
-				blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case uint64:
-				blob := new(big.Int).SetUint64(rule).Bytes()
-				copy(topic[common.HashLength-len(blob):], blob)
-			case string:
-				hash := crypto.Keccak256Hash([]byte(rule))
-				copy(topic[:], hash[:])
-			case []byte:
-				hash := crypto.Keccak256Hash(rule)
-				copy(topic[:], hash[:])
+			topic, err := makeTopic(rule)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
 
-			default:
-				// Attempt to generate the topic from funky types
-				val := reflect.ValueOf(rule)
+// makeTopic converts a single filter rule into the topic the value is indexed
+// by. Value types are encoded in place (numbers in two's complement, fixed size
+// byte arrays left aligned), whereas strings, byte slices, arrays and structs
+// are represented by the Keccak256 hash of their encoding.
+func makeTopic(rule interface{}) (common.Hash, error) {
+	var topic common.Hash
 
-				switch {
-				case val.Kind() == reflect.Array && reflect.TypeOf(rule).Elem().Kind() == reflect.Uint8:
-					reflect.Copy(reflect.ValueOf(topic[common.HashLength-val.Len():]), val)
+	// Try to generate the topic based on simple types
+	switch rule := rule.(type) {
+	case common.Hash:
+		copy(topic[:], rule[:])
+	case common.Address:
+		copy(topic[common.HashLength-common.AddressLength:], rule[:])
+	case *big.Int:
+		copy(topic[:], math.PaddedBigBytes(math.U256(new(big.Int).Set(rule)), common.HashLength))
+	case bool:
+		if rule {
+			topic[common.HashLength-1] = 1
+		}
+	case int8:
+		copy(topic[:], math.PaddedBigBytes(math.U256(big.NewInt(int64(rule))), common.HashLength))
+	case int16:
+		copy(topic[:], math.PaddedBigBytes(math.U256(big.NewInt(int64(rule))), common.HashLength))
+	case int32:
+		copy(topic[:], math.PaddedBigBytes(math.U256(big.NewInt(int64(rule))), common.HashLength))
+	case int64:
+		copy(topic[:], math.PaddedBigBytes(math.U256(big.NewInt(rule)), common.HashLength))
+	case uint8:
+		blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
+		copy(topic[common.HashLength-len(blob):], blob)
+	case uint16:
+		blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
+		copy(topic[common.HashLength-len(blob):], blob)
+	case uint32:
+		blob := new(big.Int).SetUint64(uint64(rule)).Bytes()
+		copy(topic[common.HashLength-len(blob):], blob)
+	case uint64:
+		blob := new(big.Int).SetUint64(rule).Bytes()
+		copy(topic[common.HashLength-len(blob):], blob)
+	case string:
+		hash := crypto.Keccak256Hash([]byte(rule))
+		copy(topic[:], hash[:])
+	case []byte:
+		hash := crypto.Keccak256Hash(rule)
+		copy(topic[:], hash[:])
 
-				default:
-					return nil, fmt.Errorf("unsupported indexed type: %T", rule)
-				}
+	default:
+		// Attempt to generate the topic from funky types
+		val := reflect.ValueOf(rule)
+
+		switch {
+		case val.Kind() == reflect.Array && val.Type().Elem().Kind() == reflect.Uint8:
+			// Fixed size byte arrays are right padded, same as in the ABI encoding
+			if val.Len() > common.HashLength {
+				return topic, fmt.Errorf("unsupported indexed type: %T", rule)
 			}
-			topics[i] = append(topics[i], topic)
+			reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+
+		case val.Kind() == reflect.Array || val.Kind() == reflect.Slice || val.Kind() == reflect.Struct:
+			// Composite types are hashed, just like strings and bytes
+			blob, err := packTopic(val)
+			if err != nil {
+				return topic, err
+			}
+			topic = crypto.Keccak256Hash(blob)
+
+		default:
+			return topic, fmt.Errorf("unsupported indexed type: %T", rule)
 		}
 	}
-	return topics, nil
+	return topic, nil
+}
+
+// packTopic returns the in-place encoding of a composite value used to derive
+// its topic: every element is padded to a multiple of 32 bytes and encoded back
+// to back recursively, without any length or offset fields.
+func packTopic(val reflect.Value) ([]byte, error) {
+	switch {
+	case val.Kind() == reflect.String:
+		return padTopicData([]byte(val.String())), nil
+
+	case val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8:
+		return padTopicData(val.Bytes()), nil
+
+	case val.Kind() == reflect.Array && val.Type().Elem().Kind() == reflect.Uint8:
+		// Fixed size byte arrays (and addresses) are single words, encoded below
+
+	case val.Kind() == reflect.Array || val.Kind() == reflect.Slice:
+		var blob []byte
+		for i := 0; i < val.Len(); i++ {
+			elem, err := packTopic(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			blob = append(blob, elem...)
+		}
+		return blob, nil
+
+	case val.Kind() == reflect.Struct:
+		var blob []byte
+		for i := 0; i < val.NumField(); i++ {
+			field, err := packTopic(val.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			blob = append(blob, field...)
+		}
+		return blob, nil
+	}
+	topic, err := makeTopic(val.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return topic[:], nil
+}
+
+// padTopicData right pads a dynamic byte blob to a multiple of 32 bytes.
+func padTopicData(data []byte) []byte {
+	return common.RightPadBytes(data, (len(data)+common.HashLength-1)/common.HashLength*common.HashLength)
 }
 
 // Big batch of reflect types for topic reconstruction.
@@ -105,8 +173,8 @@ var (
 
 // parseTopics converts the indexed topic fields into actual log field values.
 //
-// Note, dynamic types cannot be reconstructed since they get mapped to Keccak256
-// hashes as the topic value!
+// Note, dynamic and composite types (strings, bytes, arrays and structs) cannot
+// be reconstructed since they get mapped to Keccak256 hashes as the topic value!
 func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) error {
 	// Sanity check that the fields and topics match up
 	if len(fields) != len(topics) {
@@ -118,6 +186,9 @@ func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) er
 			return errors.New("non-indexed field in topic reconstruction")
 		}
 		field := reflect.ValueOf(out).Elem().FieldByName(capitalise(arg.Name))
+		if !field.IsValid() {
+			return fmt.Errorf("field %s can't be found in the given value", capitalise(arg.Name))
+		}
 
 		// Try to parse the topic back into the fields based on primitive types
 		switch field.Kind() {
@@ -170,13 +241,16 @@ func parseTopics(out interface{}, fields abi.Arguments, topics []common.Hash) er
 
 			case reflectBigInt:
 				num := new(big.Int).SetBytes(topics[0][:])
+				if arg.Type.T == abi.IntTy {
+					num = math.S256(num)
+				}
 				field.Set(reflect.ValueOf(num))
 
 			default:
 				// Ran out of custom types, try the crazies
 				switch {
 				case arg.Type.T == abi.FixedBytesTy:
-					reflect.Copy(field, reflect.ValueOf(topics[0][common.HashLength-arg.Type.Size:]))
+					reflect.Copy(field, reflect.ValueOf(topics[0][:arg.Type.Size]))
 
 				default:
 					return fmt.Errorf("unsupported indexed type: %v", arg.Type)
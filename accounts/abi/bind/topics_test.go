@@ -0,0 +1,116 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that filter rules are converted into topics the same way Solidity
+// indexes event arguments.
+func TestMakeTopics(t *testing.T) {
+	word := func(n int64) []byte {
+		return common.LeftPadBytes(big.NewInt(n).Bytes(), 32)
+	}
+	tests := []struct {
+		name string
+		rule interface{}
+		want common.Hash
+	}{
+		{"uint", big.NewInt(1), common.BytesToHash(word(1))},
+		{"negative int", big.NewInt(-1), common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+		{"negative int8", int8(-2), common.HexToHash("0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe")},
+		{"bool", true, common.BytesToHash(word(1))},
+		{"address", common.Address{1}, common.HexToHash("0x0000000000000000000000000100000000000000000000000000000000000000")},
+		{"bytes4", [4]byte{1, 2, 3, 4}, common.HexToHash("0x0102030400000000000000000000000000000000000000000000000000000000")},
+		{"string", "hello", crypto.Keccak256Hash([]byte("hello"))},
+		{"bytes", []byte{1, 2, 3}, crypto.Keccak256Hash([]byte{1, 2, 3})},
+		{"uint array", [2]*big.Int{big.NewInt(1), big.NewInt(2)}, crypto.Keccak256Hash(word(1), word(2))},
+		{"uint slice", []*big.Int{big.NewInt(1), big.NewInt(2)}, crypto.Keccak256Hash(word(1), word(2))},
+		{"string slice", []string{"a", "b"}, crypto.Keccak256Hash(common.RightPadBytes([]byte("a"), 32), common.RightPadBytes([]byte("b"), 32))},
+		{"struct", struct {
+			A *big.Int
+			B bool
+		}{big.NewInt(3), true}, crypto.Keccak256Hash(word(3), word(1))},
+	}
+	for _, tt := range tests {
+		topics, err := makeTopics([]interface{}{tt.rule})
+		if err != nil {
+			t.Errorf("%s: failed to make topic: %v", tt.name, err)
+			continue
+		}
+		if topics[0][0] != tt.want {
+			t.Errorf("%s: topic mismatch: have %x, want %x", tt.name, topics[0][0], tt.want)
+		}
+	}
+}
+
+// Tests that indexed values can be reconstructed from topics.
+func TestParseTopics(t *testing.T) {
+	newType := func(kind string) abi.Type {
+		typ, err := abi.NewType(kind, nil)
+		if err != nil {
+			t.Fatalf("failed to create type %s: %v", kind, err)
+		}
+		return typ
+	}
+	fields := abi.Arguments{
+		{Name: "num", Type: newType("int256"), Indexed: true},
+		{Name: "small", Type: newType("int16"), Indexed: true},
+		{Name: "id", Type: newType("bytes4"), Indexed: true},
+		{Name: "str", Type: newType("string"), Indexed: true},
+	}
+	var (
+		num   = big.NewInt(-5)
+		small = int16(-7)
+		id    = [4]byte{1, 2, 3, 4}
+		str   = "hello"
+	)
+	topics, err := makeTopics([]interface{}{num}, []interface{}{small}, []interface{}{id}, []interface{}{str})
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	var out struct {
+		Num   *big.Int
+		Small int16
+		Id    [4]byte
+		Str   common.Hash
+	}
+	if err := parseTopics(&out, fields, []common.Hash{topics[0][0], topics[1][0], topics[2][0], topics[3][0]}); err != nil {
+		t.Fatalf("failed to parse topics: %v", err)
+	}
+	want := struct {
+		Num   *big.Int
+		Small int16
+		Id    [4]byte
+		Str   common.Hash
+	}{num, small, id, crypto.Keccak256Hash([]byte(str))}
+
+	if out.Num.Cmp(want.Num) != 0 || out.Small != want.Small || out.Id != want.Id || out.Str != want.Str {
+		t.Errorf("parsed topics mismatch: have %+v, want %+v", out, want)
+	}
+	// Ensure missing fields are reported instead of crashing
+	var missing struct{ Other *big.Int }
+	if err := parseTopics(&missing, fields[:1], topics[0]); err == nil {
+		t.Errorf("missing field not reported")
+	}
+}
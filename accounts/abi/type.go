@@ -53,6 +53,7 @@ type Type struct {
 	stringKind string // holds the unparsed string for deriving signatures
 
 	// Tuple relative fields
+	TupleRawName  string   // Raw struct name defined in source code, may be empty.
 	TupleElems    []*Type  // Type information of all tuple fields
 	TupleRawNames []string // Raw field name of all tuple fields
 }
@@ -63,7 +64,14 @@ var (
 )
 
 // NewType creates a new reflection type of abi type given in t.
-func NewType(t string, components []ArgumentMarshaling) (typ Type, err error) {
+func NewType(t string, components []ArgumentMarshaling) (Type, error) {
+	return newType(t, "", components)
+}
+
+// newType creates a new reflection type of abi type given in t, additionally
+// tracking the source-level struct name of tuples through internalType (e.g.
+// "struct Contract.Point[]"), as emitted by recent Solidity compilers.
+func newType(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
 	// check that array brackets are equal if they exist
 	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("invalid arg type in abi")
@@ -75,8 +83,12 @@ func NewType(t string, components []ArgumentMarshaling) (typ Type, err error) {
 	// recursively create the type
 	if strings.Count(t, "[") != 0 {
 		i := strings.LastIndex(t, "[")
-		// recursively embed the type
-		embeddedType, err := NewType(t[:i], components)
+		// recursively embed the type, stripping the same dimension off the
+		// internal type if there's one available
+		if j := strings.LastIndex(internalType, "["); j != -1 {
+			internalType = internalType[:j]
+		}
+		embeddedType, err := newType(t[:i], internalType, components)
 		if err != nil {
 			return Type{}, err
 		}
@@ -178,7 +190,7 @@ func NewType(t string, components []ArgumentMarshaling) (typ Type, err error) {
 		)
 		expression += "("
 		for idx, c := range components {
-			cType, err := NewType(c.Type, c.Components)
+			cType, err := newType(c.Type, c.InternalType, c.Components)
 			if err != nil {
 				return Type{}, err
 			}
@@ -203,6 +215,13 @@ func NewType(t string, components []ArgumentMarshaling) (typ Type, err error) {
 		typ.TupleRawNames = names
 		typ.T = TupleTy
 		typ.stringKind = expression
+
+		const structPrefix = "struct "
+		if strings.HasPrefix(internalType, structPrefix) {
+			// Foreign struct definitions are qualified with the declaring
+			// contract's name, fold it into the raw name (Contract.S -> ContractS).
+			typ.TupleRawName = strings.Replace(internalType[len(structPrefix):], ".", "", -1)
+		}
 	case "function":
 		typ.Kind = reflect.Array
 		typ.T = FunctionTy
@@ -336,8 +355,8 @@ func isDynamicType(t Type) bool {
 // to store the location reference for actual value storage.
 func getTypeSize(t Type) int {
 	if t.T == ArrayTy && !isDynamicType(*t.Elem) {
-		// Recursively calculate type size if it is a nested array
-		if t.Elem.T == ArrayTy {
+		// Recursively calculate type size if it is a nested array or tuple
+		if t.Elem.T == ArrayTy || t.Elem.T == TupleTy {
 			return t.Size * getTypeSize(*t.Elem)
 		}
 		return t.Size * 32
@@ -300,3 +300,31 @@ func TestTypeCheck(t *testing.T) {
 		}
 	}
 }
+
+// Tests that source level struct names are extracted from the internal type
+// annotations of tuples, including tuples nested in arrays and slices.
+func TestTypeInternalTupleName(t *testing.T) {
+	var arg Argument
+	input := `{"name":"a","type":"tuple[2][]","internalType":"struct Foo.Bar[2][]","components":[{"name":"x","type":"uint256","internalType":"uint256"},{"name":"y","type":"tuple","internalType":"struct Baz","components":[{"name":"z","type":"bool","internalType":"bool"}]}]}`
+	if err := arg.UnmarshalJSON([]byte(input)); err != nil {
+		t.Fatalf("failed to parse argument: %v", err)
+	}
+	tuple := arg.Type.Elem.Elem
+	if tuple.T != TupleTy {
+		t.Fatalf("element type mismatch: have %d, want %d", tuple.T, TupleTy)
+	}
+	if tuple.TupleRawName != "FooBar" {
+		t.Errorf("outer tuple name mismatch: have %q, want %q", tuple.TupleRawName, "FooBar")
+	}
+	if name := tuple.TupleElems[1].TupleRawName; name != "Baz" {
+		t.Errorf("inner tuple name mismatch: have %q, want %q", name, "Baz")
+	}
+	// Tuples without internal type information should stay anonymous
+	typ, err := NewType("tuple", []ArgumentMarshaling{{Name: "a", Type: "uint256"}})
+	if err != nil {
+		t.Fatalf("failed to create type: %v", err)
+	}
+	if typ.TupleRawName != "" {
+		t.Errorf("anonymous tuple name mismatch: have %q, want empty", typ.TupleRawName)
+	}
+}
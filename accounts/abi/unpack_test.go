@@ -1041,6 +1041,51 @@ func TestUnpackTuple(t *testing.T) {
 	}
 }
 
+// Tests that a single tuple output can be unpacked directly into a struct, and
+// that static arrays of static tuples are fully skipped over when decoding.
+func TestUnpackTupleStruct(t *testing.T) {
+	const def = `[
+		{"name":"single","constant":true,"outputs":[{"type":"tuple","name":"","components":[{"type":"uint256","name":"x"},{"type":"bool","name":"y"}]}]},
+		{"name":"array","constant":true,"outputs":[{"type":"tuple[2]","name":"p","components":[{"type":"uint256","name":"x"},{"type":"bool","name":"y"}]},{"type":"uint256","name":"a"}]}
+	]`
+	abi, err := JSON(strings.NewReader(def))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type Point struct {
+		X *big.Int
+		Y bool
+	}
+	buff := new(bytes.Buffer)
+	buff.Write(common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000005")) // x = 5
+	buff.Write(common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000001")) // y = true
+
+	var point Point
+	if err := abi.Unpack(&point, "single", buff.Bytes()); err != nil {
+		t.Fatalf("failed to unpack single tuple: %v", err)
+	}
+	if point.X.Cmp(big.NewInt(5)) != 0 || !point.Y {
+		t.Errorf("single tuple mismatch: have %v, want {5 true}", point)
+	}
+	buff.Write(common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000006")) // p[1].x = 6
+	buff.Write(common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000000")) // p[1].y = false
+	buff.Write(common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000007")) // a = 7
+
+	var ret struct {
+		P [2]Point
+		A *big.Int
+	}
+	if err := abi.Unpack(&ret, "array", buff.Bytes()); err != nil {
+		t.Fatalf("failed to unpack tuple array: %v", err)
+	}
+	if ret.P[1].X.Cmp(big.NewInt(6)) != 0 || ret.P[1].Y {
+		t.Errorf("tuple array mismatch: have %v, want {6 false}", ret.P[1])
+	}
+	if ret.A.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("trailing value mismatch: have %v, want 7", ret.A)
+	}
+}
+
 func TestOOMMaliciousInput(t *testing.T) {
 	oomTests := []unpackTest{
 		{
@@ -18,11 +18,13 @@
 package accounts
 
 import (
+	"fmt"
 	"math/big"
 
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 )
 
@@ -33,6 +35,15 @@ type Account struct {
 	URL     URL            `json:"url"`     // Optional resource locator within a backend
 }
 
+// Content types of data that can be signed via Wallet.SignData. They allow the
+// wallet, or the external signer behind it, to interpret the data to be signed.
+const (
+	MimetypeTextWithValidator = "data/validator"
+	MimetypeTypedData         = "data/typed"
+	MimetypeClique            = "application/x-clique-header"
+	MimetypeTextPlain         = "text/plain"
+)
+
 // Wallet represents a software or hardware wallet that might contain one or more
 // accounts (derived from the same seed).
 type Wallet interface {
@@ -100,6 +111,33 @@ type Wallet interface {
 	// the account in a keystore).
 	SignHash(account Account, hash []byte) ([]byte, error)
 
+	// SignData requests the wallet to sign the hash of the given data, which is
+	// of the specified content type.
+	//
+	// Wallets backed by local keys sign the keccak256 hash of the data, whereas
+	// external signers may use the content type to decode and display the data
+	// before deciding how to hash and sign it.
+	//
+	// It looks up the account specified either solely via its address contained within,
+	// or optionally with the aid of any location metadata from the embedded URL field.
+	SignData(account Account, mimeType string, data []byte) ([]byte, error)
+
+	// SignText requests the wallet to sign the hash of a given piece of data, prefixed
+	// by the Ethereum prefix scheme (see TextHash).
+	//
+	// It looks up the account specified either solely via its address contained within,
+	// or optionally with the aid of any location metadata from the embedded URL field.
+	//
+	// If the wallet requires additional authentication to sign the request (e.g.
+	// a password to decrypt the account, or a PIN code to verify the transaction),
+	// an AuthNeededError instance will be returned, containing infos for the user
+	// about which fields or actions are needed. The user may retry by providing
+	// the needed details via SignTextWithPassphrase, or by other means (e.g. unlock
+	// the account in a keystore).
+	//
+	// The produced signature has its V value in 0/1 form.
+	SignText(account Account, text []byte) ([]byte, error)
+
 	// SignTx requests the wallet to sign the given transaction.
 	//
 	// It looks up the account specified either solely via its address contained within,
@@ -120,6 +158,10 @@ type Wallet interface {
 	// or optionally with the aid of any location metadata from the embedded URL field.
 	SignHashWithPassphrase(account Account, passphrase string, hash []byte) ([]byte, error)
 
+	// SignTextWithPassphrase is identical to SignText, but also takes a password
+	// as extra authentication information.
+	SignTextWithPassphrase(account Account, passphrase string, text []byte) ([]byte, error)
+
 	// SignTxWithPassphrase requests the wallet to sign the given transaction, with the
 	// given passphrase as extra authentication information.
 	//
@@ -148,6 +190,18 @@ type Backend interface {
 	Subscribe(sink chan<- WalletEvent) event.Subscription
 }
 
+// TextHash is a helper function that calculates a hash for the given message that
+// can be safely used to calculate a signature from.
+//
+// The hash is calculated as
+//   keccak256("\x19Ethereum Signed Message:\n"${message length}${message}).
+//
+// This gives context to the signed message and prevents signing of transactions.
+func TextHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
+
 // WalletEventType represents the different event types that can be fired by
 // the wallet subscription subsystem.
 type WalletEventType int
@@ -0,0 +1,304 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend delegating to an external
+// signer, such as clef, which holds the keys and requests the user's approval
+// for every operation.
+package external
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ExternalBackendType is the reflect type of an external signer backend.
+var ExternalBackendType = reflect.TypeOf(&ExternalBackend{})
+
+// ExternalBackend is an account backend exposing the accounts of a single
+// external signer as one wallet.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend creates an account backend delegating all signing requests
+// to the external signer at the given endpoint, which is either an IPC path or an
+// HTTP URL.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{
+		signers: []accounts.Wallet{signer},
+	}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer as the
+// only wallet of the backend.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer is present for
+// the entire lifetime of the backend, so no wallet events are ever fired.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// Close implements io.Closer, releasing the connections to the signers. It is
+// called by the account manager when it is closed.
+func (eb *ExternalBackend) Close() error {
+	for _, signer := range eb.signers {
+		signer.Close()
+	}
+	return nil
+}
+
+// ExternalSigner is a wallet backed by the external API of a signer, reachable
+// over IPC or HTTP. All keys are held by the signer, which asks its user (or its
+// rules) to approve every request.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	cache   []accounts.Account // Accounts last listed by the signer, nil if never listed
+	cacheMu sync.RWMutex       // Mutex protecting the account cache
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint,
+// which is either an IPC path or an HTTP URL.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalSigner{
+		client:   client,
+		endpoint: endpoint,
+	}, nil
+}
+
+// String implements fmt.Stringer, returning the endpoint of the signer.
+func (api *ExternalSigner) String() string {
+	return fmt.Sprintf("external signer at %s", api.endpoint)
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{
+		Scheme: "extapi",
+		Path:   api.endpoint,
+	}
+}
+
+// Status implements accounts.Wallet. The connection to the signer is managed
+// by the RPC client, so the signer is always reported as available.
+func (api *ExternalSigner) Status() (string, error) {
+	return "ok", nil
+}
+
+// Open implements accounts.Wallet, but is not supported, since the external
+// signer unlocks its accounts on its own terms.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return accounts.ErrNotSupported
+}
+
+// Close implements accounts.Wallet, terminating the connection to the external
+// signer.
+func (api *ExternalSigner) Close() error {
+	api.client.Close()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, retrieving the list of accounts the
+// external signer is willing to disclose. Depending on the signer's policy, the
+// listing may need to be approved by its user.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	var addrs []common.Address
+	if err := api.client.Call(&addrs, "account_list"); err != nil {
+		log.Error("Failed to list accounts of external signer", "url", api.endpoint, "err", err)
+		return nil
+	}
+	accnts := make([]accounts.Account, 0, len(addrs))
+	for _, addr := range addrs {
+		accnts = append(accnts, accounts.Account{
+			Address: addr,
+			URL:     api.URL(),
+		})
+	}
+	api.cacheMu.Lock()
+	api.cache = accnts
+	api.cacheMu.Unlock()
+
+	return accnts
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account
+// was disclosed by the external signer. The accounts are only listed if they
+// were never retrieved before, to avoid bothering the signer's user.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	api.cacheMu.RLock()
+	cache := api.cache
+	api.cacheMu.RUnlock()
+
+	if cache == nil {
+		cache = api.Accounts()
+	}
+	for _, a := range cache {
+		if a.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == api.URL()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is a noop for external signers since
+// there is no notion of hierarchical account derivation for them.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers
+// since there is no notion of hierarchical account derivation for them.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {}
+
+// SignHash implements accounts.Wallet, however signing arbitrary hashes is not
+// supported by external signers, since they could not show the user what is
+// being signed. This method will always return an error.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignData implements accounts.Wallet, requesting the external signer to sign
+// the given data of the given content type with the account. The signer decides
+// how to hash the data based on the content type, and displays it to the user
+// for approval.
+func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	var (
+		res  hexutil.Bytes
+		addr = common.NewMixedcaseAddress(account.Address)
+	)
+	if err := api.client.Call(&res, "account_signData", mimeType, &addr, hexutil.Encode(data)); err != nil {
+		return nil, err
+	}
+	if len(res) != 65 {
+		return nil, fmt.Errorf("invalid signature length from external signer: %d", len(res))
+	}
+	// The signer produces V in 27/28 form for most content types, but wallets are
+	// expected to return the raw recovery id
+	if res[64] == 27 || res[64] == 28 {
+		res[64] -= 27
+	}
+	return res, nil
+}
+
+// SignText implements accounts.Wallet, requesting the external signer to sign
+// the given text as a personal message.
+func (api *ExternalSigner) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return api.SignData(account, accounts.MimetypeTextPlain, text)
+}
+
+// signTransactionArgs are the transaction fields sent to the external signer.
+type signTransactionArgs struct {
+	From     common.MixedcaseAddress  `json:"from"`
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice hexutil.Big              `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     hexutil.Bytes            `json:"data"`
+}
+
+// signTransactionResult is the signed transaction returned by the external signer.
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// SignTx implements accounts.Wallet, requesting the external signer to sign the
+// given transaction with the account. The signer's user may modify the transaction
+// before approving it, in which case the modified transaction is returned.
+//
+// The signer signs with its own configured chain id, which is verified to match
+// the requested one.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := &signTransactionArgs{
+		From:     common.NewMixedcaseAddress(account.Address),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	var res signTransactionResult
+	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	if res.Tx == nil {
+		return nil, fmt.Errorf("external signer returned no transaction")
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	sender, err := types.Sender(signer, res.Tx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), sender.Hex())
+	}
+	return res.Tx, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, however signing arbitrary
+// hashes is not supported by external signers, so this method will always return
+// an error.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return api.SignHash(account, hash)
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, requesting the external
+// signer to sign the given text. Since the signer authenticates its user on its
+// own, the passphrase is silently ignored.
+func (api *ExternalSigner) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return api.SignText(account, text)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, requesting the external
+// signer to sign the given transaction. Since the signer authenticates its user
+// on its own, the passphrase is silently ignored.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return api.SignTx(account, tx, chainID)
+}
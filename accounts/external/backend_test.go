@@ -0,0 +1,225 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _ = crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+	testChain  = big.NewInt(1337)
+)
+
+// MockTxArgs are the transaction fields received by the mock signer.
+type MockTxArgs struct {
+	From     common.MixedcaseAddress  `json:"from"`
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice hexutil.Big              `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     *hexutil.Bytes           `json:"data"`
+}
+
+// MockTxResult is the signed transaction returned by the mock signer.
+type MockTxResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// MockSigner is a minimal external signer holding a single account. It signs the
+// keccak256 hash of data with V in 27/28 form for all content types, and signs
+// transactions with a fixed chain id.
+type MockSigner struct {
+	requests []string
+	listed   int
+}
+
+func (s *MockSigner) List() []common.Address {
+	s.listed++
+	return []common.Address{testAddr}
+}
+
+func (s *MockSigner) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	s.requests = append(s.requests, contentType)
+
+	sig, err := crypto.Sign(crypto.Keccak256(data), testKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+func (s *MockSigner) SignTransaction(args MockTxArgs, methodSelector *string) (*MockTxResult, error) {
+	var tx *types.Transaction
+	if args.To == nil {
+		tx = types.NewContractCreation(uint64(args.Nonce), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), *args.Data)
+	} else {
+		tx = types.NewTransaction(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), *args.Data)
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(testChain), testKey)
+	if err != nil {
+		return nil, err
+	}
+	return &MockTxResult{Tx: signed}, nil
+}
+
+func newTestSigner(t *testing.T) (*ExternalSigner, *MockSigner, func()) {
+	service := new(MockSigner)
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", service); err != nil {
+		t.Fatalf("failed to register signer service: %v", err)
+	}
+	endpoint := httptest.NewServer(server)
+
+	signer, err := NewExternalSigner(endpoint.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	return signer, service, func() {
+		signer.Close()
+		endpoint.Close()
+		server.Stop()
+	}
+}
+
+// Tests that the accounts of the external signer are exposed via an account
+// manager, and are only listed once when looking them up.
+func TestExternalAccounts(t *testing.T) {
+	signer, service, teardown := newTestSigner(t)
+	defer teardown()
+
+	manager := accounts.NewManager(&ExternalBackend{signers: []accounts.Wallet{signer}})
+	defer manager.Close()
+
+	for i := 0; i < 2; i++ {
+		wallet, err := manager.Find(accounts.Account{Address: testAddr})
+		if err != nil {
+			t.Fatalf("failed to find account: %v", err)
+		}
+		if wallet != signer {
+			t.Fatalf("wallet mismatch: have %v, want %v", wallet, signer)
+		}
+	}
+	if service.listed != 1 {
+		t.Errorf("accounts listed %d times, want 1", service.listed)
+	}
+	if _, err := manager.Find(accounts.Account{Address: common.Address{1}}); err != accounts.ErrUnknownAccount {
+		t.Errorf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	accs := signer.Accounts()
+	if len(accs) != 1 || accs[0].Address != testAddr || accs[0].URL != signer.URL() {
+		t.Errorf("account list mismatch: have %v, want %x at %v", accs, testAddr, signer.URL())
+	}
+}
+
+// Tests that the connection to the external signer is released together with the
+// account manager using it.
+func TestExternalBackendClose(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("account", new(MockSigner)); err != nil {
+		t.Fatalf("failed to register signer service: %v", err)
+	}
+	signer := &ExternalSigner{client: rpc.DialInProc(server), endpoint: "inproc"}
+	backend := &ExternalBackend{signers: []accounts.Wallet{signer}}
+
+	manager := accounts.NewManager(backend)
+
+	// Unrelated wallet subscriptions must not affect the connection
+	backend.Subscribe(make(chan accounts.WalletEvent)).Unsubscribe()
+	if accs := signer.Accounts(); len(accs) != 1 {
+		t.Fatalf("account count mismatch: have %d, want 1", len(accs))
+	}
+	manager.Close()
+
+	var addrs []common.Address
+	if err := signer.client.Call(&addrs, "account_list"); err != rpc.ErrClientQuit {
+		t.Fatalf("signer connection error mismatch: have %v, want %v", err, rpc.ErrClientQuit)
+	}
+}
+
+// Tests that data is signed remotely with the requested content type, and that
+// clique signatures are converted to the raw recovery id.
+func TestExternalSignData(t *testing.T) {
+	signer, service, teardown := newTestSigner(t)
+	defer teardown()
+
+	account := accounts.Account{Address: testAddr}
+	for _, mimeType := range []string{accounts.MimetypeTextWithValidator, accounts.MimetypeClique} {
+		sig, err := signer.SignData(account, mimeType, []byte("data"))
+		if err != nil {
+			t.Fatalf("%s: failed to sign: %v", mimeType, err)
+		}
+		pubkey, err := crypto.SigToPub(crypto.Keccak256([]byte("data")), sig)
+		if err != nil {
+			t.Fatalf("%s: failed to recover signer: %v", mimeType, err)
+		}
+		if crypto.PubkeyToAddress(*pubkey) != testAddr {
+			t.Errorf("%s: signer mismatch", mimeType)
+		}
+	}
+	if _, err := signer.SignText(account, []byte("data")); err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	want := []string{accounts.MimetypeTextWithValidator, accounts.MimetypeClique, accounts.MimetypeTextPlain}
+	if len(service.requests) != len(want) {
+		t.Fatalf("content types not forwarded: have %v, want %v", service.requests, want)
+	}
+	for i := range want {
+		if service.requests[i] != want[i] {
+			t.Errorf("content type %d mismatch: have %s, want %s", i, service.requests[i], want[i])
+		}
+	}
+	if _, err := signer.SignHash(account, make([]byte, 32)); err != accounts.ErrNotSupported {
+		t.Errorf("hash signing error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
+
+// Tests that transactions are signed remotely, and that the chain id and sender
+// of the returned transaction are verified.
+func TestExternalSignTx(t *testing.T) {
+	signer, _, teardown := newTestSigner(t)
+	defer teardown()
+
+	tx := types.NewTransaction(1, common.Address{2}, big.NewInt(3), 21000, big.NewInt(4), []byte{5})
+	signed, err := signer.SignTxWithPassphrase(accounts.Account{Address: testAddr}, "ignored", tx, testChain)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if signed.Nonce() != tx.Nonce() || *signed.To() != *tx.To() || signed.Value().Cmp(tx.Value()) != 0 || signed.Gas() != tx.Gas() {
+		t.Errorf("transaction mismatch: have %v, want %v", signed, tx)
+	}
+	if _, err := signer.SignTx(accounts.Account{Address: testAddr}, tx, big.NewInt(1)); err == nil {
+		t.Errorf("chain id mismatch not detected")
+	}
+	if _, err := signer.SignTx(accounts.Account{Address: common.Address{1}}, tx, testChain); err == nil {
+		t.Errorf("sender mismatch not detected")
+	}
+}
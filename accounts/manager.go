@@ -17,6 +17,7 @@
 package accounts
 
 import (
+	"io"
 	"reflect"
 	"sort"
 	"sync"
@@ -70,11 +71,21 @@ func NewManager(backends ...Backend) *Manager {
 	return am
 }
 
-// Close terminates the account manager's internal notification processes.
+// Close terminates the account manager's internal notification processes and
+// closes the backends holding resources, i.e. implementing io.Closer.
 func (am *Manager) Close() error {
 	errc := make(chan error)
 	am.quit <- errc
-	return <-errc
+	err := <-errc
+
+	for _, backends := range am.backends {
+		for _, backend := range backends {
+			if closer, ok := backend.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+	return err
 }
 
 // update is the wallet event loop listening for notifications from the backends
@@ -501,6 +501,18 @@ func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error)
 	return nil, accounts.ErrNotSupported
 }
 
+// SignData implements accounts.Wallet, however signing arbitrary data is not
+// supported for hardware wallets, so this method will always return an error.
+func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// SignText implements accounts.Wallet, however signing arbitrary data is not
+// supported for hardware wallets, so this method will always return an error.
+func (w *wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
 // SignTx implements accounts.Wallet. It sends the transaction over to the Ledger
 // wallet to request a confirmation from the user. It returns either the signed
 // transaction or a failure if the user denied the transaction.
@@ -554,6 +566,13 @@ func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase str
 	return w.SignHash(account, hash)
 }
 
+// SignTextWithPassphrase implements accounts.Wallet, however signing arbitrary
+// data is not supported for hardware wallets, so this method will always return
+// an error.
+func (w *wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.SignText(account, text)
+}
+
 // SignTxWithPassphrase implements accounts.Wallet, attempting to sign the given
 // transaction with the given account using passphrase as extra authentication.
 // Since USB wallets don't rely on passphrases, these are silently ignored.
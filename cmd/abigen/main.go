@@ -22,10 +22,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 var (
@@ -35,6 +37,7 @@ var (
 
 	solFlag  = flag.String("sol", "", "Path to the Ethereum contract Solidity source to build and bind")
 	solcFlag = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
+	jsonFlag = flag.String("combined-json", "", "Path to the combined-json output of solc to bind, - for STDIN")
 	excFlag  = flag.String("exc", "", "Comma separated types to exclude from binding")
 
 	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
@@ -46,11 +49,14 @@ func main() {
 	// Parse and ensure all needed inputs are specified
 	flag.Parse()
 
-	if *abiFlag == "" && *solFlag == "" {
-		fmt.Printf("No contract ABI (--abi) or Solidity source (--sol) specified\n")
+	if *abiFlag == "" && *solFlag == "" && *jsonFlag == "" {
+		fmt.Printf("No contract ABI (--abi), Solidity source (--sol) or combined JSON (--combined-json) specified\n")
 		os.Exit(-1)
-	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && *solFlag != "" {
-		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
+	} else if (*abiFlag != "" || *binFlag != "" || *typFlag != "") && (*solFlag != "" || *jsonFlag != "") {
+		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) and combined JSON (--combined-json) flags\n")
+		os.Exit(-1)
+	} else if *solFlag != "" && *jsonFlag != "" {
+		fmt.Printf("Solidity source (--sol) and combined JSON (--combined-json) flags are mutually exclusive\n")
 		os.Exit(-1)
 	}
 	if *pkgFlag == "" {
@@ -74,8 +80,9 @@ func main() {
 		abis  []string
 		bins  []string
 		types []string
+		libs  = make(map[string]string)
 	)
-	if *solFlag != "" || (*abiFlag == "-" && *pkgFlag == "") {
+	if *solFlag != "" || *jsonFlag != "" || (*abiFlag == "-" && *pkgFlag == "") {
 		// Generate the list of types to exclude from binding
 		exclude := make(map[string]bool)
 		for _, kind := range strings.Split(*excFlag, ",") {
@@ -84,30 +91,53 @@ func main() {
 
 		var contracts map[string]*compiler.Contract
 		var err error
-		if *solFlag != "" {
+		switch {
+		case *solFlag != "":
 			contracts, err = compiler.CompileSolidity(*solcFlag, *solFlag)
 			if err != nil {
 				fmt.Printf("Failed to build Solidity contract: %v\n", err)
 				os.Exit(-1)
 			}
-		} else {
+		case *jsonFlag != "" && *jsonFlag != "-":
+			contracts, err = contractsFromFile(*jsonFlag)
+			if err != nil {
+				fmt.Printf("Failed to read input combined JSON: %v\n", err)
+				os.Exit(-1)
+			}
+		default:
 			contracts, err = contractsFromStdin()
 			if err != nil {
 				fmt.Printf("Failed to read input ABIs from STDIN: %v\n", err)
 				os.Exit(-1)
 			}
 		}
-		// Gather all non-excluded contract for binding
-		for name, contract := range contracts {
+		// Gather all non-excluded contract for binding, sorted for stable output
+		names := make([]string, 0, len(contracts))
+		for name := range contracts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
 			if exclude[strings.ToLower(name)] {
 				continue
 			}
+			contract := contracts[name]
+
 			abi, _ := json.Marshal(contract.Info.AbiDefinition) // Flatten the compiler parse
 			abis = append(abis, string(abi))
 			bins = append(bins, contract.Code)
 
 			nameParts := strings.Split(name, ":")
 			types = append(types, nameParts[len(nameParts)-1])
+
+			// Track the placeholders other contracts reference this one by, in
+			// case it's a library needing to be linked into their bytecode
+			if contract.Code != "" && contract.Code != "0x" {
+				for _, pattern := range libraryPlaceholders(name) {
+					libs[pattern] = nameParts[len(nameParts)-1]
+				}
+			}
 		}
 	} else {
 		// Otherwise load up the ABI, optional bytecode and type name from the parameters
@@ -140,7 +170,7 @@ func main() {
 		types = append(types, kind)
 	}
 	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, *pkgFlag, lang)
+	code, err := bind.Bind(types, abis, bins, *pkgFlag, lang, libs)
 	if err != nil {
 		fmt.Printf("Failed to generate ABI binding: %v\n", err)
 		os.Exit(-1)
@@ -163,3 +193,25 @@ func contractsFromStdin() (map[string]*compiler.Contract, error) {
 	}
 	return compiler.ParseCombinedJSON(bytes, "", "", "", "")
 }
+
+func contractsFromFile(path string) (map[string]*compiler.Contract, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return compiler.ParseCombinedJSON(bytes, "", "", "", "")
+}
+
+// libraryPlaceholders returns the placeholders solc leaves in the bytecode of
+// contracts referencing the library with the given fully qualified name (e.g.
+// "lib.sol:Math"), both in the legacy padded name and the newer hashed forms.
+func libraryPlaceholders(name string) []string {
+	legacy := name
+	if len(legacy) > 36 {
+		legacy = legacy[:36]
+	}
+	legacy = "__" + legacy + strings.Repeat("_", 38-len(legacy))
+	hashed := "__$" + crypto.Keccak256Hash([]byte(name)).Hex()[2:36] + "$__"
+
+	return []string{legacy, hashed}
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var commandDeploy = cli.Command{
+	Name:  "deploy",
+	Usage: "Deploy a new checkpoint oracle contract",
+	Flags: []cli.Flag{
+		signersFlag,
+		thresholdFlag,
+		sectionSizeFlag,
+		confirmsFlag,
+		keyFileFlag,
+		passwordFileFlag,
+	},
+	Action: deploy,
+}
+
+var commandStatus = cli.Command{
+	Name:   "status",
+	Usage:  "Fetches the signers and checkpoint status of the oracle contract",
+	Action: status,
+}
+
+var commandSign = cli.Command{
+	Name:  "sign",
+	Usage: "Sign the checkpoint with the specified key",
+	Flags: []cli.Flag{
+		indexFlag,
+		keyFileFlag,
+		passwordFileFlag,
+	},
+	Action: sign,
+}
+
+var commandPublish = cli.Command{
+	Name:  "publish",
+	Usage: "Publish a checkpoint into the oracle",
+	Flags: []cli.Flag{
+		indexFlag,
+		signatureFlag,
+		keyFileFlag,
+		passwordFileFlag,
+	},
+	Action: publish,
+}
+
+// deploy deploys the checkpoint registrar contract.
+//
+// Note the network where the contract is deployed depends on
+// the network where the connected node is located.
+func deploy(ctx *cli.Context) error {
+	// Gather all the addresses that should be permitted to sign
+	var addrs []common.Address
+	for _, account := range strings.Split(ctx.String(signersFlag.Name), ",") {
+		if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+			utils.Fatalf("Invalid account in --signers: '%s'", trimmed)
+		}
+		addrs = append(addrs, common.HexToAddress(account))
+	}
+	// Retrieve and validate the signing threshold
+	needed := ctx.Int64(thresholdFlag.Name)
+	if needed <= 0 || needed > int64(len(addrs)) {
+		utils.Fatalf("Invalid signature threshold %d", needed)
+	}
+	// Print a summary to ensure the user understands what they're signing
+	fmt.Printf("Deploying new checkpoint oracle:\n\n")
+	for i, addr := range addrs {
+		fmt.Printf("Admin %d => %s\n", i+1, addr.Hex())
+	}
+	fmt.Printf("\nSignatures needed to publish: %d\n", needed)
+
+	// Deploy the contract with the account of the given keyfile
+	key := getKey(ctx)
+	client := newClient(ctx)
+
+	oracle, tx, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(key.PrivateKey), client, addrs, new(big.Int).SetUint64(ctx.Uint64(sectionSizeFlag.Name)), new(big.Int).SetUint64(ctx.Uint64(confirmsFlag.Name)), big.NewInt(needed))
+	if err != nil {
+		utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+	}
+	log.Info("Deployed checkpoint oracle", "address", oracle, "tx", tx.Hash().Hex())
+	return nil
+}
+
+// status fetches the admin list and the latest checkpoint registered in the
+// oracle contract.
+func status(ctx *cli.Context) error {
+	// Create a wrapper around the checkpoint oracle contract
+	addr, oracle := newContract(ctx, newRPCClient(ctx.GlobalString(nodeURLFlag.Name)))
+	fmt.Printf("Oracle => %s\n", addr.Hex())
+	fmt.Println()
+
+	// Retrieve the list of authorized signers (admins)
+	admins, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		return err
+	}
+	for i, admin := range admins {
+		fmt.Printf("Admin %d => %s\n", i+1, admin.Hex())
+	}
+	fmt.Println()
+
+	// Retrieve the latest checkpoint
+	index, checkpoint, height, err := oracle.Contract().GetLatestCheckpoint(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Checkpoint (published at #%d) %d => %s\n", height, index, common.Hash(checkpoint).Hex())
+	return nil
+}
+
+// sign creates the signature for the specific checkpoint with the local key.
+// Only contract admins have the permission to sign a checkpoint.
+func sign(ctx *cli.Context) error {
+	// Resolve the oracle and the checkpoint to sign from the node
+	client := newRPCClient(ctx.GlobalString(nodeURLFlag.Name))
+	addr, oracle := newContract(ctx, client)
+	checkpoint := getCheckpoint(ctx, client)
+
+	// Ensure the key is one of the oracle admins
+	key := getKey(ctx)
+	admins, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		return err
+	}
+	var admin bool
+	for _, a := range admins {
+		if a == key.Address {
+			admin = true
+			break
+		}
+	}
+	if !admin {
+		utils.Fatalf("Signer %s is not an admin of the oracle", key.Address.Hex())
+	}
+	// Sign the checkpoint and print the signature
+	sig, err := crypto.Sign(sighash(addr, checkpoint.SectionIndex, checkpoint.Hash()), key.PrivateKey)
+	if err != nil {
+		utils.Fatalf("Failed to sign checkpoint: %v", err)
+	}
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+
+	fmt.Printf("Oracle     => %s\n", addr.Hex())
+	fmt.Printf("Index      => %d\n", checkpoint.SectionIndex)
+	fmt.Printf("Checkpoint => %s\n", checkpoint.Hash().Hex())
+	fmt.Printf("Signer     => %s\n", key.Address.Hex())
+	fmt.Printf("Signature  => %s\n", hexutil.Encode(sig))
+	return nil
+}
+
+// publish registers the specified checkpoint in the oracle contract with the
+// signatures collected from the admins.
+func publish(ctx *cli.Context) error {
+	// Resolve the oracle and the checkpoint to publish from the node
+	client := newRPCClient(ctx.GlobalString(nodeURLFlag.Name))
+	addr, oracle := newContract(ctx, client)
+	checkpoint := getCheckpoint(ctx, client)
+
+	// Recover the signers of the signatures, they must be sorted by address
+	hash := sighash(addr, checkpoint.SectionIndex, checkpoint.Hash())
+
+	var (
+		sigs    [][]byte
+		signers []common.Address
+	)
+	for _, hexsig := range strings.Split(ctx.String(signatureFlag.Name), ",") {
+		sig, err := hexutil.Decode(strings.TrimSpace(hexsig))
+		if err != nil || len(sig) != 65 {
+			utils.Fatalf("Invalid signature in --signatures: '%s'", hexsig)
+		}
+		rsv := common.CopyBytes(sig)
+		rsv[64] -= 27 // Transform V from 27/28 to 0/1 according to the yellow paper for recovery
+
+		pubkey, err := crypto.SigToPub(hash, rsv)
+		if err != nil {
+			utils.Fatalf("Failed to recover signer of '%s': %v", hexsig, err)
+		}
+		sigs = append(sigs, sig)
+		signers = append(signers, crypto.PubkeyToAddress(*pubkey))
+	}
+	sort.Sort(&signatureSorter{sigs: sigs, signers: signers})
+
+	// Ensure all signers are distinct admins of the oracle
+	admins, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		return err
+	}
+	for i, signer := range signers {
+		if i > 0 && signers[i-1] == signer {
+			utils.Fatalf("Duplicate signature from %s", signer.Hex())
+		}
+		var admin bool
+		for _, a := range admins {
+			if a == signer {
+				admin = true
+				break
+			}
+		}
+		if !admin {
+			utils.Fatalf("Signer %s is not an admin of the oracle", signer.Hex())
+		}
+	}
+	// Bind the transaction to the latest block of the chain for replay protection
+	head, err := ethclient.NewClient(client).HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve latest header: %v", err)
+	}
+	key := getKey(ctx)
+	tx, err := oracle.RegisterCheckpoint(bind.NewKeyedTransactor(key.PrivateKey), checkpoint.SectionIndex, checkpoint.Hash().Bytes(), head.Number, head.Hash(), sigs)
+	if err != nil {
+		utils.Fatalf("Failed to register checkpoint: %v", err)
+	}
+	log.Info("Successfully submitted checkpoint", "index", checkpoint.SectionIndex, "hash", checkpoint.Hash(), "tx", tx.Hash().Hex())
+	return nil
+}
+
+// signatureSorter sorts the signatures by the addresses of their signers, in
+// the order expected by the oracle contract.
+type signatureSorter struct {
+	sigs    [][]byte
+	signers []common.Address
+}
+
+func (s *signatureSorter) Len() int { return len(s.sigs) }
+func (s *signatureSorter) Less(i, j int) bool {
+	return bytes.Compare(s.signers[i].Bytes(), s.signers[j].Bytes()) < 0
+}
+func (s *signatureSorter) Swap(i, j int) {
+	s.sigs[i], s.sigs[j] = s.sigs[j], s.sigs[i]
+	s.signers[i], s.signers[j] = s.signers[j], s.signers[i]
+}
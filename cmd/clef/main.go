@@ -50,10 +50,10 @@ import (
 )
 
 // ExternalAPIVersion -- see extapi_changelog.md
-const ExternalAPIVersion = "4.0.0"
+const ExternalAPIVersion = "4.1.0"
 
 // InternalAPIVersion -- see intapi_changelog.md
-const InternalAPIVersion = "3.0.0"
+const InternalAPIVersion = "3.1.0"
 
 const legalWarning = `
 WARNING! 
@@ -115,6 +115,10 @@ var (
 		Usage: "Enable rule-engine",
 		Value: "rules.json",
 	}
+	nodeFlag = cli.StringFlag{
+		Name:  "node",
+		Usage: "Node (IPC path or HTTP/WS URL) to simulate transactions on before approval, requires the debug API",
+	}
 	stdiouiFlag = cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -172,6 +176,22 @@ Clef that the file is 'safe' to execute.`,
 remove any stored credential for that address (keyfile)
 `,
 	}
+	setPolicyCommand = cli.Command{
+		Action:    utils.MigrateFlags(setPolicy),
+		Name:      "setpolicy",
+		Usage:     "Store a declarative policy limiting automatic transaction approval",
+		ArgsUsage: "<policy.json>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			configdirFlag,
+			signerSecretFlag,
+		},
+		Description: `
+The setpolicy command validates the given policy file, and stores it encrypted within Clef's configuration. 
+Transactions violating the policy are never approved by the rule-engine, but always need manual approval. 
+
+Store an empty policy ({}) to lift all limits.`,
+	}
 )
 
 func init() {
@@ -195,12 +215,13 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		nodeFlag,
 		stdiouiFlag,
 		testFlag,
 		advancedMode,
 	}
 	app.Action = signer
-	app.Commands = []cli.Command{initCommand, attestCommand, setCredentialCommand}
+	app.Commands = []cli.Command{initCommand, attestCommand, setCredentialCommand, setPolicyCommand}
 
 }
 func main() {
@@ -319,6 +340,35 @@ func setCredential(ctx *cli.Context) error {
 	return nil
 }
 
+func setPolicy(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires a policy file to be passed as an argument.")
+	}
+	if err := initialize(ctx); err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read policy: %v", err)
+	}
+	if _, err := rules.ParsePolicy(blob); err != nil {
+		utils.Fatalf("Invalid policy: %v", err)
+	}
+	stretchedKey, err := readMasterKey(ctx, nil)
+	if err != nil {
+		utils.Fatalf(err.Error())
+	}
+	configDir := ctx.GlobalString(configdirFlag.Name)
+	vaultLocation := filepath.Join(configDir, common.Bytes2Hex(crypto.Keccak256([]byte("vault"), stretchedKey)[:10]))
+	confKey := crypto.Keccak256([]byte("config"), stretchedKey)
+
+	// Initialize the encrypted storages
+	configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confKey)
+	configStorage.Put("policy", string(blob))
+	log.Info("Policy updated", "file", ctx.Args().First())
+	return nil
+}
+
 func initialize(c *cli.Context) error {
 	// Set up the logger to print everything
 	logOutput := os.Stdout
@@ -359,8 +409,19 @@ func signer(c *cli.Context) error {
 	log.Info("Loaded 4byte db", "signatures", db.Size(), "file", fourByteDb, "local", fourByteLocal)
 
 	var (
-		api core.ExternalAPI
+		api   core.ExternalAPI
+		audit log.Logger
 	)
+	// Audit logging, shared by the API and the policy evaluator
+	if logfile := c.GlobalString(auditLogFlag.Name); logfile != "" {
+		audit, err = core.NewAuditLog(logfile)
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		log.Info("Audit logs configured", "file", logfile)
+	}
+	// Policy violations are always processed manually, bypassing the rules
+	manual := ui
 
 	configDir := c.GlobalString(configdirFlag.Name)
 	if stretchedKey, err := readMasterKey(c, ui); err != nil {
@@ -376,11 +437,13 @@ func signer(c *cli.Context) error {
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
+		policykey := crypto.Keccak256([]byte("policystorage"), stretchedKey)
 
 		// Initialize the encrypted storages
 		pwStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
+		policyStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "policystorage.json"), policykey)
 
 		//Do we have a rule-file?
 		ruleJS, err := ioutil.ReadFile(c.GlobalString(ruleFlag.Name))
@@ -404,23 +467,35 @@ func signer(c *cli.Context) error {
 				log.Info("Rule engine configured", "file", c.String(ruleFlag.Name))
 			}
 		}
+		// Do we have a policy? It's stored in the encrypted config, so needs no attestation
+		if blob := configStorage.Get("policy"); blob != "" {
+			policy, err := rules.ParsePolicy([]byte(blob))
+			if err != nil {
+				utils.Fatalf("Invalid policy: %v", err)
+			}
+			ui = rules.NewPolicyEvaluator(ui, manual, policy, policyStorage, audit)
+			log.Info("Signing policy configured")
+		}
 	}
 
+	// Connect to a node for transaction previews, if requested
+	var simulator *core.Simulator
+	if endpoint := c.GlobalString(nodeFlag.Name); endpoint != "" {
+		if simulator, err = core.NewSimulator(endpoint); err != nil {
+			utils.Fatalf("Failed to connect to node: %v", err)
+		}
+		log.Info("Transaction simulation enabled", "node", endpoint)
+	}
 	apiImpl := core.NewSignerAPI(
 		c.GlobalInt64(utils.NetworkIdFlag.Name),
 		c.GlobalString(keystoreFlag.Name),
 		c.GlobalBool(utils.NoUSBFlag.Name),
-		ui, db,
+		ui, db, simulator,
 		c.GlobalBool(utils.LightKDFFlag.Name),
 		c.GlobalBool(advancedMode.Name))
 	api = apiImpl
-	// Audit logging
-	if logfile := c.GlobalString(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
-		if err != nil {
-			utils.Fatalf(err.Error())
-		}
-		log.Info("Audit logs configured", "file", logfile)
+	if audit != nil {
+		api = core.NewAuditLogger(audit, api)
 	}
 	// register signer API with server
 	var (
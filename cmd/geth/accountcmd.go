@@ -204,6 +204,16 @@ func accountList(ctx *cli.Context) error {
 	return nil
 }
 
+// fetchKeystore retrieves the encrypted keystore from the account manager,
+// terminating if the accounts are delegated to an external signer.
+func fetchKeystore(am *accounts.Manager) *keystore.KeyStore {
+	keystores := am.Backends(keystore.KeyStoreType)
+	if len(keystores) == 0 {
+		utils.Fatalf("Keystore is not available, accounts are managed by the external signer")
+	}
+	return keystores[0].(*keystore.KeyStore)
+}
+
 // tries unlocking the specified account a few times.
 func unlockAccount(ctx *cli.Context, ks *keystore.KeyStore, address string, i int, passwords []string) (accounts.Account, string) {
 	account, err := utils.MakeAddress(ks, address)
@@ -299,6 +309,9 @@ func accountCreate(ctx *cli.Context) error {
 		}
 	}
 	utils.SetNodeConfig(ctx, &cfg.Node)
+	if cfg.Node.ExternalSigner != "" {
+		utils.Fatalf("Accounts are managed by the external signer, create them there")
+	}
 	scryptN, scryptP, keydir, err := cfg.Node.AccountConfig()
 
 	if err != nil {
@@ -323,7 +336,7 @@ func accountUpdate(ctx *cli.Context) error {
 		utils.Fatalf("No accounts specified to update")
 	}
 	stack, _ := makeConfigNode(ctx)
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := fetchKeystore(stack.AccountManager())
 
 	for _, addr := range ctx.Args() {
 		account, oldPassword := unlockAccount(ctx, ks, addr, 0, nil)
@@ -348,7 +361,7 @@ func importWallet(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	passphrase := getPassPhrase("", false, 0, utils.MakePasswordList(ctx))
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := fetchKeystore(stack.AccountManager())
 	acct, err := ks.ImportPreSaleKey(keyJSON, passphrase)
 	if err != nil {
 		utils.Fatalf("%v", err)
@@ -369,7 +382,7 @@ func accountImport(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	passphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	ks := fetchKeystore(stack.AccountManager())
 	acct, err := ks.ImportECDSA(key, passphrase)
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/instant"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -152,6 +153,10 @@ var (
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
+	DeveloperInstantFlag = cli.BoolFlag{
+		Name:  "dev.instant",
+		Usage: "Seal blocks in developer mode only when requested through the dev API",
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -164,7 +169,7 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "snap", "beam" or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -182,6 +187,15 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: eth.DefaultConfig.LightPeers,
 	}
+	ULCTrustedNodesFlag = cli.StringFlag{
+		Name:  "ulc.servers",
+		Usage: "Comma separated list of trusted ultra light servers (enode URLs), enables the ultra light client mode",
+	}
+	ULCMinTrustedFractionFlag = cli.IntFlag{
+		Name:  "ulc.fraction",
+		Usage: "Minimum percentage of trusted ultra light servers required to announce a new head",
+		Value: eth.DefaultULCMinTrustedFraction,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -259,6 +273,16 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for pending remote transactions to survive node restarts (disabled if empty)",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteJournalSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.remotejournalslots",
+		Usage: "Maximum number of pending remote transactions to journal on shutdown",
+		Value: core.DefaultTxPoolConfig.RemoteJournalSlots,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -391,6 +415,19 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerTxOrderingFlag = cli.StringFlag{
+		Name:  "miner.txordering",
+		Usage: `Ordering of the transactions in mined blocks ("price" or "fifo")`,
+		Value: "price",
+	}
+	MinerPrioritySendersFlag = cli.StringFlag{
+		Name:  "miner.prioritysenders",
+		Usage: "Comma separated accounts whose transactions are mined before any other",
+	}
+	MinerSenderGasQuotaFlag = cli.Uint64Flag{
+		Name:  "miner.sendergasquota",
+		Usage: "Maximum gas a single sender's transactions may use per mined block (0 = unlimited)",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -402,6 +439,11 @@ var (
 		Usage: "Password file to use for non-interactive password input",
 		Value: "",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer (IPC path or HTTP URL) holding the accounts, replacing the local keystore and USB wallets",
+		Value: "",
+	}
 
 	VMEnableDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
@@ -850,6 +892,9 @@ func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error
 		return accounts.Account{Address: common.HexToAddress(account)}, nil
 	}
 	// Otherwise try to interpret the account as a keystore index
+	if ks == nil {
+		return accounts.Account{}, fmt.Errorf("invalid account address %q, indices need a local keystore", account)
+	}
 	index, err := strconv.Atoi(account)
 	if err != nil || index < 0 {
 		return accounts.Account{}, fmt.Errorf("invalid account address or index %q", account)
@@ -913,7 +958,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setBootstrapNodes(ctx, cfg)
 	setBootstrapNodesV5(ctx, cfg)
 
-	lightClient := ctx.GlobalString(SyncModeFlag.Name) == "light"
+	lightClient := ctx.GlobalString(SyncModeFlag.Name) == "light" || ctx.GlobalIsSet(ULCTrustedNodesFlag.Name)
 	lightServer := ctx.GlobalInt(LightServFlag.Name) != 0
 	lightPeers := ctx.GlobalInt(LightPeersFlag.Name)
 
@@ -992,6 +1037,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setDataDir(ctx *cli.Context, cfg *node.Config) {
@@ -1036,6 +1084,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalSlotsFlag.Name) {
+		cfg.RemoteJournalSlots = ctx.GlobalUint64(TxPoolRemoteJournalSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1080,6 +1134,31 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
+// setULC configures the ultra light client mode from the command line flags.
+func setULC(ctx *cli.Context, cfg *eth.Config) {
+	servers := ctx.GlobalString(ULCTrustedNodesFlag.Name)
+	if servers == "" {
+		return
+	}
+	cfg.ULC = &eth.ULCConfig{MinTrustedFraction: ctx.GlobalInt(ULCMinTrustedFractionFlag.Name)}
+	for _, url := range strings.Split(servers, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			if _, err := enode.ParseV4(url); err != nil {
+				Fatalf("Invalid trusted ultra light server %s: %v", url, err)
+			}
+			cfg.ULC.TrustedServers = append(cfg.ULC.TrustedServers, url)
+		}
+	}
+	if cfg.ULC.MinTrustedFraction <= 0 || cfg.ULC.MinTrustedFraction > 100 {
+		Fatalf("Invalid minimum trusted fraction %d, must be within 1-100", cfg.ULC.MinTrustedFraction)
+	}
+	// The ultra light client is a special light client
+	if cfg.SyncMode != downloader.LightSync {
+		log.Info("Switching to light sync mode for ultra light client")
+		cfg.SyncMode = downloader.LightSync
+	}
+}
+
 func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
 	whitelist := ctx.GlobalString(WhitelistFlag.Name)
 	if whitelist == "" {
@@ -1162,8 +1241,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	// Avoid conflicting network flags
 	checkExclusive(ctx, DeveloperFlag, TestnetFlag, RinkebyFlag)
 	checkExclusive(ctx, LightServFlag, SyncModeFlag, "light")
+	checkExclusive(ctx, LightServFlag, ULCTrustedNodesFlag)
+	checkExclusive(ctx, DeveloperFlag, ExternalSignerFlag)
 
-	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	if ctx.GlobalBool(DeveloperInstantFlag.Name) && !ctx.GlobalBool(DeveloperFlag.Name) {
+		Fatalf("Option %q requires %q", DeveloperInstantFlag.Name, DeveloperFlag.Name)
+	}
+
+	// The keystore is unavailable if accounts are delegated to an external signer
+	var ks *keystore.KeyStore
+	if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
+		ks = keystores[0].(*keystore.KeyStore)
+	}
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
@@ -1179,6 +1268,8 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	setULC(ctx, cfg)
+
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1231,6 +1322,26 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.MinerNoverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerTxOrderingFlag.Name) {
+		switch ordering := ctx.GlobalString(MinerTxOrderingFlag.Name); ordering {
+		case "price", "fifo":
+			cfg.MinerTxOrdering = ordering
+		default:
+			Fatalf("Invalid transaction ordering %q, want \"price\" or \"fifo\"", ordering)
+		}
+	}
+	if ctx.GlobalIsSet(MinerPrioritySendersFlag.Name) {
+		for _, account := range strings.Split(ctx.GlobalString(MinerPrioritySendersFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid priority sender: %s", trimmed)
+			} else {
+				cfg.MinerPrioritySenders = append(cfg.MinerPrioritySenders, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.GlobalIsSet(MinerSenderGasQuotaFlag.Name) {
+		cfg.MinerSenderGasQuota = ctx.GlobalUint64(MinerSenderGasQuotaFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1278,7 +1389,11 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		}
 		log.Info("Using developer account", "address", developer.Address)
 
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		if ctx.GlobalBool(DeveloperInstantFlag.Name) {
+			cfg.Genesis = core.DeveloperInstantGenesisBlock(developer.Address)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		}
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) && !ctx.GlobalIsSet(MinerLegacyGasPriceFlag.Name) {
 			cfg.MinerGasPrice = big.NewInt(1)
 		}
@@ -1413,6 +1528,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var engine consensus.Engine
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.Instant != nil {
+		engine = instant.New()
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -162,7 +162,8 @@ func (s *Solidity) run(cmd *exec.Cmd, source string) (map[string]*Contract, erro
 // provided source, language and compiler version, and compiler options are all
 // passed through into the Contract structs.
 //
-// The solc output is expected to contain ABI, source mapping, user docs, and dev docs.
+// The solc output is expected to contain ABI and bytecode, optionally source
+// mapping, user docs, and dev docs.
 //
 // Returns an error if the JSON is malformed or missing data, or if the JSON
 // embedded within the JSON is malformed.
@@ -180,13 +181,19 @@ func ParseCombinedJSON(combinedJSON []byte, source string, languageVersion strin
 		if err := json.Unmarshal([]byte(info.Abi), &abi); err != nil {
 			return nil, fmt.Errorf("solc: error reading abi definition (%v)", err)
 		}
+		// Documentation is optional, the output may be generated without it
+		// (e.g. solc --combined-json abi,bin).
 		var userdoc interface{}
-		if err := json.Unmarshal([]byte(info.Userdoc), &userdoc); err != nil {
-			return nil, fmt.Errorf("solc: error reading user doc: %v", err)
+		if info.Userdoc != "" {
+			if err := json.Unmarshal([]byte(info.Userdoc), &userdoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading user doc: %v", err)
+			}
 		}
 		var devdoc interface{}
-		if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
-			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
+		if info.Devdoc != "" {
+			if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
+				return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
+			}
 		}
 		contracts[name] = &Contract{
 			Code:        "0x" + info.Bin,
@@ -75,3 +75,19 @@ func TestCompileError(t *testing.T) {
 	}
 	t.Logf("error: %v", err)
 }
+
+// Tests that combined JSON outputs without documentation can be parsed.
+func TestParseCombinedJSONNoDocs(t *testing.T) {
+	input := `{"contracts":{"test.sol:test":{"abi":"[]","bin":"6060"}},"version":"0.4.24"}`
+	contracts, err := ParseCombinedJSON([]byte(input), "", "", "", "")
+	if err != nil {
+		t.Fatalf("failed to parse combined JSON: %v", err)
+	}
+	c, ok := contracts["test.sol:test"]
+	if !ok {
+		t.Fatal("contract test.sol:test not found")
+	}
+	if c.Code != "0x6060" {
+		t.Errorf("bytecode mismatch: have %s, want 0x6060", c.Code)
+	}
+}
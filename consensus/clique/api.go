@@ -17,12 +17,36 @@
 package clique
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+const (
+	defaultStatusWindow = 64   // Number of recent blocks the signer status is reported for by default
+	maxStatusWindow     = 4096 // Maximum number of recent blocks the signer status may be requested for
+)
+
+// SignerStatus is the liveness report of a single signer within a window of
+// blocks.
+type SignerStatus struct {
+	Inturn     uint64 `json:"inturn"`     // Number of blocks signed in-turn
+	Noturn     uint64 `json:"noturn"`     // Number of blocks signed out-of-turn
+	Missed     uint64 `json:"missed"`     // Number of in-turn blocks signed by someone else
+	LastSigned uint64 `json:"lastSigned"` // Number of the last block signed (zero if unknown)
+}
+
+// Status is the liveness report of the signers within a window of blocks.
+type Status struct {
+	From          uint64                           `json:"from"`          // First block of the window
+	To            uint64                           `json:"to"`            // Last block of the window
+	InturnPercent float64                          `json:"inturnPercent"` // Percentage of blocks signed in-turn
+	Signers       map[common.Address]*SignerStatus `json:"signers"`       // Activity of the individual signers
+}
+
 // API is a user facing RPC API to allow controlling the signer and voting
 // mechanisms of the proof-of-authority scheme.
 type API struct {
@@ -117,3 +141,90 @@ func (api *API) Discard(address common.Address) {
 
 	delete(api.clique.proposals, address)
 }
+
+// Status reports the signing activity within the given number of most recent
+// blocks (64 by default, 4096 at most): the in-turn and out-of-turn blocks of each
+// signer, the turns missed (in-turn blocks signed by someone else) and the last
+// block signed. All currently authorized signers are reported, even if inactive.
+func (api *API) Status(window *uint64) (*Status, error) {
+	blocks := uint64(defaultStatusWindow)
+	if window != nil {
+		blocks = *window
+	}
+	if blocks > maxStatusWindow {
+		blocks = maxStatusWindow
+	}
+	header := api.chain.CurrentHeader()
+	end := header.Number.Uint64()
+	if blocks > end {
+		blocks = end // genesis is not signed
+	}
+	// Gather the headers of the window, oldest first
+	headers := make([]*types.Header, blocks)
+	for i := len(headers) - 1; i >= 0; i-- {
+		if header == nil || header.Number.Uint64() != end-blocks+uint64(i)+1 {
+			return nil, fmt.Errorf("missing header %d", end-blocks+uint64(i)+1)
+		}
+		headers[i] = header
+		header = api.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("missing header %d", end-blocks)
+	}
+	// Walk a snapshot forward across the window, checking each signer against the
+	// turns of its parent's snapshot
+	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{
+		From:    end - blocks + 1,
+		To:      end,
+		Signers: make(map[common.Address]*SignerStatus),
+	}
+	signer := func(address common.Address) *SignerStatus {
+		if status.Signers[address] == nil {
+			status.Signers[address] = new(SignerStatus)
+		}
+		return status.Signers[address]
+	}
+	var inturn uint64
+	for _, header := range headers {
+		number := header.Number.Uint64()
+
+		author, err := api.clique.Author(header)
+		if err != nil {
+			return nil, err
+		}
+		s := signer(author)
+		if s.LastSigned < number {
+			s.LastSigned = number
+		}
+		if snap.inturn(number, author) {
+			s.Inturn++
+			inturn++
+		} else {
+			s.Noturn++
+
+			signers := snap.signers()
+			signer(signers[number%uint64(len(signers))]).Missed++
+		}
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			return nil, err
+		}
+	}
+	for _, address := range snap.signers() {
+		signer(address)
+	}
+	// The last blocks of the signers are known from the snapshot, even beyond the
+	// window
+	for number, address := range snap.Recents {
+		if s := signer(address); s.LastSigned < number {
+			s.LastSigned = number
+		}
+	}
+	if blocks > 0 {
+		status.InturnPercent = float64(100*inturn) / float64(blocks)
+	}
+	return status, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the signer status reports the in-turn, out-of-turn and missed
+// blocks of the signers correctly.
+func TestStatus(t *testing.T) {
+	// Create three signers, ordered by their address to know their turns
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := accounts.address(names[i]), accounts.address(names[j])
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	genesis := &core.Genesis{ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal)}
+	for i, name := range names {
+		copy(genesis.ExtraData[extraVanity+i*common.AddressLength:], accounts.address(name).Bytes())
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.Commit(db)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1}
+	engine := New(config.Clique, db)
+	engine.fakeDiff = true
+
+	// Sign two blocks in-turn, followed by three out-of-turn ones
+	signers := []string{names[1], names[2], names[1], names[2], names[1]}
+	blocks, _ := core.GenerateChain(&config, genesis.ToBlock(db), engine, db, len(signers), nil)
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		header.Difficulty = diffInTurn
+
+		accounts.sign(header, signers[i])
+		blocks[i] = block.WithSeal(header)
+	}
+	// Enable the metrics to check that the liveness counters are only updated for
+	// canonical blocks, but not on verification or when the status is reported
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	counters := func() [3]int64 {
+		signer := accounts.address(names[1])
+		return [3]int64{signerCounter(signer, "inturn").Count(), signerCounter(signer, "noturn").Count(), signerCounter(signer, "missed").Count()}
+	}
+	before := counters()
+
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if err := engine.VerifyHeader(chain, blocks[len(blocks)-1].Header(), true); err != nil {
+		t.Fatalf("failed to reverify head: %v", err)
+	}
+	if have := counters(); have != before {
+		t.Fatalf("liveness counters changed by verification: have %v, want %v", have, before)
+	}
+	engine.TrackCanonical(chain, chain.CurrentHeader(), 0)
+	engine.TrackCanonical(chain, chain.CurrentHeader(), chain.CurrentHeader().Number.Uint64())
+	imported := counters()
+	if diff := [3]int64{imported[0] - before[0], imported[1] - before[1], imported[2] - before[2]}; diff != [3]int64{1, 2, 1} {
+		t.Fatalf("liveness counters mismatch after tracking: have %v, want %v", diff, [3]int64{1, 2, 1})
+	}
+	api := &API{chain: chain, clique: engine}
+
+	window := func(n uint64) *uint64 { return &n }
+
+	tests := []struct {
+		window  *uint64
+		from    uint64
+		percent float64
+		status  map[string]SignerStatus
+	}{
+		// Default window covering the entire chain
+		{
+			window: nil, from: 1, percent: 40,
+			status: map[string]SignerStatus{
+				names[0]: {Missed: 1},
+				names[1]: {Inturn: 1, Noturn: 2, Missed: 1, LastSigned: 5},
+				names[2]: {Inturn: 1, Noturn: 1, Missed: 1, LastSigned: 4},
+			},
+		},
+		// Limited window, only the last two blocks are scanned
+		{
+			window: window(2), from: 4, percent: 0,
+			status: map[string]SignerStatus{
+				names[0]: {},
+				names[1]: {Noturn: 1, Missed: 1, LastSigned: 5},
+				names[2]: {Noturn: 1, Missed: 1, LastSigned: 4},
+			},
+		},
+		// Empty window, last signed blocks are still known from the snapshot
+		{
+			window: window(0), from: 6, percent: 0,
+			status: map[string]SignerStatus{
+				names[0]: {},
+				names[1]: {LastSigned: 5},
+				names[2]: {LastSigned: 4},
+			},
+		},
+	}
+	for i, tt := range tests {
+		status, err := api.Status(tt.window)
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve status: %v", i, err)
+		}
+		if status.From != tt.from || status.To != 5 {
+			t.Errorf("test %d: window mismatch: have [%d, %d], want [%d, 5]", i, status.From, status.To, tt.from)
+		}
+		if status.InturnPercent != tt.percent {
+			t.Errorf("test %d: in-turn percentage mismatch: have %v, want %v", i, status.InturnPercent, tt.percent)
+		}
+		if len(status.Signers) != len(tt.status) {
+			t.Errorf("test %d: signer count mismatch: have %d, want %d", i, len(status.Signers), len(tt.status))
+		}
+		for name, want := range tt.status {
+			if have := status.Signers[accounts.address(name)]; have == nil || *have != want {
+				t.Errorf("test %d, signer %s: status mismatch: have %+v, want %+v", i, name, have, want)
+			}
+		}
+	}
+	if have := counters(); have != imported {
+		t.Errorf("liveness counters changed by status reports: have %v, want %v", have, imported)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the RLP handed to external signers hashes to the signature hash,
+// and that it is independent of the seal contained in the header.
+func TestCliqueRLP(t *testing.T) {
+	header := &types.Header{
+		ParentHash: common.HexToHash("0xbeef"),
+		Difficulty: diffInTurn,
+		Number:     big.NewInt(1),
+		GasLimit:   4712388,
+		Time:       big.NewInt(1545000000),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	blob := CliqueRLP(header)
+	if hash := crypto.Keccak256Hash(blob); hash != SigHash(header) {
+		t.Fatalf("signature hash mismatch: have %x, want %x", hash, SigHash(header))
+	}
+	copy(header.Extra[extraVanity:], common.FromHex("0x0102030405"))
+	if sealed := CliqueRLP(header); string(sealed) != string(blob) {
+		t.Fatalf("seal included in signing data")
+	}
+}
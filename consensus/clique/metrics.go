@@ -0,0 +1,96 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxTrackedBlocks is the maximum number of blocks accounted in the liveness
+// metrics on a single chain head update. Blocks imported in bulk without head
+// updates (e.g. by fast sync) are only accounted within this recent window.
+const maxTrackedBlocks = 1024
+
+var (
+	inturnBlockMeter = metrics.NewRegisteredMeter("clique/blocks/inturn", nil)
+	noturnBlockMeter = metrics.NewRegisteredMeter("clique/blocks/noturn", nil)
+	missedTurnMeter  = metrics.NewRegisteredMeter("clique/blocks/missed", nil)
+)
+
+// TrackCanonical accounts the canonical blocks above number after, up to the new
+// chain head, in the liveness metrics. It is meant to be called whenever the
+// chain head changes, as opposed to on header verification, which may happen
+// multiple times per block and for blocks that never become canonical.
+func (c *Clique) TrackCanonical(chain consensus.ChainReader, head *types.Header, after uint64) {
+	if !metrics.Enabled {
+		return
+	}
+	number := head.Number.Uint64()
+	if number <= after {
+		return
+	}
+	if number-after > maxTrackedBlocks {
+		after = number - maxTrackedBlocks
+	}
+	// Gather the new canonical headers, then account them in ascending order
+	headers := make([]*types.Header, 0, number-after)
+	for header := head; header != nil && header.Number.Uint64() > after; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+		headers = append(headers, header)
+	}
+	for i := len(headers) - 1; i >= 0; i-- {
+		header := headers[i]
+		number := header.Number.Uint64()
+
+		snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			log.Debug("Failed to account block liveness", "number", number, "hash", header.Hash(), "err", err)
+			return
+		}
+		signer, err := ecrecover(header, c.signatures)
+		if err != nil {
+			log.Debug("Failed to account block liveness", "number", number, "hash", header.Hash(), "err", err)
+			return
+		}
+		trackSigner(snap, number, signer)
+	}
+}
+
+// trackSigner updates the liveness metrics with a canonical block signed by the
+// given signer, checked against the snapshot of its parent. If the block was
+// signed out-of-turn, the in-turn signer missed its turn.
+func trackSigner(snap *Snapshot, number uint64, signer common.Address) {
+	if snap.inturn(number, signer) {
+		inturnBlockMeter.Mark(1)
+		signerCounter(signer, "inturn").Inc(1)
+		return
+	}
+	noturnBlockMeter.Mark(1)
+	signerCounter(signer, "noturn").Inc(1)
+
+	signers := snap.signers()
+	missedTurnMeter.Mark(1)
+	signerCounter(signers[number%uint64(len(signers))], "missed").Inc(1)
+}
+
+// signerCounter retrieves the liveness counter of the given kind of a signer.
+func signerCounter(signer common.Address, kind string) metrics.Counter {
+	return metrics.GetOrRegisterCounter("clique/signers/"+signer.Hex()+"/"+kind, nil)
+}
@@ -80,6 +80,7 @@ func newSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, number uin
 	for _, signer := range signers {
 		snap.Signers[signer] = struct{}{}
 	}
+	snap.transition(number + 1)
 	return snap
 }
 
@@ -285,6 +286,8 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 			}
 			delete(snap.Tally, header.Coinbase)
 		}
+		// If the signer set is overridden for the next block, authorize it already
+		snap.transition(number + 1)
 	}
 	snap.Number += uint64(len(headers))
 	snap.Hash = headers[len(headers)-1].Hash()
@@ -292,6 +295,23 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// transition replaces the set of authorized signers if the consensus config
+// schedules an override for the given block, discarding all pending votes and
+// recent signers.
+func (s *Snapshot) transition(number uint64) {
+	signers := s.config.TransitionSigners(number)
+	if signers == nil {
+		return
+	}
+	s.Signers = make(map[common.Address]struct{})
+	for _, signer := range signers {
+		s.Signers[signer] = struct{}{}
+	}
+	s.Recents = make(map[uint64]common.Address)
+	s.Votes = nil
+	s.Tally = make(map[common.Address]Tally)
+}
+
 // signers retrieves the list of authorized signers in ascending order.
 func (s *Snapshot) signers() []common.Address {
 	sigs := make([]common.Address, 0, len(s.Signers))
@@ -80,7 +80,7 @@ func (ap *testerAccountPool) sign(header *types.Header, signer string) {
 		ap.accounts[signer], _ = crypto.GenerateKey()
 	}
 	// Sign the header and embed the signature in extra data
-	sig, _ := crypto.Sign(sigHash(header).Bytes(), ap.accounts[signer])
+	sig, _ := crypto.Sign(SigHash(header).Bytes(), ap.accounts[signer])
 	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
 }
 
@@ -99,11 +99,12 @@ type testerVote struct {
 func TestClique(t *testing.T) {
 	// Define the various voting scenarios to test
 	tests := []struct {
-		epoch   uint64
-		signers []string
-		votes   []testerVote
-		results []string
-		failure error
+		epoch       uint64
+		signers     []string
+		transitions map[uint64][]string
+		votes       []testerVote
+		results     []string
+		failure     error
 	}{
 		{
 			// Single signer, no votes cast
@@ -374,6 +375,46 @@ func TestClique(t *testing.T) {
 				{signer: "A", newbatch: true},
 			},
 			failure: errRecentlySigned,
+		}, {
+			// Signer set transitions in the config should replace the signers from
+			// the scheduled block on, regardless of any votes
+			signers:     []string{"A", "B"},
+			transitions: map[uint64][]string{3: {"C", "D"}},
+			votes: []testerVote{
+				{signer: "A", voted: "E", auth: true},
+				{signer: "B", voted: "E", auth: true},
+				{signer: "C", checkpoint: []string{"C", "D"}},
+				{signer: "D"},
+			},
+			results: []string{"C", "D"},
+		}, {
+			// Signer set transitions should discard all pending votes
+			signers:     []string{"A", "B", "C"},
+			transitions: map[uint64][]string{2: {"A", "B", "C"}},
+			votes: []testerVote{
+				{signer: "A", voted: "D", auth: true},
+				{signer: "B", checkpoint: []string{"A", "B", "C"}},
+				{signer: "C", voted: "D", auth: true},
+			},
+			results: []string{"A", "B", "C"},
+		}, {
+			// Transition blocks must already be signed by the new signer set
+			signers:     []string{"A", "B"},
+			transitions: map[uint64][]string{2: {"C"}},
+			votes: []testerVote{
+				{signer: "A"},
+				{signer: "B", checkpoint: []string{"C"}},
+			},
+			failure: errUnauthorizedSigner,
+		}, {
+			// Transition blocks must contain the new signer set
+			signers:     []string{"A", "B"},
+			transitions: map[uint64][]string{2: {"B", "C"}},
+			votes: []testerVote{
+				{signer: "A"},
+				{signer: "B", checkpoint: []string{"A", "B"}},
+			},
+			failure: errMismatchingCheckpointSigners,
 		},
 	}
 	// Run through the scenarios and test them
@@ -409,6 +450,13 @@ func TestClique(t *testing.T) {
 			Period: 1,
 			Epoch:  tt.epoch,
 		}
+		for number, signers := range tt.transitions {
+			transition := params.CliqueTransition{Block: number}
+			for _, signer := range signers {
+				transition.Signers = append(transition.Signers, accounts.address(signer))
+			}
+			config.Clique.Transitions = append(config.Clique.Transitions, transition)
+		}
 		engine := New(config.Clique, db)
 		engine.fakeDiff = true
 
@@ -0,0 +1,243 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package instant implements a consensus engine for developer chains, sealing
+// blocks instantly whenever they are requested.
+package instant
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when a block without a number is verified, or
+	// the genesis block is attempted to be sealed.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidTimestamp is returned if the timestamp of a block is not later
+	// than the one of its parent.
+	errInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+)
+
+// Instant is the consensus engine of developer chains. Blocks carry no seal and
+// no block rewards, and are sealed right away whenever the miner requests it,
+// allowing tests to drive block production precisely.
+type Instant struct {
+	next uint64     // Timestamp of the next sealed block (0 = current time)
+	lock sync.Mutex // Protects the timestamp override
+}
+
+// New creates an instant-seal consensus engine.
+func New() *Instant {
+	return new(Instant)
+}
+
+// SetNextTimestamp overrides the timestamp of the next block sealed. The value
+// is only used if it is later than the parent's timestamp.
+func (e *Instant) SetNextTimestamp(time uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.next = time
+}
+
+// Author implements consensus.Engine, returning the header's coinbase as the
+// address of the account that created the block.
+func (e *Instant) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (e *Instant) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return e.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (e *Instant) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := e.verifyHeader(chain, header, headers[:i])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database. Timestamps are deliberately not checked
+// against the local clock, as developer chains may be moved into the future.
+func (e *Instant) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	// Retrieve the parent of the header, the genesis block is always valid
+	if number == 0 {
+		return nil
+	}
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	// Ensure the block is well formed, without caring about its seal
+	if uint64(len(header.Extra)) > params.MaximumExtraDataSize {
+		return fmt.Errorf("extra-data too long: %d > %d", len(header.Extra), params.MaximumExtraDataSize)
+	}
+	if header.Time.Cmp(parent.Time) <= 0 {
+		return errInvalidTimestamp
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(common.Big1) != 0 {
+		return errInvalidDifficulty
+	}
+	if header.UncleHash != types.CalcUncleHash(nil) {
+		return errInvalidUncleHash
+	}
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
+	}
+	return nil
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (e *Instant) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine. It's a noop for instant sealing as
+// blocks carry no seal.
+func (e *Instant) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	return nil
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top.
+func (e *Instant) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	number := header.Number.Uint64()
+
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Difficulty = e.CalcDifficulty(chain, header.Time.Uint64(), parent)
+
+	// Use the requested timestamp if any, ensuring it's later than the parent's
+	e.lock.Lock()
+	if e.next > parent.Time.Uint64() {
+		header.Time = new(big.Int).SetUint64(e.next)
+	}
+	e.lock.Unlock()
+
+	if header.Time.Cmp(parent.Time) <= 0 {
+		header.Time = new(big.Int).Add(parent.Time, common.Big1)
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, ensuring no uncles are set, nor block
+// rewards given, and returns the final block.
+func (e *Instant) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// Seal implements consensus.Engine, delivering the block as is, consuming the
+// timestamp override if the block was prepared with it.
+func (e *Instant) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	// Sealing the genesis block is not supported
+	if block.NumberU64() == 0 {
+		return errUnknownBlock
+	}
+	e.lock.Lock()
+	if e.next != 0 && block.Time().Uint64() >= e.next {
+		e.next = 0
+	}
+	e.lock.Unlock()
+
+	select {
+	case results <- block:
+	default:
+		log.Warn("Sealing result is not read by miner", "sealhash", e.SealHash(block.Header()))
+	}
+	return nil
+}
+
+// SealHash returns the hash of a block prior to it being sealed.
+func (e *Instant) SealHash(header *types.Header) common.Hash {
+	return header.Hash()
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. Developer chains have a
+// constant difficulty of 1.
+func (e *Instant) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	return big.NewInt(1)
+}
+
+// APIs implements consensus.Engine. The developer API needs the miner, so it is
+// provided by the eth service instead.
+func (e *Instant) APIs(chain consensus.ChainReader) []rpc.API {
+	return nil
+}
+
+// Close implements consensus.Engine. It's a noop for instant sealing as there
+// are no background threads.
+func (e *Instant) Close() error {
+	return nil
+}
@@ -0,0 +1,107 @@
+pragma solidity ^0.4.24;
+
+/// @title Light client checkpoint oracle
+///
+/// @notice The oracle registers checkpoints of the canonical chain signed by a
+/// threshold of trusted admins, for light clients to sync from.
+contract CheckpointOracle {
+    /// @notice Emitted for every admin vote counted towards a checkpoint.
+    event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, uint8 v, bytes32 r, bytes32 s);
+
+    uint64 sectionIndex;             // slot 0: section index of the latest registered checkpoint
+    bytes32 hash;                    // slot 1: hash of the latest registered checkpoint
+    uint height;                     // slot 2: block number in which the latest checkpoint was registered
+    uint sectionSize;                // slot 3: number of blocks in a checkpoint section
+    uint processConfirms;            // slot 4: confirmations needed before a section can be registered
+    uint threshold;                  // slot 5: admin signatures needed to register a checkpoint
+    mapping(address => bool) admins; // slot 6: admin set
+    address[] adminList;             // slot 7: admin list, in the order given on deployment
+
+    /// @param _adminlist       admins allowed to submit and sign checkpoints
+    /// @param _sectionSize     number of blocks in a checkpoint section
+    /// @param _processConfirms confirmations needed before a section can be registered
+    /// @param _threshold       admin signatures needed to register a checkpoint
+    constructor(address[] _adminlist, uint _sectionSize, uint _processConfirms, uint _threshold) public {
+        require(_threshold > 0 && _threshold <= _adminlist.length);
+
+        sectionSize = _sectionSize;
+        processConfirms = _processConfirms;
+        threshold = _threshold;
+        for (uint i = 0; i < _adminlist.length; i++) {
+            admins[_adminlist[i]] = true;
+            adminList.push(_adminlist[i]);
+        }
+    }
+
+    /// @notice Returns the latest registered checkpoint and the block in which it
+    /// was registered, all zero if none was registered yet.
+    function GetLatestCheckpoint() public view returns (uint64, bytes32, uint) {
+        return (sectionIndex, hash, height);
+    }
+
+    /// @notice Returns the list of admins.
+    function GetAllAdmin() public view returns (address[]) {
+        return adminList;
+    }
+
+    /// @notice Registers a checkpoint signed by at least threshold admins.
+    ///
+    /// @param _recentNumber number of a recent block of the chain the call is meant for
+    /// @param _recentHash   hash of that block, binding the call to the chain
+    /// @param _hash         checkpoint hash
+    /// @param _sectionIndex section index of the checkpoint
+    /// @param v, r, s       admin signatures of the checkpoint, ordered by signer address
+    ///
+    /// @return whether the checkpoint was registered. Calls for sections that are
+    /// not yet confirmed, already registered or older, as well as empty checkpoint
+    /// hashes, are ignored. Any other invalid call reverts.
+    function SetCheckpoint(
+        uint _recentNumber,
+        bytes32 _recentHash,
+        bytes32 _hash,
+        uint64 _sectionIndex,
+        uint8[] v,
+        bytes32[] r,
+        bytes32[] s
+    ) public returns (bool) {
+        require(admins[msg.sender]);
+        require(blockhash(_recentNumber) == _recentHash);
+        require(v.length == r.length && v.length == s.length);
+
+        if (block.number < (uint(_sectionIndex) + 1) * sectionSize + processConfirms) {
+            return false;
+        }
+        if (_sectionIndex < sectionIndex) {
+            return false;
+        }
+        if (_sectionIndex == sectionIndex && (_sectionIndex != 0 || height != 0)) {
+            return false;
+        }
+        if (_hash == bytes32(0)) {
+            return false;
+        }
+        // EIP-191 version 0x00 signature, bound to this oracle
+        bytes32 signedHash = keccak256(abi.encodePacked(byte(0x19), byte(0x00), this, _sectionIndex, _hash));
+
+        // Signers must be in strictly increasing order, so no vote counts twice
+        address lastVoter = address(0);
+        for (uint idx = 0; idx < v.length; idx++) {
+            address signer = ecrecover(signedHash, v[idx], r[idx], s[idx]);
+            require(admins[signer]);
+            require(uint(signer) > uint(lastVoter));
+            lastVoter = signer;
+
+            emit NewCheckpointVote(_sectionIndex, _hash, v[idx], r[idx], s[idx]);
+
+            // Register the checkpoint as soon as enough votes are counted
+            if (idx + 1 >= threshold) {
+                hash = _hash;
+                height = block.number;
+                sectionIndex = _sectionIndex;
+                return true;
+            }
+        }
+        // Not enough valid votes
+        revert();
+    }
+}
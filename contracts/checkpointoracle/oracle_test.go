@@ -0,0 +1,482 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	testSectionSize = 8 // Number of blocks in a checkpoint section
+	testConfirms    = 2 // Number of confirmations before a section can be registered
+)
+
+// Account is the key and address pair of a checkpoint admin.
+type Account struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+// Accounts is a list of admins sortable by address.
+type Accounts []Account
+
+func (a Accounts) Len() int           { return len(a) }
+func (a Accounts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a Accounts) Less(i, j int) bool { return bytes.Compare(a[i].addr.Bytes(), a[j].addr.Bytes()) < 0 }
+
+// newTestAccounts creates a batch of admins sorted by their addresses.
+func newTestAccounts(n int) Accounts {
+	var accounts Accounts
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateKey()
+		accounts = append(accounts, Account{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)})
+	}
+	sort.Sort(accounts)
+	return accounts
+}
+
+// signCheckpoint signs the checkpoint hash in the format expected by the
+// oracle contract.
+func signCheckpoint(addr common.Address, key *ecdsa.PrivateKey, index uint64, hash common.Hash) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index)
+	data := append([]byte{0x19, 0x00}, append(addr.Bytes(), append(buf, hash.Bytes()...)...)...)
+
+	sig, _ := crypto.Sign(crypto.Keccak256(data), key)
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return sig
+}
+
+// Tests that checkpoints can only be registered with enough ordered admin
+// signatures and only once the section is old enough.
+func TestCheckpointRegister(t *testing.T) {
+	accounts := newTestAccounts(3)
+	alloc := make(core.GenesisAlloc)
+	for _, account := range accounts {
+		alloc[account.addr] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	backend := backends.NewSimulatedBackend(alloc, 10000000)
+
+	// Deploy the oracle with a 2-of-3 admin threshold
+	admins := []common.Address{accounts[0].addr, accounts[1].addr, accounts[2].addr}
+	addr, _, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(accounts[0].key), backend, admins, big.NewInt(testSectionSize), big.NewInt(testConfirms), big.NewInt(2))
+	if err != nil {
+		t.Fatalf("Failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(addr, backend)
+	if err != nil {
+		t.Fatalf("Failed to bind oracle: %v", err)
+	}
+	if oracle.ContractAddr() != addr {
+		t.Fatalf("Contract address mismatch: have %x, want %x", oracle.ContractAddr(), addr)
+	}
+	stored, err := oracle.Contract().GetAllAdmin(nil)
+	if err != nil {
+		t.Fatalf("Failed to retrieve admins: %v", err)
+	}
+	if !reflect.DeepEqual(stored, admins) {
+		t.Fatalf("Admin list mismatch: have %x, want %x", stored, admins)
+	}
+	checkpoint := &params.TrustedCheckpoint{
+		SectionIndex: 0,
+		SectionHead:  common.HexToHash("0x01"),
+		CHTRoot:      common.HexToHash("0x02"),
+		BloomRoot:    common.HexToHash("0x03"),
+	}
+	hash := checkpoint.Hash()
+
+	// register submits the checkpoint from the given admin with the signatures
+	// of the given signers, returning the error of the submission.
+	register := func(sender Account, signers ...Account) (*types.Transaction, error) {
+		var sigs [][]byte
+		for _, signer := range signers {
+			sigs = append(sigs, signCheckpoint(addr, signer.key, checkpoint.SectionIndex, hash))
+		}
+		head, _ := backend.HeaderByNumber(context.Background(), nil)
+		tx, err := oracle.RegisterCheckpoint(bind.NewKeyedTransactor(sender.key), checkpoint.SectionIndex, hash.Bytes(), head.Number, head.Hash(), sigs)
+		backend.Commit()
+		return tx, err
+	}
+	// assertLatest checks the latest checkpoint registered in the oracle.
+	assertLatest := func(index uint64, hash common.Hash, height uint64) {
+		t.Helper()
+
+		idx, h, number, err := oracle.Contract().GetLatestCheckpoint(nil)
+		if err != nil {
+			t.Fatalf("Failed to retrieve latest checkpoint: %v", err)
+		}
+		if idx != index || common.Hash(h) != hash || number.Uint64() != height {
+			t.Fatalf("Latest checkpoint mismatch: have (%d, %x, %d), want (%d, %x, %d)", idx, h, number, index, hash, height)
+		}
+	}
+	// Registering a checkpoint of a section not yet confirmed should be ignored
+	if _, err := register(accounts[0], accounts[0], accounts[1]); err != nil {
+		t.Fatalf("Failed to submit early checkpoint: %v", err)
+	}
+	assertLatest(0, common.Hash{}, 0)
+
+	for i := 0; i < testSectionSize+testConfirms; i++ {
+		backend.Commit()
+	}
+	// Invalid submissions should be rejected
+	if _, err := register(newTestAccounts(1)[0], accounts[0], accounts[1]); err == nil {
+		t.Fatalf("Checkpoint submitted by non-admin accepted")
+	}
+	if _, err := register(accounts[0], accounts[0]); err == nil {
+		t.Fatalf("Checkpoint with insufficient signatures accepted")
+	}
+	if _, err := register(accounts[0], accounts[1], accounts[0]); err == nil {
+		t.Fatalf("Checkpoint with unordered signatures accepted")
+	}
+	if _, err := register(accounts[0], accounts[0], accounts[0]); err == nil {
+		t.Fatalf("Checkpoint with duplicate signatures accepted")
+	}
+	assertLatest(0, common.Hash{}, 0)
+
+	// Register the checkpoint properly and check the emitted votes
+	tx, err := register(accounts[2], accounts[0], accounts[2])
+	if err != nil {
+		t.Fatalf("Failed to register checkpoint: %v", err)
+	}
+	head, _ := backend.HeaderByNumber(context.Background(), nil)
+	assertLatest(0, hash, head.Number.Uint64())
+
+	receipt, _ := backend.TransactionReceipt(context.Background(), tx.Hash())
+	events := oracle.LookupCheckpointEvents([][]*types.Log{receipt.Logs}, checkpoint.SectionIndex, hash)
+	if len(events) != 2 {
+		t.Fatalf("Vote event count mismatch: have %d, want %d", len(events), 2)
+	}
+	for i, event := range events {
+		sig := append(append(event.R[:], event.S[:]...), event.V-27)
+		pubkey, err := crypto.SigToPub(crypto.Keccak256(append([]byte{0x19, 0x00}, append(addr.Bytes(), append(make([]byte, 8), hash.Bytes()...)...)...)), sig)
+		if err != nil {
+			t.Fatalf("Failed to recover vote %d signer: %v", i, err)
+		}
+		if signer := crypto.PubkeyToAddress(*pubkey); signer != []common.Address{accounts[0].addr, accounts[2].addr}[i] {
+			t.Fatalf("Vote %d signer mismatch: have %x", i, signer)
+		}
+	}
+	if events := oracle.LookupCheckpointEvents([][]*types.Log{receipt.Logs}, checkpoint.SectionIndex+1, hash); len(events) != 0 {
+		t.Fatalf("Found votes for unregistered section: %d", len(events))
+	}
+	// Registering the same section again should be ignored
+	if _, err := register(accounts[0], accounts[0], accounts[1]); err != nil {
+		t.Fatalf("Failed to submit stale checkpoint: %v", err)
+	}
+	assertLatest(0, hash, head.Number.Uint64())
+}
+
+// testOracle is a checkpoint oracle deployed on a simulated chain, with a fixed
+// section size and confirmation count.
+type testOracle struct {
+	backend *backends.SimulatedBackend
+	admins  Accounts
+	address common.Address
+	oracle  *CheckpointOracle
+	abi     abi.ABI
+}
+
+// newTestOracle deploys a checkpoint oracle with the given admins and signature
+// threshold onto a new simulated chain.
+func newTestOracle(t *testing.T, admins Accounts, threshold int64) *testOracle {
+	alloc := make(core.GenesisAlloc)
+	for _, admin := range admins {
+		alloc[admin.addr] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	backend := backends.NewSimulatedBackend(alloc, 10000000)
+
+	var addrs []common.Address
+	for _, admin := range admins {
+		addrs = append(addrs, admin.addr)
+	}
+	address, _, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(admins[0].key), backend, addrs, big.NewInt(testSectionSize), big.NewInt(testConfirms), big.NewInt(threshold))
+	if err != nil {
+		t.Fatalf("Failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(address, backend)
+	if err != nil {
+		t.Fatalf("Failed to bind oracle: %v", err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(contract.CheckpointOracleABI))
+	if err != nil {
+		t.Fatalf("Failed to parse oracle ABI: %v", err)
+	}
+	return &testOracle{backend: backend, admins: admins, address: address, oracle: oracle, abi: parsed}
+}
+
+// advance mines empty blocks until the pending block has the given number.
+func (o *testOracle) advance(t *testing.T, pending uint64) {
+	head, _ := o.backend.HeaderByNumber(context.Background(), nil)
+	if head.Number.Uint64()+1 > pending {
+		t.Fatalf("Pending block %d already passed, head is %d", pending, head.Number)
+	}
+	for number := head.Number.Uint64() + 1; number < pending; number++ {
+		o.backend.Commit()
+	}
+}
+
+// sign creates the signatures of the given accounts over a checkpoint.
+func (o *testOracle) sign(index uint64, hash common.Hash, signers ...Account) [][]byte {
+	var sigs [][]byte
+	for _, signer := range signers {
+		sigs = append(sigs, signCheckpoint(o.address, signer.key, index, hash))
+	}
+	return sigs
+}
+
+// pack encodes a checkpoint submission bound to the current head block.
+func (o *testOracle) pack(t *testing.T, index uint64, hash common.Hash, sigs [][]byte) []byte {
+	head, _ := o.backend.HeaderByNumber(context.Background(), nil)
+
+	var (
+		v []uint8
+		r [][32]byte
+		s [][32]byte
+	)
+	for _, sig := range sigs {
+		r = append(r, common.BytesToHash(sig[:32]))
+		s = append(s, common.BytesToHash(sig[32:64]))
+		v = append(v, sig[64])
+	}
+	data, err := o.abi.Pack("SetCheckpoint", head.Number, head.Hash(), hash, index, v, r, s)
+	if err != nil {
+		t.Fatalf("Failed to pack submission: %v", err)
+	}
+	return data
+}
+
+// Outcomes of a call to the oracle contract.
+const (
+	callReverted   = "reverted"
+	callIgnored    = "ignored"    // Returned false
+	callRegistered = "registered" // Returned true
+)
+
+// call executes a call to the oracle on top of the pending block, without
+// modifying any state, and returns its outcome.
+func (o *testOracle) call(from common.Address, value int64, data []byte) string {
+	out, err := o.backend.PendingCallContract(context.Background(), ethereum.CallMsg{
+		From:  from,
+		To:    &o.address,
+		Value: big.NewInt(value),
+		Data:  data,
+	})
+	switch {
+	case err != nil || len(out) == 0:
+		return callReverted
+	case len(out) == 32 && out[31] == 1:
+		return callRegistered
+	default:
+		return callIgnored
+	}
+}
+
+// Tests that the constructor only accepts reachable thresholds requiring at least
+// one signature, and stores the admins in the given order.
+func TestCheckpointDeploy(t *testing.T) {
+	accounts := newTestAccounts(3)
+	admins := Accounts{accounts[2], accounts[0], accounts[1]} // deliberately unsorted
+
+	for _, threshold := range []int64{0, 4} {
+		backend := backends.NewSimulatedBackend(core.GenesisAlloc{admins[0].addr: {Balance: big.NewInt(1000000000000000000)}}, 10000000)
+		addrs := []common.Address{admins[0].addr, admins[1].addr, admins[2].addr}
+		if _, _, _, err := contract.DeployCheckpointOracle(bind.NewKeyedTransactor(admins[0].key), backend, addrs, big.NewInt(testSectionSize), big.NewInt(testConfirms), big.NewInt(threshold)); err == nil {
+			t.Errorf("Oracle with threshold %d of 3 deployed", threshold)
+		}
+	}
+	for threshold := int64(1); threshold <= 3; threshold++ {
+		o := newTestOracle(t, admins, threshold)
+
+		stored, err := o.oracle.Contract().GetAllAdmin(nil)
+		if err != nil {
+			t.Fatalf("Failed to retrieve admins: %v", err)
+		}
+		if !reflect.DeepEqual(stored, []common.Address{admins[0].addr, admins[1].addr, admins[2].addr}) {
+			t.Errorf("Admin list mismatch: have %x", stored)
+		}
+		index, hash, height, err := o.oracle.Contract().GetLatestCheckpoint(nil)
+		if err != nil || index != 0 || hash != [32]byte{} || height.Sign() != 0 {
+			t.Errorf("Initial checkpoint mismatch: have (%d, %x, %v, %v), want zero", index, hash, height, err)
+		}
+	}
+}
+
+// Tests every rule a checkpoint submission is validated against, checking which
+// submissions revert, which are ignored and which register the checkpoint.
+func TestCheckpointSubmission(t *testing.T) {
+	admins := newTestAccounts(3)
+	outsider := newTestAccounts(1)[0]
+
+	o := newTestOracle(t, admins, 2)
+	o.advance(t, testSectionSize+testConfirms) // first block section 0 may be registered in
+
+	var (
+		hash      = common.HexToHash("0xdeadbeef")
+		valid     = o.sign(0, hash, admins[0], admins[1])
+		malformed = append(make([]byte, 64), 27) // r = s = 0, unrecoverable
+	)
+	// Signatures over a different oracle are not valid for this one
+	other := signCheckpoint(common.Address{0x01}, admins[1].key, 0, hash)
+
+	// Section indexes wider than 64 bits are rejected by the ABI decoding
+	wide := o.pack(t, 0, hash, valid)
+	wide[4+3*32+23] = 0x01
+
+	// Signature arrays of different lengths are rejected
+	head, _ := o.backend.HeaderByNumber(context.Background(), nil)
+	mismatched, err := o.abi.Pack("SetCheckpoint", head.Number, head.Hash(), hash, uint64(0), []uint8{27, 27}, [][32]byte{{}, {}}, [][32]byte{{}})
+	if err != nil {
+		t.Fatalf("Failed to pack submission: %v", err)
+	}
+	// Submissions must be bound to a recent block of this chain
+	stale, err := o.abi.Pack("SetCheckpoint", head.Number, common.Hash{0x01}, hash, uint64(0), []uint8{}, [][32]byte{}, [][32]byte{})
+	if err != nil {
+		t.Fatalf("Failed to pack submission: %v", err)
+	}
+	future, err := o.abi.Pack("SetCheckpoint", new(big.Int).Add(head.Number, big.NewInt(1)), head.Hash(), hash, uint64(0), []uint8{}, [][32]byte{}, [][32]byte{})
+	if err != nil {
+		t.Fatalf("Failed to pack submission: %v", err)
+	}
+	getLatest, _ := o.abi.Pack("GetLatestCheckpoint")
+	getAdmins, _ := o.abi.Pack("GetAllAdmin")
+
+	tests := []struct {
+		name  string
+		from  Account
+		value int64
+		data  []byte
+		want  string
+	}{
+		// Method dispatch
+		{"latest checkpoint", admins[0], 0, getLatest, callIgnored},
+		{"latest checkpoint with value", admins[0], 1, getLatest, callReverted},
+		{"admin list with value", admins[0], 1, getAdmins, callReverted},
+		{"unknown method", admins[0], 0, []byte{0x12, 0x34, 0x56, 0x78}, callReverted},
+		{"no method", admins[0], 0, nil, callReverted},
+
+		// Reverting submissions
+		{"submission with value", admins[0], 1, o.pack(t, 0, hash, valid), callReverted},
+		{"non-admin sender", outsider, 0, o.pack(t, 0, hash, valid), callReverted},
+		{"wrong recent hash", admins[0], 0, stale, callReverted},
+		{"future recent block", admins[0], 0, future, callReverted},
+		{"section index over 64 bits", admins[0], 0, wide, callReverted},
+		{"mismatched signature arrays", admins[0], 0, mismatched, callReverted},
+		{"no signatures", admins[0], 0, o.pack(t, 0, hash, nil), callReverted},
+		{"too few signatures", admins[0], 0, o.pack(t, 0, hash, valid[:1]), callReverted},
+		{"unordered signatures", admins[0], 0, o.pack(t, 0, hash, [][]byte{valid[1], valid[0]}), callReverted},
+		{"duplicate signatures", admins[0], 0, o.pack(t, 0, hash, [][]byte{valid[0], valid[0]}), callReverted},
+		{"non-admin signature", admins[0], 0, o.pack(t, 0, hash, append(valid[:1:1], o.sign(0, hash, outsider)...)), callReverted},
+		{"malformed signature", admins[0], 0, o.pack(t, 0, hash, [][]byte{valid[0], malformed}), callReverted},
+		{"signature of other section", admins[0], 0, o.pack(t, 0, hash, append(valid[:1:1], o.sign(1, hash, admins[1])...)), callReverted},
+		{"signature of other checkpoint", admins[0], 0, o.pack(t, 0, hash, append(valid[:1:1], o.sign(0, common.Hash{0x01}, admins[1])...)), callReverted},
+		{"signature of other oracle", admins[0], 0, o.pack(t, 0, hash, [][]byte{valid[0], other}), callReverted},
+
+		// Ignored submissions
+		{"empty checkpoint", admins[0], 0, o.pack(t, 0, common.Hash{}, o.sign(0, common.Hash{}, admins[0], admins[1])), callIgnored},
+		{"unconfirmed section", admins[0], 0, o.pack(t, 1, hash, o.sign(1, hash, admins[0], admins[1])), callIgnored},
+
+		// Registering submissions, votes beyond the threshold are not checked
+		{"threshold signatures", admins[0], 0, o.pack(t, 0, hash, valid), callRegistered},
+		{"all signatures", admins[2], 0, o.pack(t, 0, hash, o.sign(0, hash, admins...)), callRegistered},
+		{"signatures beyond threshold", admins[1], 0, o.pack(t, 0, hash, append(o.sign(0, hash, admins[0], admins[2]), malformed)), callRegistered},
+	}
+	for _, tt := range tests {
+		if have := o.call(tt.from.addr, tt.value, tt.data); have != tt.want {
+			t.Errorf("%s: outcome mismatch: have %s, want %s", tt.name, have, tt.want)
+		}
+	}
+}
+
+// Tests that sections can only be registered once confirmed, in increasing order,
+// possibly skipping some, but never twice.
+func TestCheckpointSequence(t *testing.T) {
+	admins := newTestAccounts(2)
+	o := newTestOracle(t, admins, 1)
+
+	// register submits and mines a checkpoint for the given section, returning the
+	// outcome the submission had on the pending state before.
+	register := func(index uint64) string {
+		hash := common.BytesToHash([]byte{byte(index + 1)})
+		sigs := o.sign(index, hash, admins[0])
+
+		outcome := o.call(admins[0].addr, 0, o.pack(t, index, hash, sigs))
+		head, _ := o.backend.HeaderByNumber(context.Background(), nil)
+		if _, err := o.oracle.RegisterCheckpoint(bind.NewKeyedTransactor(admins[0].key), index, hash.Bytes(), head.Number, head.Hash(), sigs); err != nil && outcome != callReverted {
+			t.Fatalf("Failed to submit section %d: %v", index, err)
+		}
+		o.backend.Commit()
+
+		latest, stored, height, _ := o.oracle.Contract().GetLatestCheckpoint(nil)
+		if outcome == callRegistered && (latest != index || common.Hash(stored) != hash || height.Uint64() != head.Number.Uint64()+1) {
+			t.Fatalf("Section %d not registered: have (%d, %x, %d)", index, latest, stored, height)
+		}
+		return outcome
+	}
+	// Section 0 becomes registrable exactly sectionSize+confirms blocks in
+	o.advance(t, testSectionSize+testConfirms-1)
+	if have := register(0); have != callIgnored {
+		t.Fatalf("Section 0 registration one block early: have %s, want %s", have, callIgnored)
+	}
+	if have := register(0); have != callRegistered {
+		t.Fatalf("Section 0 registration when confirmed: have %s, want %s", have, callRegistered)
+	}
+	if have := register(0); have != callIgnored {
+		t.Fatalf("Section 0 registered twice: have %s, want %s", have, callIgnored)
+	}
+	// Section 1 has the same confirmation boundary at the end of its own section
+	o.advance(t, 2*testSectionSize+testConfirms-1)
+	if have := register(1); have != callIgnored {
+		t.Fatalf("Section 1 registration one block early: have %s, want %s", have, callIgnored)
+	}
+	if have := register(1); have != callRegistered {
+		t.Fatalf("Section 1 registration when confirmed: have %s, want %s", have, callRegistered)
+	}
+	// Sections may be skipped, but not registered out of order
+	o.advance(t, 4*testSectionSize+testConfirms)
+	if have := register(3); have != callRegistered {
+		t.Fatalf("Section 3 registration after skipping 2: have %s, want %s", have, callRegistered)
+	}
+	if have := register(2); have != callIgnored {
+		t.Fatalf("Section 2 registration after 3: have %s, want %s", have, callIgnored)
+	}
+	if have := register(0); have != callIgnored {
+		t.Fatalf("Section 0 registration after 3: have %s, want %s", have, callIgnored)
+	}
+}
@@ -157,6 +157,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, constant
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil && genesis.Config.Clique != nil {
+		if err := genesis.Config.Clique.CheckTransitions(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
@@ -268,6 +273,11 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
+	if g.Config != nil && g.Config.Clique != nil {
+		if err := g.Config.Clique.CheckTransitions(); err != nil {
+			return nil, err
+		}
+	}
 	block := g.ToBlock(db)
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
@@ -366,6 +376,17 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DeveloperInstantGenesisBlock returns the 'geth --dev --dev.instant' genesis
+// block, which uses the instant-seal engine instead of proof-of-authority.
+func DeveloperInstantGenesisBlock(faucet common.Address) *Genesis {
+	config := *params.AllInstantProtocolChanges
+
+	genesis := DeveloperGenesisBlock(0, faucet)
+	genesis.Config = &config
+	genesis.ExtraData = nil
+	return genesis
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
@@ -162,3 +162,24 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that genesis specs scheduling invalid clique signer transitions are
+// rejected instead of halting the chain at the transition.
+func TestSetupGenesisInvalidTransitions(t *testing.T) {
+	genesis := &Genesis{
+		Config: &params.ChainConfig{
+			ChainID: big.NewInt(1337),
+			Clique: &params.CliqueConfig{
+				Period:      1,
+				Epoch:       30000,
+				Transitions: []params.CliqueTransition{{Block: 5, Signers: []common.Address{}}},
+			},
+		},
+	}
+	if _, _, err := SetupGenesisBlock(ethdb.NewMemDatabase(), genesis); err == nil {
+		t.Errorf("genesis with empty signer transition accepted")
+	}
+	if _, err := genesis.Commit(ethdb.NewMemDatabase()); err == nil {
+		t.Errorf("genesis with empty signer transition committed")
+	}
+}
@@ -216,7 +216,16 @@ func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header, checkFreq int)
 				chain[i-1].Hash().Bytes()[:4], i, chain[i].Number, chain[i].Hash().Bytes()[:4], chain[i].ParentHash[:4])
 		}
 	}
-
+	// A zero check frequency skips the consensus verification altogether, only
+	// rejecting the banned headers (e.g. headers vouched for by trusted servers)
+	if checkFreq == 0 {
+		for i, header := range chain {
+			if BadHashes[header.Hash()] {
+				return i, ErrBlacklistedHash
+			}
+		}
+		return 0, nil
+	}
 	// Generate the list of seal verification requests, and start the parallel verifier
 	seals := make([]bool, len(chain))
 	for i := 0; i < len(seals)/checkFreq; i++ {
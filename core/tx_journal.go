@@ -111,7 +111,7 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 			batch = batch[:0]
 		}
 	}
-	log.Info("Loaded local transaction journal", "transactions", total, "dropped", dropped)
+	log.Info("Loaded transaction journal", "path", journal.path, "transactions", total, "dropped", dropped)
 
 	return failure
 }
@@ -163,7 +163,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		return err
 	}
 	journal.writer = sink
-	log.Info("Regenerated local transaction journal", "transactions", journaled, "accounts", len(all))
+	log.Info("Regenerated transaction journal", "path", journal.path, "transactions", journaled, "accounts", len(all))
 
 	return nil
 }
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"container/heap"
 	"math"
 	"math/big"
@@ -394,6 +395,39 @@ func (h *priceHeap) Pop() interface{} {
 	return x
 }
 
+// pagePriceHeap is a heap.Interface implementation over transactions, keeping the
+// cheapest one on top, used to select the most expensive transactions of the pool
+// in a stable order.
+type pagePriceHeap []*types.Transaction
+
+func (h pagePriceHeap) Len() int           { return len(h) }
+func (h pagePriceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h pagePriceHeap) Less(i, j int) bool { return h.cheaper(h[i], h[j]) }
+
+// cheaper returns whether transaction a sorts after transaction b in a price
+// decreasing listing.
+func (h pagePriceHeap) cheaper(a, b *types.Transaction) bool {
+	switch a.GasPrice().Cmp(b.GasPrice()) {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+	return bytes.Compare(a.Hash().Bytes(), b.Hash().Bytes()) > 0
+}
+
+func (h *pagePriceHeap) Push(x interface{}) {
+	*h = append(*h, x.(*types.Transaction))
+}
+
+func (h *pagePriceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
 // txPricedList is a price-sorted heap to allow operating on transactions pool
 // contents in a price-incrementing way.
 type txPricedList struct {
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"container/heap"
 	"errors"
 	"fmt"
 	"math"
@@ -128,6 +129,9 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	RemoteJournal      string // Journal of remote pending transactions to survive node restarts (empty = disabled)
+	RemoteJournalSlots uint64 // Maximum number of remote transactions to journal on shutdown
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -145,6 +149,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteJournalSlots: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -164,6 +170,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.RemoteJournal != "" && conf.RemoteJournalSlots < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal slots", "provided", conf.RemoteJournalSlots, "updated", DefaultTxPoolConfig.RemoteJournalSlots)
+		conf.RemoteJournalSlots = DefaultTxPoolConfig.RemoteJournalSlots
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -270,6 +280,13 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, reload the pending set of the last run.
+	// Everything is revalidated against the current head state during insertion.
+	if config.RemoteJournal != "" {
+		if err := newTxJournal(config.RemoteJournal).load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -392,33 +409,39 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number.Uint64())
 				add = pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
 			)
-			for rem.NumberU64() > add.NumberU64() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-					log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-					return
-				}
-			}
-			for add.NumberU64() > rem.NumberU64() {
-				included = append(included, add.Transactions()...)
-				if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-					log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-					return
+			if rem == nil {
+				// The old head is missing if the chain was rewound (e.g. via SetHead),
+				// in which case its transactions are dropped instead of reinjected
+				log.Debug("Skipping transaction reorg of rewound chain", "old", oldHead.Hash(), "new", newHead.Hash())
+			} else {
+				for rem.NumberU64() > add.NumberU64() {
+					discarded = append(discarded, rem.Transactions()...)
+					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
+						return
+					}
 				}
-			}
-			for rem.Hash() != add.Hash() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-					log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-					return
+				for add.NumberU64() > rem.NumberU64() {
+					included = append(included, add.Transactions()...)
+					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
+						return
+					}
 				}
-				included = append(included, add.Transactions()...)
-				if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-					log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-					return
+				for rem.Hash() != add.Hash() {
+					discarded = append(discarded, rem.Transactions()...)
+					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
+						return
+					}
+					included = append(included, add.Transactions()...)
+					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
+						return
+					}
 				}
+				reinject = types.TxDifference(discarded, included)
 			}
-			reinject = types.TxDifference(discarded, included)
 		}
 	}
 	// Initialize the internal state to the current head
@@ -467,6 +490,11 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.config.RemoteJournal != "" {
+		if err := pool.journalRemotes(); err != nil {
+			log.Warn("Failed to write remote transaction journal", "err", err)
+		}
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -545,6 +573,85 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool, returning the
+// pending as well as queued transactions of the given account, sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var pending, queued types.Transactions
+	if list, ok := pool.pending[addr]; ok {
+		pending = list.Flatten()
+	}
+	if list, ok := pool.queue[addr]; ok {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
+// ContentByPrice retrieves a page of the pending (or queued) transactions of the
+// pool, sorted by gas price in decreasing order (ties broken by hash). The total
+// number of transactions in the requested set is also returned.
+func (pool *TxPool) ContentByPrice(pending bool, offset, limit int) (types.Transactions, int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	lists := pool.queue
+	if pending {
+		lists = pool.pending
+	}
+	// Reject pages beyond the end of the set before allocating anything
+	total := 0
+	for _, list := range lists {
+		total += list.Len()
+	}
+	if offset < 0 || limit <= 0 || offset >= total {
+		return nil, total
+	}
+	size := total
+	if limit < total-offset {
+		size = offset + limit
+	}
+	// Only keep the most expensive offset+limit transactions around instead of
+	// sorting the entire pool content
+	page := make(pagePriceHeap, 0, size)
+	for _, list := range lists {
+		for _, tx := range list.txs.items {
+			if len(page) < size {
+				heap.Push(&page, tx)
+			} else if len(page) > 0 && page.cheaper(page[0], tx) {
+				page[0] = tx
+				heap.Fix(&page, 0)
+			}
+		}
+	}
+	txs := make(types.Transactions, len(page))
+	for i := len(txs) - 1; i >= 0; i-- {
+		txs[i] = heap.Pop(&page).(*types.Transaction)
+	}
+	return txs[offset:], total
+}
+
+// LocalStats retrieves the number of pending and queued transactions in the
+// pool, along with how many of them originate from local accounts. All counts
+// are taken from the same snapshot of the pool, so the local ones never exceed
+// the totals.
+func (pool *TxPool) LocalStats() (pending, queued, localPending, localQueued int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending, queued = pool.stats()
+	for addr := range pool.locals.accounts {
+		if list := pool.pending[addr]; list != nil {
+			localPending += list.Len()
+		}
+		if list := pool.queue[addr]; list != nil {
+			localQueued += list.Len()
+		}
+	}
+	return pending, queued, localPending, localQueued
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -583,6 +690,46 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves the highest priced executable remote transactions, at most
+// RemoteJournalSlots of them, keeping the nonce ordering of every account. The
+// returned transaction set is a copy and can be freely modified by calling code.
+//
+// The caller must hold pool.mu for writing, flattening the lists updates their cache.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	pending := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			pending[addr] = list.Flatten()
+		}
+	}
+	txs := make(map[common.Address]types.Transactions)
+	for it, n := types.NewTransactionsByPriceAndNonce(pool.signer, pending), uint64(0); n < pool.config.RemoteJournalSlots; n++ {
+		tx := it.Peek()
+		if tx == nil {
+			break
+		}
+		addr, _ := types.Sender(pool.signer, tx)
+		txs[addr] = append(txs[addr], tx)
+		it.Shift()
+	}
+	return txs
+}
+
+// journalRemotes dumps the best executable remote transactions into the remote
+// journal, to be reloaded by the next pool created with the same configuration.
+func (pool *TxPool) journalRemotes() error {
+	// Flattening the pending lists caches the result, so a read lock is not enough
+	pool.mu.Lock()
+	remotes := pool.remote()
+	pool.mu.Unlock()
+
+	journal := newTxJournal(pool.config.RemoteJournal)
+	if err := journal.rotate(remotes); err != nil {
+		return err
+	}
+	return journal.close()
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
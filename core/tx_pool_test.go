@@ -1680,6 +1680,82 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the pending remote transactions are journaled on shutdown, bounded
+// by the configured slots, and revalidated when reloaded on startup.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.RemoteJournal = journal
+	config.RemoteJournalSlots = 4
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	// Create three remote accounts with differently priced transactions
+	cheap, _ := crypto.GenerateKey()
+	pricey, _ := crypto.GenerateKey()
+	stale, _ := crypto.GenerateKey()
+
+	for _, key := range []*ecdsa.PrivateKey{cheap, pricey, stale} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	txs := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(1), cheap),
+		pricedTransaction(1, 100000, big.NewInt(1), cheap),
+		pricedTransaction(0, 100000, big.NewInt(3), pricey),
+		pricedTransaction(1, 100000, big.NewInt(3), pricey),
+		pricedTransaction(0, 100000, big.NewInt(2), stale),
+	}
+	for i, err := range pool.AddRemotes(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add remote transaction: %v", i, err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 5 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 5)
+	}
+	// Terminate the old pool, include the stale transaction, create a new pool
+	// and ensure only the still valid journaled transactions survive
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(stale.PublicKey), 1)
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	for _, tx := range []*types.Transaction{txs[0], txs[2], txs[3]} {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("journaled transaction %x missing", tx.Hash())
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -1736,6 +1812,84 @@ func TestTransactionStatusCheck(t *testing.T) {
 	}
 }
 
+// Tests that the per account, price sorted and local/remote content queries of
+// the pool return the correct subsets of transactions.
+func TestTransactionContentQueries(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the content retrievals with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Add a few pending and queued transactions with differing prices
+	pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(5), keys[0]))
+	pool.AddLocal(pricedTransaction(1, 100000, big.NewInt(3), keys[0]))
+	pool.AddLocal(pricedTransaction(3, 100000, big.NewInt(9), keys[0]))
+	pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(7), keys[1]))
+	pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), keys[2]))
+	pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(2), keys[2]))
+
+	// Check the per account content retrieval
+	pending, queued := pool.ContentFrom(crypto.PubkeyToAddress(keys[0].PublicKey))
+	if len(pending) != 2 || pending[0].Nonce() != 0 || pending[1].Nonce() != 1 {
+		t.Errorf("account pending content mismatch: have %v", pending)
+	}
+	if len(queued) != 1 || queued[0].Nonce() != 3 {
+		t.Errorf("account queued content mismatch: have %v", queued)
+	}
+	if pending, queued := pool.ContentFrom(common.Address{}); len(pending) != 0 || len(queued) != 0 {
+		t.Errorf("unknown account content mismatch: have %d pending, %d queued", len(pending), len(queued))
+	}
+	// Check the price sorted pages of the pending set
+	tests := []struct {
+		offset, limit int
+		prices        []int64
+	}{
+		{0, 10, []int64{7, 5, 3, 1}},
+		{0, 2, []int64{7, 5}},
+		{1, 2, []int64{5, 3}},
+		{3, 2, []int64{1}},
+		{4, 2, nil},
+		{1 << 62, 1 << 62, nil},
+		{-1, 2, nil},
+		{1, -1, nil},
+	}
+	for i, tt := range tests {
+		txs, total := pool.ContentByPrice(true, tt.offset, tt.limit)
+		if total != 4 {
+			t.Errorf("test %d: total mismatch: have %d, want %d", i, total, 4)
+		}
+		if len(txs) != len(tt.prices) {
+			t.Errorf("test %d: page size mismatch: have %d, want %d", i, len(txs), len(tt.prices))
+			continue
+		}
+		for j, tx := range txs {
+			if tx.GasPrice().Int64() != tt.prices[j] {
+				t.Errorf("test %d, tx %d: price mismatch: have %v, want %d", i, j, tx.GasPrice(), tt.prices[j])
+			}
+		}
+	}
+	if txs, total := pool.ContentByPrice(false, 0, 10); total != 2 || len(txs) != 2 || txs[0].GasPrice().Int64() != 9 {
+		t.Errorf("queued page mismatch: have %v, total %d", txs, total)
+	}
+	// Check the local transaction breakdown
+	totalPending, totalQueued, localPending, localQueued := pool.LocalStats()
+	if wantPending, wantQueued := pool.Stats(); totalPending != wantPending || totalQueued != wantQueued {
+		t.Errorf("total stats mismatch: have %d/%d, want %d/%d", totalPending, totalQueued, wantPending, wantQueued)
+	}
+	if localPending != 2 || localQueued != 1 {
+		t.Errorf("local stats mismatch: have %d/%d, want %d/%d", localPending, localQueued, 2, 1)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
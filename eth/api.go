@@ -155,6 +155,21 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return api.e.miner.HashRate()
 }
 
+// SendBundle schedules a group of signed, RLP encoded transactions for atomic
+// inclusion in one of the blocks mined up to and including the target block. The
+// returned hash identifies the bundle.
+func (api *PrivateMinerAPI) SendBundle(encodedTxs []hexutil.Bytes, targetBlock hexutil.Uint64) (common.Hash, error) {
+	txs := make(types.Transactions, len(encodedTxs))
+	for i, encodedTx := range encodedTxs {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	return api.e.Miner().SendBundle(txs, uint64(targetBlock))
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
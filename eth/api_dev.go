@@ -0,0 +1,129 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/instant"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errUnknownSnapshot is returned if a chain snapshot is requested to be reverted
+// to that was never taken (or was already discarded by an earlier revert).
+var errUnknownSnapshot = errors.New("unknown snapshot")
+
+// PrivateDevAPI provides private RPC methods to drive the block production of
+// developer chains sealing on demand, allowing test suites to precisely control
+// the chain contents.
+type PrivateDevAPI struct {
+	e      *Ethereum
+	engine *instant.Instant
+
+	snapshots []*types.Header // Chain heads snapshotted, indexed by id-1
+	lock      sync.Mutex      // Serializes the chain manipulations
+}
+
+// NewPrivateDevAPI creates a new RPC service which drives the block production
+// of the instant-seal consensus engine.
+func NewPrivateDevAPI(e *Ethereum, engine *instant.Instant) *PrivateDevAPI {
+	return &PrivateDevAPI{e: e, engine: engine}
+}
+
+// Mine seals the given number of blocks (one if omitted) on top of the current
+// head, including all the pending transactions, and returns their hashes once
+// they are part of the chain.
+func (api *PrivateDevAPI) Mine(blocks *uint64) ([]common.Hash, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	count := uint64(1)
+	if blocks != nil {
+		count = *blocks
+	}
+	eb, err := api.e.Etherbase()
+	if err != nil {
+		return nil, fmt.Errorf("etherbase missing: %v", err)
+	}
+	api.e.Miner().SetEtherbase(eb)
+
+	hashes := make([]common.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		block, err := api.e.Miner().SealBlock()
+		if err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, block.Hash())
+	}
+	return hashes, nil
+}
+
+// SetNextBlockTimestamp sets the timestamp of the next block mined, which must be
+// later than the one of the current head. Subsequent blocks continue from it.
+func (api *PrivateDevAPI) SetNextBlockTimestamp(timestamp uint64) error {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if head := api.e.BlockChain().CurrentHeader(); timestamp <= head.Time.Uint64() {
+		return fmt.Errorf("timestamp %d not later than head's %d", timestamp, head.Time.Uint64())
+	}
+	api.engine.SetNextTimestamp(timestamp)
+	return nil
+}
+
+// Snapshot records the current head of the chain, returning an id which can be
+// used to revert the chain to it.
+func (api *PrivateDevAPI) Snapshot() hexutil.Uint64 {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	api.snapshots = append(api.snapshots, api.e.BlockChain().CurrentHeader())
+	return hexutil.Uint64(len(api.snapshots))
+}
+
+// Revert rewinds the chain to the head recorded by the given snapshot, dropping
+// all the blocks (and their transactions) mined since. The snapshot and all the
+// ones taken after it are discarded.
+func (api *PrivateDevAPI) Revert(id hexutil.Uint64) error {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if id == 0 || uint64(id) > uint64(len(api.snapshots)) {
+		return errUnknownSnapshot
+	}
+	var (
+		chain  = api.e.BlockChain()
+		header = api.snapshots[id-1]
+		number = header.Number.Uint64()
+	)
+	if canon := chain.GetHeaderByNumber(number); canon == nil || canon.Hash() != header.Hash() {
+		return fmt.Errorf("snapshot block %d [%x…] no longer canonical", number, header.Hash().Bytes()[:4])
+	}
+	if err := chain.SetHead(number); err != nil {
+		return err
+	}
+	api.snapshots = api.snapshots[:id-1]
+
+	// Reset the transaction pool and the pending block to the new head
+	chain.PostChainEvents([]interface{}{core.ChainHeadEvent{Block: chain.CurrentBlock()}}, nil)
+	return nil
+}
@@ -0,0 +1,244 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	devTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	devTestAddress = crypto.PubkeyToAddress(devTestKey.PublicKey)
+)
+
+// newDevTester starts a networkless node running an instant-seal developer chain
+// and returns its dev API. The returned function tears the node down.
+func newDevTester(t *testing.T) (*Ethereum, *PrivateDevAPI, func()) {
+	workspace, err := ioutil.TempDir("", "dev-api-tester-")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	stack, err := node.New(&node.Config{DataDir: workspace, Name: "dev-api-tester"})
+	if err != nil {
+		os.RemoveAll(workspace)
+		t.Fatalf("failed to create node: %v", err)
+	}
+	config := DefaultConfig
+	config.Genesis = core.DeveloperInstantGenesisBlock(devTestAddress)
+	config.Etherbase = devTestAddress
+	config.MinerGasPrice = big.NewInt(1)
+
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return New(ctx, &config) }); err != nil {
+		t.Fatalf("failed to register Ethereum protocol: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start test stack: %v", err)
+	}
+	var ethereum *Ethereum
+	if err := stack.Service(&ethereum); err != nil {
+		t.Fatalf("failed to retrieve Ethereum service: %v", err)
+	}
+	var api *PrivateDevAPI
+	for _, service := range ethereum.APIs() {
+		if dev, ok := service.Service.(*PrivateDevAPI); ok {
+			api = dev
+		}
+	}
+	if api == nil {
+		t.Fatalf("dev API missing from instant-seal chain")
+	}
+	return ethereum, api, func() {
+		stack.Stop()
+		os.RemoveAll(workspace)
+	}
+}
+
+// sendDevTransaction injects a value transfer from the developer account into the
+// transaction pool.
+func sendDevTransaction(t *testing.T, ethereum *Ethereum, nonce uint64) *types.Transaction {
+	signer := types.NewEIP155Signer(params.AllInstantProtocolChanges.ChainID)
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, devTestKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := ethereum.TxPool().AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that blocks are only sealed on request, containing the pending transactions.
+func TestDevMine(t *testing.T) {
+	ethereum, api, teardown := newDevTester(t)
+	defer teardown()
+
+	chain := ethereum.BlockChain()
+	if err := ethereum.StartMining(1); err == nil {
+		t.Fatalf("continuous mining started on instant-seal chain")
+	}
+	tx := sendDevTransaction(t, ethereum, 0)
+	time.Sleep(100 * time.Millisecond)
+	if head := chain.CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("block sealed without request: head %d", head)
+	}
+	// Mine a single block, which must include the pending transaction
+	hashes, err := api.Mine(nil)
+	if err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	if len(hashes) != 1 || chain.CurrentBlock().Hash() != hashes[0] {
+		t.Fatalf("mined block mismatch: have %x, head %x", hashes, chain.CurrentBlock().Hash())
+	}
+	if txs := chain.CurrentBlock().Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Fatalf("mined transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+	}
+	// The pool is reset asynchronously on the new head, wait for it
+	for i := 0; ; i++ {
+		if pending, _ := ethereum.TxPool().Stats(); pending == 0 {
+			break
+		} else if i == 100 {
+			t.Fatalf("pending transactions left after mining: %d", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Mine multiple blocks in one go, all on the canonical chain
+	count := uint64(3)
+	if hashes, err = api.Mine(&count); err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("mined block count mismatch: have %d, want %d", len(hashes), 3)
+	}
+	for i, hash := range hashes {
+		if canon := chain.GetHeaderByNumber(uint64(i) + 2).Hash(); canon != hash {
+			t.Errorf("block %d: hash mismatch: have %x, want canonical %x", i+2, hash, canon)
+		}
+	}
+}
+
+// Tests that the timestamp of the next block can be set, but only forward.
+func TestDevSetNextBlockTimestamp(t *testing.T) {
+	ethereum, api, teardown := newDevTester(t)
+	defer teardown()
+
+	chain := ethereum.BlockChain()
+	if _, err := api.Mine(nil); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	head := chain.CurrentBlock().Time().Uint64()
+	if err := api.SetNextBlockTimestamp(head); err == nil {
+		t.Fatalf("timestamp of the head accepted")
+	}
+	next := head + 3600
+	if err := api.SetNextBlockTimestamp(next); err != nil {
+		t.Fatalf("failed to set next timestamp: %v", err)
+	}
+	if _, err := api.Mine(nil); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	if have := chain.CurrentBlock().Time().Uint64(); have != next {
+		t.Fatalf("timestamp mismatch: have %d, want %d", have, next)
+	}
+	// Subsequent blocks must continue from the requested timestamp
+	if _, err := api.Mine(nil); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	if have := chain.CurrentBlock().Time().Uint64(); have <= next {
+		t.Fatalf("timestamp not increasing: have %d, parent %d", have, next)
+	}
+}
+
+// Tests that the chain can be reverted to snapshots, which are consumed by it,
+// and that snapshots no longer on the canonical chain are refused.
+func TestDevSnapshotRevert(t *testing.T) {
+	ethereum, api, teardown := newDevTester(t)
+	defer teardown()
+
+	chain := ethereum.BlockChain()
+	if err := api.Revert(0); err != errUnknownSnapshot {
+		t.Fatalf("revert to snapshot 0: have %v, want %v", err, errUnknownSnapshot)
+	}
+	if _, err := api.Mine(nil); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	base := chain.CurrentBlock().Hash()
+	first := api.Snapshot()
+
+	sendDevTransaction(t, ethereum, 0)
+	count := uint64(2)
+	if _, err := api.Mine(&count); err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	second := api.Snapshot()
+	if _, err := api.Mine(nil); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	// Reverting to the first snapshot drops the blocks and all later snapshots
+	if err := api.Revert(first); err != nil {
+		t.Fatalf("failed to revert to snapshot: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.NumberU64() != 1 || head.Hash() != base {
+		t.Fatalf("head mismatch after revert: have #%d [%x], want #1 [%x]", head.NumberU64(), head.Hash(), base)
+	}
+	if state, _ := chain.State(); state.GetNonce(devTestAddress) != 0 {
+		t.Fatalf("reverted transaction still applied")
+	}
+	if err := api.Revert(first); err != errUnknownSnapshot {
+		t.Fatalf("revert to consumed snapshot: have %v, want %v", err, errUnknownSnapshot)
+	}
+	if err := api.Revert(second); err != errUnknownSnapshot {
+		t.Fatalf("revert to discarded snapshot: have %v, want %v", err, errUnknownSnapshot)
+	}
+	// A snapshot whose block was replaced on the canonical chain can't be reverted to
+	kept := api.Snapshot()
+	if _, err := api.Mine(&count); err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	replaced := api.Snapshot()
+	if err := chain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if err := api.SetNextBlockTimestamp(chain.CurrentBlock().Time().Uint64() + 3600); err != nil {
+		t.Fatalf("failed to set next timestamp: %v", err)
+	}
+	if _, err := api.Mine(&count); err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	if err := api.Revert(replaced); err == nil {
+		t.Fatalf("reverted to non-canonical snapshot")
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Fatalf("head changed by failed revert: have %d, want %d", head, 3)
+	}
+	if err := api.Revert(kept); err != nil {
+		t.Fatalf("failed to revert to canonical snapshot: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != base {
+		t.Fatalf("head mismatch after revert: have %x, want %x", head, base)
+	}
+}
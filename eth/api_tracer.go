@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"runtime"
 	"sync"
@@ -35,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
@@ -803,3 +805,147 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 	}
 	return nil, vm.Context{}, nil, fmt.Errorf("transaction index %d out of range for block %#x", txIndex, blockHash)
 }
+
+// SimulationResult is the outcome of a call executed by SimulateCall, along with
+// the side effects it would have had if it was included in a block.
+type SimulationResult struct {
+	Gas            hexutil.Uint64                    `json:"gas"`
+	Failed         bool                              `json:"failed"`
+	ReturnValue    hexutil.Bytes                     `json:"returnValue"`
+	Logs           []*types.Log                      `json:"logs"`
+	BalanceChanges map[common.Address]*BalanceChange `json:"balanceChanges"`
+}
+
+// BalanceChange is the ether balance of an account before and after a simulated
+// call.
+type BalanceChange struct {
+	Before *hexutil.Big `json:"before"`
+	After  *hexutil.Big `json:"after"`
+}
+
+// SimulateCall executes the given call on top of the state of the requested block
+// without persisting any changes. It returns the outcome of the call, the logs it
+// emitted and the ether balance changes of all accounts it touched.
+//
+// Contrary to eth_call, the sender must be able to pay for the value and gas of
+// the call, so the simulation reflects what would happen if it was sent as a
+// transaction.
+func (api *PrivateDebugAPI) SimulateCall(ctx context.Context, args ethapi.CallArgs, number rpc.BlockNumber) (*SimulationResult, error) {
+	// Fetch the block and state to simulate on top of
+	var (
+		block   *types.Block
+		statedb *state.StateDB
+		err     error
+	)
+	switch number {
+	case rpc.PendingBlockNumber:
+		block, statedb = api.eth.miner.Pending()
+	case rpc.LatestBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	default:
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	if statedb == nil {
+		if statedb, err = api.eth.blockchain.StateAt(block.Root()); err != nil {
+			return nil, err
+		}
+	}
+	prestate := statedb.Copy()
+
+	// Assemble the call, defaulting the gas allowance to the block gas limit
+	gas := uint64(args.Gas)
+	if gas == 0 {
+		gas = block.GasLimit()
+	}
+	msg := types.NewMessage(args.From, args.To, statedb.GetNonce(args.From), args.Value.ToInt(), gas, args.GasPrice.ToInt(), args.Data, false)
+
+	tracer := newTouchTracer()
+	vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: tracer})
+
+	// Handle timeouts and RPC cancellations
+	deadlineCtx, cancel := context.WithTimeout(ctx, defaultTraceTimeout)
+	go func() {
+		<-deadlineCtx.Done()
+		vmenv.Cancel()
+	}()
+	defer cancel()
+
+	statedb.Prepare(common.Hash{}, block.Hash(), 0)
+	ret, used, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(gas))
+	if err != nil {
+		return nil, fmt.Errorf("simulation failed: %v", err)
+	}
+	if err := deadlineCtx.Err(); err != nil {
+		return nil, fmt.Errorf("simulation aborted: %v", err)
+	}
+	// Collect the side effects of the call
+	result := &SimulationResult{
+		Gas:            hexutil.Uint64(used),
+		Failed:         failed,
+		ReturnValue:    ret,
+		Logs:           statedb.GetLogs(common.Hash{}),
+		BalanceChanges: make(map[common.Address]*BalanceChange),
+	}
+	if result.Logs == nil {
+		result.Logs = []*types.Log{}
+	}
+	// The block beneficiary collects the gas fee of the call
+	tracer.touched[vmctx.Coinbase] = struct{}{}
+	for addr := range tracer.touched {
+		before, after := prestate.GetBalance(addr), statedb.GetBalance(addr)
+		if before.Cmp(after) != 0 {
+			result.BalanceChanges[addr] = &BalanceChange{
+				Before: (*hexutil.Big)(before),
+				After:  (*hexutil.Big)(after),
+			}
+		}
+	}
+	return result, nil
+}
+
+// touchTracer is a vm.Tracer collecting all accounts a call interacts with: the
+// sender, every executed contract, the recipients of value transfers, the created
+// contracts and the beneficiaries of self-destructs.
+type touchTracer struct {
+	touched map[common.Address]struct{}
+}
+
+func newTouchTracer() *touchTracer {
+	return &touchTracer{touched: make(map[common.Address]struct{})}
+}
+
+func (t *touchTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.touched[from] = struct{}{}
+	t.touched[to] = struct{}{}
+	return nil
+}
+
+func (t *touchTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	t.touched[contract.Address()] = struct{}{}
+
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		t.touched[common.BigToAddress(stack.Back(1))] = struct{}{}
+	case vm.CREATE:
+		// Contracts with empty init code are never executed, derive their address
+		t.touched[crypto.CreateAddress(contract.Address(), env.StateDB.GetNonce(contract.Address()))] = struct{}{}
+	case vm.CREATE2:
+		inithash := crypto.Keccak256(memory.GetPtr(stack.Back(1).Int64(), stack.Back(2).Int64()))
+		t.touched[crypto.CreateAddress2(contract.Address(), common.BigToHash(stack.Back(3)), inithash)] = struct{}{}
+	case vm.SELFDESTRUCT:
+		t.touched[common.BigToAddress(stack.Back(0))] = struct{}{}
+	}
+	return nil
+}
+
+func (t *touchTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *touchTracer) CaptureEnd(output []byte, gasUsed uint64, duration time.Duration, err error) error {
+	return nil
+}
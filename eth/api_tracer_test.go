@@ -0,0 +1,153 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that simulated calls report their outcome, the logs surviving them and
+// the balance changes of all touched accounts, without modifying any state.
+func TestSimulateCall(t *testing.T) {
+	var (
+		sender    = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		forwarder = common.HexToAddress("0x000000000000000000000000000000000000f0f0")
+		reverter  = common.HexToAddress("0x000000000000000000000000000000000000dead")
+		recipient = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		creator   = common.HexToAddress("0x000000000000000000000000000000000000c0c0")
+		creator2  = common.HexToAddress("0x000000000000000000000000000000000000c2c2")
+		funds     = big.NewInt(1000000000000000000)
+	)
+	// Deploy a contract emitting a log and forwarding all value to the recipient,
+	// one emitting a log but reverting afterwards, and two endowing new contracts
+	// with empty init code with all value, using CREATE and CREATE2
+	db := ethdb.NewMemDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			sender:    {Balance: funds},
+			forwarder: {Code: hexutil.MustDecode("0x60006000a060006000600060003473000000000000000000000000000000000000bbbb5af100"), Balance: new(big.Int)},
+			reverter:  {Code: hexutil.MustDecode("0x60006000a060006000fd"), Balance: new(big.Int)},
+			creator:   {Code: hexutil.MustDecode("0x6000600034f000"), Balance: new(big.Int)},
+			creator2:  {Code: hexutil.MustDecode("0x60006000600034f500"), Balance: new(big.Int)},
+		},
+	}
+	gspec.MustCommit(db)
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer blockchain.Stop()
+
+	api := NewPrivateDebugAPI(gspec.Config, &Ethereum{blockchain: blockchain})
+
+	// Simulate a successful call and check its side effects
+	res, err := api.SimulateCall(context.Background(), ethapi.CallArgs{
+		From:     sender,
+		To:       &forwarder,
+		Gas:      100000,
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Value:    hexutil.Big(*big.NewInt(1000)),
+	}, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to simulate call: %v", err)
+	}
+	if res.Failed {
+		t.Fatalf("forwarding call failed")
+	}
+	if len(res.Logs) != 1 || res.Logs[0].Address != forwarder {
+		t.Errorf("log mismatch: have %v, want one log of %x", res.Logs, forwarder)
+	}
+	if len(res.BalanceChanges) != 3 {
+		t.Errorf("balance change count mismatch: have %d, want 3", len(res.BalanceChanges))
+	}
+	spent := new(big.Int).Add(big.NewInt(1000), new(big.Int).SetUint64(uint64(res.Gas)))
+	if change := res.BalanceChanges[sender]; change == nil || change.Before.ToInt().Cmp(funds) != 0 || new(big.Int).Sub(funds, change.After.ToInt()).Cmp(spent) != 0 {
+		t.Errorf("sender balance change mismatch: have %v, want %v spent", change, spent)
+	}
+	if change := res.BalanceChanges[recipient]; change == nil || change.Before.ToInt().Sign() != 0 || change.After.ToInt().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("recipient balance change mismatch: have %v, want 0 -> 1000", change)
+	}
+	fee := new(big.Int).SetUint64(uint64(res.Gas))
+	if change := res.BalanceChanges[common.Address{}]; change == nil || new(big.Int).Sub(change.After.ToInt(), change.Before.ToInt()).Cmp(fee) != 0 {
+		t.Errorf("coinbase balance change mismatch: have %v, want %v earned", change, fee)
+	}
+	// Simulate calls endowing new contracts and check that these are reported
+	created := map[common.Address]common.Address{
+		creator:  crypto.CreateAddress(creator, 0),
+		creator2: crypto.CreateAddress2(creator2, common.Hash{}, crypto.Keccak256(nil)),
+	}
+	for from, contract := range created {
+		to := from
+		res, err := api.SimulateCall(context.Background(), ethapi.CallArgs{
+			From:     sender,
+			To:       &to,
+			Gas:      100000,
+			GasPrice: hexutil.Big(*big.NewInt(1)),
+			Value:    hexutil.Big(*big.NewInt(1000)),
+		}, rpc.LatestBlockNumber)
+		if err != nil {
+			t.Fatalf("failed to simulate creation by %x: %v", from, err)
+		}
+		if res.Failed {
+			t.Fatalf("creation by %x failed", from)
+		}
+		if change := res.BalanceChanges[contract]; change == nil || change.Before.ToInt().Sign() != 0 || change.After.ToInt().Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("created contract %x balance change mismatch: have %v, want 0 -> 1000", contract, change)
+		}
+	}
+	// Simulate a reverting call and ensure its logs are discarded
+	res, err = api.SimulateCall(context.Background(), ethapi.CallArgs{
+		From:     sender,
+		To:       &reverter,
+		Gas:      100000,
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+	}, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to simulate call: %v", err)
+	}
+	if !res.Failed {
+		t.Errorf("reverting call succeeded")
+	}
+	if len(res.Logs) != 0 {
+		t.Errorf("reverted logs reported: %v", res.Logs)
+	}
+	// Ensure that the sender has to be able to pay for the call
+	if _, err := api.SimulateCall(context.Background(), ethapi.CallArgs{
+		From:     recipient,
+		To:       &forwarder,
+		Gas:      100000,
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+	}, rpc.LatestBlockNumber); err == nil {
+		t.Errorf("call from account without funds succeeded")
+	}
+	// Ensure none of the simulations were persisted
+	statedb, _ := blockchain.State()
+	if balance := statedb.GetBalance(recipient); balance.Sign() != 0 {
+		t.Errorf("simulation persisted: recipient balance %v", balance)
+	}
+}
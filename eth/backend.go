@@ -26,11 +26,13 @@ import (
 	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/instant"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -43,6 +45,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -56,6 +59,8 @@ type LesServer interface {
 	Stop()
 	Protocols() []p2p.Protocol
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
+	SetContractBackend(bind.ContractBackend)
+	APIs() []rpc.API
 }
 
 // Ethereum implements the Ethereum full node service.
@@ -99,6 +104,15 @@ func (s *Ethereum) AddLesServer(ls LesServer) {
 	ls.SetBloomBitsIndexer(s.bloomIndexer)
 }
 
+// SetContractBackend injects the contract backend into the embedded light
+// server, used by it to serve the checkpoints registered in the oracle.
+func (s *Ethereum) SetContractBackend(backend bind.ContractBackend) {
+	// Pass the rpc client to les server if it is enabled.
+	if s.lesServer != nil {
+		s.lesServer.SetContractBackend(backend)
+	}
+}
+
 // New creates a new Ethereum object (including the
 // initialisation of the common Ethereum object)
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
@@ -173,13 +187,26 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, config.Whitelist); err != nil {
 		return nil, err
 	}
 
-	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine, config.MinerRecommit, config.MinerGasFloor, config.MinerGasCeil, eth.isLocalBlock)
+	orderer, err := makeTxOrderer(config)
+	if err != nil {
+		return nil, err
+	}
+	minerConfig := &miner.Config{
+		Recommit:  config.MinerRecommit,
+		GasFloor:  config.MinerGasFloor,
+		GasCeil:   config.MinerGasCeil,
+		TxOrderer: orderer,
+	}
+	eth.miner = miner.New(eth, minerConfig, eth.chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.MinerExtraData))
 
 	eth.APIBackend = &EthAPIBackend{eth, nil}
@@ -192,6 +219,27 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	return eth, nil
 }
 
+// makeTxOrderer creates the transaction ordering policy of the miner from the
+// configured ordering, priority senders and per sender gas quota.
+func makeTxOrderer(config *Config) (miner.TxOrderer, error) {
+	var orderer miner.TxOrderer
+	switch config.MinerTxOrdering {
+	case "", "price":
+		orderer = miner.NewPriceOrderer()
+	case "fifo":
+		orderer = miner.NewFIFOOrderer()
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q", config.MinerTxOrdering)
+	}
+	if len(config.MinerPrioritySenders) > 0 {
+		orderer = miner.NewPriorityOrderer(config.MinerPrioritySenders, orderer)
+	}
+	if config.MinerSenderGasQuota > 0 {
+		orderer = miner.NewQuotaOrderer(config.MinerSenderGasQuota, orderer)
+	}
+	return orderer, nil
+}
+
 func makeExtraData(extra []byte) []byte {
 	if len(extra) == 0 {
 		// create default extradata
@@ -227,6 +275,10 @@ func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainCo
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	// If a developer chain sealing on demand is requested, set it up
+	if chainConfig.Instant != nil {
+		return instant.New()
+	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case ethash.ModeFake:
@@ -260,6 +312,19 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed explicitly by the les server
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+	// Append the developer API if blocks are sealed on demand
+	if engine, ok := s.engine.(*instant.Instant); ok {
+		apis = append(apis, rpc.API{
+			Namespace: "dev",
+			Version:   "1.0",
+			Service:   NewPrivateDevAPI(s, engine),
+		})
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -403,6 +468,10 @@ func (s *Ethereum) SetEtherbase(etherbase common.Address) {
 // is already running, this method adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
 func (s *Ethereum) StartMining(threads int) error {
+	// Instant-seal developer chains are only mined on demand
+	if _, ok := s.engine.(*instant.Instant); ok {
+		return errors.New("blocks are only sealed through the dev API")
+	}
 	// Update the thread count within the consensus engine
 	type threaded interface {
 		SetThreads(threads int)
@@ -434,7 +503,7 @@ func (s *Ethereum) StartMining(threads int) error {
 				log.Error("Etherbase account unavailable locally", "err", err)
 				return fmt.Errorf("signer missing: %v", err)
 			}
-			clique.Authorize(eb, wallet.SignHash)
+			clique.Authorize(eb, wallet.SignData)
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -488,6 +557,11 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers(params.BloomBitsBlocks)
 
+	// Start accounting the signers of canonical blocks on proof-of-authority chains
+	if engine, ok := s.engine.(*clique.Clique); ok && metrics.Enabled {
+		go s.trackLiveness(engine)
+	}
+
 	// Start the RPC service
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.NetVersion())
 
@@ -507,6 +581,28 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	return nil
 }
 
+// trackLiveness feeds the blocks becoming canonical into the liveness metrics of
+// the clique engine, until the blockchain is stopped.
+func (s *Ethereum) trackLiveness(engine *clique.Clique) {
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := s.blockchain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	last := s.blockchain.CurrentHeader().Number.Uint64()
+	for {
+		select {
+		case ev := <-heads:
+			head := ev.Block.Header()
+			engine.TrackCanonical(s.blockchain, head, last)
+			if number := head.Number.Uint64(); number > last {
+				last = number
+			}
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
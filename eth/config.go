@@ -94,6 +94,12 @@ type Config struct {
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// CheckpointOracle is the configuration for checkpoint oracle.
+	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
+
+	// Ultra Light client options
+	ULC *ULCConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -112,6 +118,10 @@ type Config struct {
 	MinerRecommit  time.Duration
 	MinerNoverify  bool
 
+	MinerTxOrdering      string           `toml:",omitempty"` // Transaction ordering policy of mined blocks ("price" or "fifo")
+	MinerPrioritySenders []common.Address `toml:",omitempty"` // Senders whose transactions are included before any other
+	MinerSenderGasQuota  uint64           `toml:",omitempty"` // Maximum gas used by a single sender's transactions per block (0 = unlimited)
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -92,6 +93,8 @@ var (
 	errCancelHeaderProcessing  = errors.New("header processing canceled (requested)")
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
+	errNoBeamSync              = errors.New("no beam sync active")
+	errBeamFetchFailed         = errors.New("state item unavailable from all peers")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
 )
 
@@ -104,6 +107,8 @@ type Downloader struct {
 	peers   *peerSet // Set of active peers from which download can proceed
 	stateDB ethdb.Database
 
+	SnapSyncer *snap.Syncer // Range based state retriever used in snap sync mode
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -138,6 +143,9 @@ type Downloader struct {
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [eth/63] Channel receiving inbound node state data
 
+	beamSync *stateSync   // Background state sync serving on-demand retrievals in beam sync
+	beamLock sync.RWMutex // Lock protecting the beam sync
+
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
 	cancelCh   chan struct{}  // Channel to cancel mid-flight syncs
@@ -213,6 +221,7 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 	dl := &Downloader{
 		mode:           mode,
 		stateDB:        stateDb,
+		SnapSyncer:     snap.NewSyncer(stateDb),
 		mux:            mux,
 		queue:          newQueue(),
 		peers:          newPeerSet(),
@@ -256,7 +265,7 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync, BeamSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
 	case LightSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
@@ -447,7 +456,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
-	if d.mode == FastSync {
+	if d.mode.isFast() {
 		if height <= uint64(fsMinFullBlocks) {
 			origin = 0
 		} else {
@@ -458,7 +467,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		}
 	}
 	d.committed = 1
-	if d.mode == FastSync && pivot != 0 {
+	if d.mode.isFast() && pivot != 0 {
 		d.committed = 0
 	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
@@ -473,7 +482,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		func() error { return d.fetchReceipts(origin + 1) },        // Receipts are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, pivot, td) },
 	}
-	if d.mode == FastSync {
+	if d.mode == BeamSync {
+		fetchers = append(fetchers, func() error { return d.processBeamSyncContent(latest) })
+	} else if d.mode.isFast() {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -658,7 +669,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 	switch d.mode {
 	case FullSync:
 		localHeight = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync, BeamSync:
 		localHeight = d.blockchain.CurrentFastBlock().NumberU64()
 	default:
 		localHeight = d.lightchain.CurrentHeader().Number.Uint64()
@@ -739,7 +750,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 				switch d.mode {
 				case FullSync:
 					known = d.blockchain.HasBlock(h, n)
-				case FastSync:
+				case FastSync, SnapSync, BeamSync:
 					known = d.blockchain.HasFastBlock(h, n)
 				default:
 					known = d.lightchain.HasHeader(h, n)
@@ -812,7 +823,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 				switch d.mode {
 				case FullSync:
 					known = d.blockchain.HasBlock(h, n)
-				case FastSync:
+				case FastSync, SnapSync, BeamSync:
 					known = d.blockchain.HasFastBlock(h, n)
 				default:
 					known = d.lightchain.HasHeader(h, n)
@@ -1357,7 +1368,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us something useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode.isFast() || d.mode == LightSync {
 					head := d.lightchain.CurrentHeader()
 					if td.Cmp(d.lightchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
 						return errStallingPeer
@@ -1385,7 +1396,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode.isFast() || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1413,7 +1424,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					}
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode.isFast() {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1597,6 +1608,41 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	}
 }
 
+// processBeamSyncContent takes fetch results from the queue and writes them to
+// the database like fast sync, but instead of waiting for the state of the pivot
+// block, it commits the pivot right away and executes all blocks on top of it,
+// retrieving any missing state on demand. The rest of the pivot state is filled
+// in by a background state sync, outliving the sync cycle.
+func (d *Downloader) processBeamSyncContent(latest *types.Header) error {
+	pivot := uint64(0)
+	if height := latest.Number.Uint64(); height > uint64(fsMinFullBlocks) {
+		pivot = height - uint64(fsMinFullBlocks)
+	}
+	for {
+		results := d.queue.Results(true)
+		if len(results) == 0 {
+			return nil
+		}
+		if d.chainInsertHook != nil {
+			d.chainInsertHook(results)
+		}
+		P, beforeP, afterP := splitAroundPivot(pivot, results)
+		if err := d.commitFastSyncData(beforeP, nil); err != nil {
+			return err
+		}
+		if P != nil {
+			log.Info("Starting beam sync", "pivot", P.Header.Number, "root", P.Header.Root)
+			d.syncBeamState(P.Header.Root)
+			if err := d.commitPivotBlock(P); err != nil {
+				return err
+			}
+		}
+		if err := d.importBlockResults(afterP); err != nil {
+			return err
+		}
+	}
+}
+
 func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, before, after []*fetchResult) {
 	for _, result := range results {
 		num := result.Header.Number.Uint64()
@@ -1617,10 +1663,14 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 	if len(results) == 0 {
 		return nil
 	}
+	var done chan struct{}
+	if stateSync != nil {
+		done = stateSync.done
+	}
 	select {
 	case <-d.quitCh:
 		return errCancelContentProcessing
-	case <-stateSync.done:
+	case <-done:
 		if err := stateSync.Wait(); err != nil {
 			return err
 		}
@@ -1676,6 +1726,22 @@ func (d *Downloader) DeliverReceipts(id string, receipts [][]*types.Receipt) (er
 
 // DeliverNodeData injects a new batch of node state data received from a remote node.
 func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
+	// The state retrieval of beam sync outlives the sync cycles, accept any data
+	// while it's running, even if no sync cycle is active
+	d.beamLock.RLock()
+	beam := d.beamSync != nil
+	d.beamLock.RUnlock()
+
+	if beam {
+		stateInMeter.Mark(int64(len(data)))
+		select {
+		case d.stateCh <- &statePack{id, data}:
+			return nil
+		case <-d.quitCh:
+			stateDropMeter.Mark(int64(len(data)))
+			return errNoSyncActive
+		}
+	}
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
@@ -185,7 +185,10 @@ func (dl *downloadTester) CurrentFastBlock() *types.Block {
 func (dl *downloadTester) FastSyncCommitHead(hash common.Hash) error {
 	// For now only check that the state trie is correct
 	if block := dl.GetBlockByHash(hash); block != nil {
-		_, err := trie.NewSecure(block.Root(), trie.NewDatabase(dl.stateDb), 0)
+		db := trie.NewDatabase(dl.stateDb)
+		db.SetFetcher(dl.downloader.FetchNode)
+
+		_, err := trie.NewSecure(block.Root(), db, 0)
 		return err
 	}
 	return fmt.Errorf("non existent block: %x", hash[:4])
@@ -234,10 +237,19 @@ func (dl *downloadTester) InsertChain(blocks types.Blocks) (i int, err error) {
 	defer dl.lock.Unlock()
 
 	for i, block := range blocks {
-		if parent, ok := dl.ownBlocks[block.ParentHash()]; !ok {
+		parent, ok := dl.ownBlocks[block.ParentHash()]
+		if !ok {
 			return i, errors.New("unknown parent")
-		} else if _, err := dl.stateDb.Get(parent.Root().Bytes()); err != nil {
-			return i, fmt.Errorf("unknown parent state %x: %v", parent.Root(), err)
+		}
+		if _, err := dl.stateDb.Get(parent.Root().Bytes()); err != nil {
+			// Parent state missing, retrieve it on demand if beam syncing
+			dl.lock.Unlock()
+			_, err = dl.downloader.FetchNode(parent.Root())
+			dl.lock.Lock()
+
+			if err != nil {
+				return i, fmt.Errorf("unknown parent state %x: %v", parent.Root(), err)
+			}
 		}
 		if _, ok := dl.ownHeaders[block.Hash()]; !ok {
 			dl.ownHashes = append(dl.ownHashes, block.Hash())
@@ -429,6 +441,7 @@ func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronis
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
+func TestCanonicalSynchronisation64Beam(t *testing.T)  { testCanonicalSynchronisation(t, 64, BeamSync) }
 
 func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -1567,3 +1580,56 @@ func TestRemoteHeaderRequestSpan(t *testing.T) {
 		}
 	}
 }
+
+// Tests that beam sync commits the pivot block without waiting for its state,
+// executes the blocks after it with the state retrieved on demand and finishes
+// retrieving the pivot state in the background, after the sync cycle ended.
+func TestBeamSync63(t *testing.T) { testBeamSync(t, 63) }
+func TestBeamSync64(t *testing.T) { testBeamSync(t, 64) }
+
+func testBeamSync(t *testing.T, protocol int) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheItems - 15)
+	tester.newPeer("peer", protocol, chain)
+
+	// Synchronise and ensure the head block was executed, not just fast synced
+	if err := tester.sync("peer", nil, BeamSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+	if head := tester.CurrentBlock().NumberU64(); head != uint64(chain.len()-1) {
+		t.Fatalf("head block mismatch: have %d, want %d", head, chain.len()-1)
+	}
+	// Wait for the background state retrieval to finish and verify the pivot state
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		tester.downloader.beamLock.RLock()
+		done := tester.downloader.beamSync == nil
+		tester.downloader.beamLock.RUnlock()
+
+		if done {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("beam sync state retrieval timed out")
+		}
+	}
+	pivot := chain.headerm[chain.chain[chain.len()-1-fsMinFullBlocks]]
+	tr, err := trie.New(pivot.Root, trie.NewDatabase(tester.stateDb))
+	if err != nil {
+		t.Fatalf("failed to open pivot state: %v", err)
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("pivot state incomplete: %v", err)
+	}
+	// Once the state is retrieved, no more on-demand retrievals should be served
+	if _, err := tester.downloader.FetchNode(pivot.Root); err != errNoBeamSync {
+		t.Fatalf("on-demand retrieval error mismatch: have %v, want %v", err, errNoBeamSync)
+	}
+}
@@ -25,10 +25,18 @@ const (
 	FullSync  SyncMode = iota // Synchronise the entire blockchain history from full blocks
 	FastSync                  // Quickly download the headers, full sync only at the chain head
 	LightSync                 // Download only the headers and terminate afterwards
+	SnapSync                  // Fast sync, retrieving the state as contiguous ranges via the snap protocol
+	BeamSync                  // Fast sync, executing blocks right after the pivot with state retrieved on demand
 )
 
 func (mode SyncMode) IsValid() bool {
-	return mode >= FullSync && mode <= LightSync
+	return mode >= FullSync && mode <= BeamSync
+}
+
+// isFast returns whether the mode downloads the state of a pivot block instead
+// of executing all the blocks, i.e. whether it's fast sync or one of its flavours.
+func (mode SyncMode) isFast() bool {
+	return mode == FastSync || mode == SnapSync || mode == BeamSync
 }
 
 // String implements the stringer interface.
@@ -40,6 +48,10 @@ func (mode SyncMode) String() string {
 		return "fast"
 	case LightSync:
 		return "light"
+	case SnapSync:
+		return "snap"
+	case BeamSync:
+		return "beam"
 	default:
 		return "unknown"
 	}
@@ -53,6 +65,10 @@ func (mode SyncMode) MarshalText() ([]byte, error) {
 		return []byte("fast"), nil
 	case LightSync:
 		return []byte("light"), nil
+	case SnapSync:
+		return []byte("snap"), nil
+	case BeamSync:
+		return []byte("beam"), nil
 	default:
 		return nil, fmt.Errorf("unknown sync mode %d", mode)
 	}
@@ -66,8 +82,12 @@ func (mode *SyncMode) UnmarshalText(text []byte) error {
 		*mode = FastSync
 	case "light":
 		*mode = LightSync
+	case "snap":
+		*mode = SnapSync
+	case "beam":
+		*mode = BeamSync
 	default:
-		return fmt.Errorf(`unknown sync mode %q, want "full", "fast" or "light"`, text)
+		return fmt.Errorf(`unknown sync mode %q, want "full", "fast", "snap", "beam" or "light"`, text)
 	}
 	return nil
 }
@@ -48,6 +48,13 @@ func (req *stateReq) timedOut() bool {
 	return req.response == nil
 }
 
+// beamReq is an on-demand retrieval request of a single state item, issued by
+// block execution during beam sync.
+type beamReq struct {
+	hash    common.Hash // Hash of the state item to retrieve
+	deliver chan []byte // Channel to deliver the item on, closed on failure
+}
+
 // stateSyncStats is a collection of progress stats to report during a state trie
 // sync to RPC requests as well as to display in user logs.
 type stateSyncStats struct {
@@ -69,6 +76,70 @@ func (d *Downloader) syncState(root common.Hash) *stateSync {
 	return s
 }
 
+// syncBeamState starts downloading the state with the given root hash in the
+// background, serving on-demand retrievals of missing state items with priority.
+// Contrary to regular state syncs, it is not bound to the current sync cycle.
+func (d *Downloader) syncBeamState(root common.Hash) *stateSync {
+	s := newStateSync(d, root)
+	s.abort = d.quitCh
+
+	d.beamLock.Lock()
+	d.beamSync = s
+	d.beamLock.Unlock()
+
+	go func() {
+		if err := s.Wait(); err != nil {
+			log.Warn("Beam sync state retrieval failed", "root", root, "err", err)
+		} else {
+			log.Info("Beam sync state retrieval completed", "root", root)
+		}
+		d.beamLock.Lock()
+		if d.beamSync == s {
+			d.beamSync = nil
+		}
+		d.beamLock.Unlock()
+	}()
+	select {
+	case d.stateSyncStart <- s:
+	case <-d.quitCh:
+		s.err = errCancelStateFetch
+		close(s.done)
+	}
+	return s
+}
+
+// FetchNode retrieves a single trie node or contract code from the network via
+// the running beam sync, blocking until it arrives or the background state sync
+// terminates. It is meant to be used as the fetcher of the chain's trie database
+// to execute blocks on top of a partially retrieved state.
+func (d *Downloader) FetchNode(hash common.Hash) ([]byte, error) {
+	d.beamLock.RLock()
+	s := d.beamSync
+	d.beamLock.RUnlock()
+
+	if s == nil {
+		return nil, errNoBeamSync
+	}
+	req := &beamReq{hash: hash, deliver: make(chan []byte, 1)}
+	select {
+	case s.beamReqs <- req:
+		select {
+		case blob, ok := <-req.deliver:
+			if !ok {
+				return nil, errBeamFetchFailed
+			}
+			return blob, nil
+		case <-s.done:
+		}
+	case <-s.done:
+	}
+	// The background sync terminated, if successfully, the item is on disk
+	if blob, err := d.stateDB.Get(hash[:]); err == nil && len(blob) > 0 {
+		return blob, nil
+	}
+	return nil, errNoBeamSync
+}
+
 // stateFetcher manages the active state sync and accepts requests
 // on its behalf.
 func (d *Downloader) stateFetcher() {
@@ -214,15 +285,20 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 type stateSync struct {
 	d *Downloader // Downloader instance to access and manage current peerset
 
+	root   common.Hash                // State root currently being synced
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
 
+	beamReqs    chan *beamReq                 // On-demand retrieval requests during beam sync
+	beamWaiters map[common.Hash][]chan []byte // Delivery channels of pending on-demand retrievals
+
 	numUncommitted   int
 	bytesUncommitted int
 
 	deliver    chan *stateReq // Delivery channel multiplexing peer responses
 	cancel     chan struct{}  // Channel to signal a termination request
+	abort      chan struct{}  // Channel to signal an external termination (sync cycle or downloader)
 	cancelOnce sync.Once      // Ensures cancel only ever gets called once
 	done       chan struct{}  // Channel to signal termination completion
 	err        error          // Any error hit during sync (set before completion)
@@ -237,14 +313,23 @@ type stateTask struct {
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
+	d.cancelLock.RLock()
+	abort := d.cancelCh
+	d.cancelLock.RUnlock()
+
 	return &stateSync{
 		d:       d,
+		root:    root,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewLegacyKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
 		deliver: make(chan *stateReq),
 		cancel:  make(chan struct{}),
+		abort:   abort,
 		done:    make(chan struct{}),
+
+		beamReqs:    make(chan *beamReq),
+		beamWaiters: make(map[common.Hash][]chan []byte),
 	}
 }
 
@@ -286,6 +371,13 @@ func (s *stateSync) loop() (err error) {
 		}
 	}()
 
+	// In snap sync mode, retrieve the bulk of the state as contiguous ranges first,
+	// leaving only the missing and changed trie nodes to be healed below
+	if s.d.mode == SnapSync {
+		if err = s.snapSync(); err != nil {
+			return err
+		}
+	}
 	// Keep assigning new tasks until the sync completes or aborts
 	for s.sched.Pending() > 0 {
 		if err = s.commit(false); err != nil {
@@ -300,9 +392,16 @@ func (s *stateSync) loop() (err error) {
 		case <-s.cancel:
 			return errCancelStateFetch
 
-		case <-s.d.cancelCh:
+		case <-s.abort:
 			return errCancelStateFetch
 
+		case req := <-s.beamReqs:
+			// On-demand retrieval requested by block execution, schedule with priority
+			s.beamWaiters[req.hash] = append(s.beamWaiters[req.hash], req.deliver)
+			if _, ok := s.tasks[req.hash]; !ok {
+				s.tasks[req.hash] = &stateTask{make(map[string]struct{})}
+			}
+
 		case req := <-s.deliver:
 			// Response, disconnect or timeout triggered, drop the peer if stalling
 			log.Trace("Received node data response", "peer", req.peer.id, "count", len(req.response), "dropped", req.dropped, "timeout", !req.dropped && req.timedOut())
@@ -324,6 +423,35 @@ func (s *stateSync) loop() (err error) {
 	return nil
 }
 
+// snapSync runs the snapshot syncer against the state root, aborting if either
+// the state sync or the entire sync cycle is cancelled. Afterwards the trie sync
+// scheduler is reset, to only heal the trie nodes still missing.
+func (s *stateSync) snapSync() error {
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-s.cancel:
+		case <-s.abort:
+		case <-done:
+			return
+		}
+		close(cancel)
+	}()
+	if err := s.d.SnapSyncer.Sync(s.root, cancel); err != nil {
+		select {
+		case <-cancel:
+			return errCancelStateFetch
+		default:
+			return err
+		}
+	}
+	s.sched = state.NewStateSync(s.root, s.d.stateDB)
+	return nil
+}
+
 func (s *stateSync) commit(force bool) error {
 	if !force && s.bytesUncommitted < ethdb.IdealBatchSize {
 		return nil
@@ -360,7 +488,7 @@ func (s *stateSync) assignTasks() {
 			case s.d.trackStateReq <- req:
 				req.peer.FetchNodeData(req.items)
 			case <-s.cancel:
-			case <-s.d.cancelCh:
+			case <-s.abort:
 			}
 		}
 	}
@@ -376,9 +504,26 @@ func (s *stateSync) fillTasks(n int, req *stateReq) {
 			s.tasks[hash] = &stateTask{make(map[string]struct{})}
 		}
 	}
-	// Find tasks that haven't been tried with the request's peer.
+	// Find tasks that haven't been tried with the request's peer, prioritizing
+	// the ones needed by block execution in beam sync.
 	req.items = make([]common.Hash, 0, n)
 	req.tasks = make(map[common.Hash]*stateTask, n)
+	for hash := range s.beamWaiters {
+		if len(req.items) == n {
+			break
+		}
+		t, ok := s.tasks[hash]
+		if !ok {
+			continue
+		}
+		if _, ok := t.attempts[req.peer.id]; ok {
+			continue
+		}
+		t.attempts[req.peer.id] = struct{}{}
+		req.items = append(req.items, hash)
+		req.tasks[hash] = t
+		delete(s.tasks, hash)
+	}
 	for hash, t := range s.tasks {
 		// Stop when we've gathered enough requests
 		if len(req.items) == n {
@@ -413,13 +558,22 @@ func (s *stateSync) process(req *stateReq) (int, error) {
 	// Iterate over all the delivered data and inject one-by-one into the trie
 	for _, blob := range req.response {
 		_, hash, err := s.processNodeData(blob)
+		beam := s.deliverBeam(hash, blob)
+		if beam {
+			delete(s.tasks, hash) // Might have been rescheduled while in flight
+		}
+
 		switch err {
 		case nil:
 			s.numUncommitted++
 			s.bytesUncommitted += len(blob)
 			successful++
 		case trie.ErrNotRequested:
-			unexpected++
+			if beam {
+				successful++ // Requested on demand only, not (yet) by the trie sync
+			} else {
+				unexpected++
+			}
 		case trie.ErrAlreadyProcessed:
 			duplicate++
 		default:
@@ -438,6 +592,15 @@ func (s *stateSync) process(req *stateReq) (int, error) {
 		if len(req.response) > 0 || req.timedOut() {
 			delete(task.attempts, req.peer.id)
 		}
+		// If an on-demand item was requested from all peers, fail the block execution
+		// depending on it, but keep syncing the rest of the state
+		if waiters, ok := s.beamWaiters[hash]; ok && len(task.attempts) >= npeers {
+			for _, waiter := range waiters {
+				close(waiter)
+			}
+			delete(s.beamWaiters, hash)
+			continue
+		}
 		// If we've requested the node too many times already, it may be a malicious
 		// sync where nobody has the right data. Abort.
 		if len(task.attempts) >= npeers {
@@ -449,6 +612,20 @@ func (s *stateSync) process(req *stateReq) (int, error) {
 	return successful, nil
 }
 
+// deliverBeam hands a retrieved state item to all on-demand requests waiting
+// for it, returning whether there were any.
+func (s *stateSync) deliverBeam(hash common.Hash, blob []byte) bool {
+	waiters, ok := s.beamWaiters[hash]
+	if !ok {
+		return false
+	}
+	for _, waiter := range waiters {
+		waiter <- blob
+	}
+	delete(s.beamWaiters, hash)
+	return true
+}
+
 // processNodeData tries to inject a trie node data blob delivered from a remote
 // peer into the state trie, returning whether anything useful was written or any
 // error occurred.
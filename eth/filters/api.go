@@ -234,11 +234,22 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+//
+// If a starting block is given in the criteria, all matching logs from that block
+// up to the current head are sent first, after which the subscription continues
+// with the live logs. Logs removed by a reorg that happened while replaying are
+// only reported if they were previously sent to the subscriber. If the historical
+// logs cannot be retrieved, the subscription request fails with the error.
+//
+// Subscriptions never end, so an end block cannot be given in the criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 {
+		return nil, errors.New("end block not supported by log subscriptions")
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
@@ -249,15 +260,47 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	if err != nil {
 		return nil, err
 	}
+	// If a starting block was requested, replay the historical logs before
+	// switching over to the live ones. The live subscription is already active,
+	// so events racing with the replay are buffered and deduplicated afterwards.
+	var replayDone chan *logReplayResult
+	if crit.BlockHash == nil && crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		replayDone = make(chan *logReplayResult, 1)
+	}
 
 	go func() {
-
+		var (
+			replay   *logReplay
+			buffered [][]*types.Log
+		)
+		notify := func(logs []*types.Log) {
+			if replay != nil {
+				logs = replay.filter(logs)
+			}
+			for _, log := range logs {
+				notifier.Notify(rpcSub.ID, &log)
+			}
+		}
 		for {
 			select {
+			case res := <-replayDone:
+				if res.err != nil { // subscription request failed
+					logsSub.Unsubscribe()
+					return
+				}
+				notify(res.logs)
+				replay = res.replay
+				for _, logs := range buffered {
+					notify(logs)
+				}
+				replayDone, buffered = nil, nil
+
 			case logs := <-matchedLogs:
-				for _, log := range logs {
-					notifier.Notify(rpcSub.ID, &log)
+				if replayDone != nil {
+					buffered = append(buffered, logs)
+					continue
 				}
+				notify(logs)
 			case <-rpcSub.Err(): // client send an unsubscribe request
 				logsSub.Unsubscribe()
 				return
@@ -268,9 +311,25 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 		}
 	}()
 
+	// A failed replay fails the subscription request, rather than silently
+	// leaving a gap in the logs delivered to the subscriber
+	if replayDone != nil {
+		logs, replay, err := replayLogs(ctx, api.backend, crit)
+		replayDone <- &logReplayResult{logs, replay, err}
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay logs from block %v: %v", crit.FromBlock, err)
+		}
+	}
 	return rpcSub, nil
 }
 
+// logReplayResult is the outcome of the historical phase of a log subscription.
+type logReplayResult struct {
+	logs   []*types.Log
+	replay *logReplay
+	err    error
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -0,0 +1,131 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// replayReorgDepth is the number of blocks below the replay head for which the
+// delivered block hashes are tracked. Removed logs for blocks older than this
+// are assumed to refer to canonical blocks that were replayed.
+const replayReorgDepth = 128
+
+// maxReplayBlocks is the maximum number of historical blocks a log subscription
+// may replay. The replayed logs are retrieved before the subscription is created,
+// so the range is bounded to limit the logs held in memory at once.
+const maxReplayBlocks = 10000
+
+// logReplay tracks the logs delivered to a subscriber during the historical
+// replay phase of a log subscription. Live events racing with the replay are
+// checked against it, so that no log is delivered twice and removed logs are
+// only reported for blocks the subscriber actually saw.
+type logReplay struct {
+	head      uint64               // Last block number covered by the replay
+	delivered map[common.Hash]bool // Recent blocks whose logs were sent to the subscriber
+}
+
+// newLogReplay creates a replay tracker for a replay ending at block head.
+func newLogReplay(head uint64) *logReplay {
+	return &logReplay{
+		head:      head,
+		delivered: make(map[common.Hash]bool),
+	}
+}
+
+// tracked returns whether logs of the given block number are subject to
+// deduplication against the replayed set.
+func (r *logReplay) tracked(number uint64) bool {
+	return number <= r.head && number+replayReorgDepth > r.head
+}
+
+// track marks the blocks of the given (replayed) logs as delivered.
+func (r *logReplay) track(logs []*types.Log) {
+	for _, log := range logs {
+		if r.tracked(log.BlockNumber) {
+			r.delivered[log.BlockHash] = true
+		}
+	}
+}
+
+// filter drops the live logs which were already delivered during the replay
+// and the removed logs that refer to blocks the subscriber never saw. The
+// delivered set is updated so that a block re-added after a removal passes.
+func (r *logReplay) filter(logs []*types.Log) []*types.Log {
+	var (
+		ret     []*types.Log
+		added   = make(map[common.Hash]bool)
+		removed = make(map[common.Hash]bool)
+	)
+	for _, log := range logs {
+		if !r.tracked(log.BlockNumber) {
+			ret = append(ret, log)
+			continue
+		}
+		if log.Removed {
+			if r.delivered[log.BlockHash] {
+				removed[log.BlockHash] = true
+				ret = append(ret, log)
+			}
+			continue
+		}
+		if !r.delivered[log.BlockHash] || added[log.BlockHash] {
+			added[log.BlockHash] = true
+			ret = append(ret, log)
+		}
+	}
+	for hash := range removed {
+		delete(r.delivered, hash)
+	}
+	for hash := range added {
+		r.delivered[hash] = true
+	}
+	return ret
+}
+
+// replayLogs retrieves the historical logs matching the criteria from the
+// requested starting block up to the current chain head, returning them along
+// with a tracker covering the replay.
+func replayLogs(ctx context.Context, backend Backend, crit FilterCriteria) ([]*types.Log, *logReplay, error) {
+	header, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("head header not found")
+	}
+	head := header.Number.Int64()
+	replay := newLogReplay(uint64(head))
+	if crit.FromBlock.Int64() > head {
+		return nil, replay, nil
+	}
+	if blocks := head - crit.FromBlock.Int64() + 1; blocks > maxReplayBlocks {
+		return nil, nil, fmt.Errorf("replay range too large: %d blocks, limit is %d", blocks, maxReplayBlocks)
+	}
+	logs, err := NewRangeFilter(backend, crit.FromBlock.Int64(), head, crit.Addresses, crit.Topics).Logs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	replay.track(logs)
+	return logs, replay, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that live logs racing with a historical replay are deduplicated and
+// that removed logs are only reported for blocks the subscriber has seen.
+func TestLogReplayFilter(t *testing.T) {
+	var (
+		seen   = common.HexToHash("0x01")
+		unseen = common.HexToHash("0x02")
+		fresh  = common.HexToHash("0x03")
+	)
+	replay := newLogReplay(1000)
+	replay.track([]*types.Log{{BlockNumber: 999, BlockHash: seen}})
+
+	// Live logs for the already replayed block must be dropped
+	if logs := replay.filter([]*types.Log{{BlockNumber: 999, BlockHash: seen}}); len(logs) != 0 {
+		t.Fatalf("duplicate log delivered: %v", logs)
+	}
+	// Removed logs of a block never sent must be dropped, seen ones delivered
+	if logs := replay.filter([]*types.Log{{BlockNumber: 998, BlockHash: unseen, Removed: true}}); len(logs) != 0 {
+		t.Fatalf("removed log for unseen block delivered: %v", logs)
+	}
+	if logs := replay.filter([]*types.Log{{BlockNumber: 999, BlockHash: seen, Removed: true}, {BlockNumber: 999, BlockHash: seen, Removed: true}}); len(logs) != 2 {
+		t.Fatalf("removed logs mismatch: have %d, want 2", len(logs))
+	}
+	// A removed block re-added by a later reorg must be delivered again
+	if logs := replay.filter([]*types.Log{{BlockNumber: 999, BlockHash: seen}, {BlockNumber: 999, BlockHash: seen}}); len(logs) != 2 {
+		t.Fatalf("re-added logs mismatch: have %d, want 2", len(logs))
+	}
+	if logs := replay.filter([]*types.Log{{BlockNumber: 999, BlockHash: seen}}); len(logs) != 0 {
+		t.Fatalf("duplicate re-added log delivered: %v", logs)
+	}
+	// Logs beyond the replay head or below the tracked window are passed through
+	if logs := replay.filter([]*types.Log{{BlockNumber: 1001, BlockHash: fresh}, {BlockNumber: 1001, BlockHash: fresh, Removed: true}}); len(logs) != 2 {
+		t.Fatalf("live logs mismatch: have %d, want 2", len(logs))
+	}
+	if logs := replay.filter([]*types.Log{{BlockNumber: 1, BlockHash: unseen, Removed: true}}); len(logs) != 1 {
+		t.Fatalf("deep removed logs mismatch: have %d, want 1", len(logs))
+	}
+}
+
+// Tests that the historical phase of a log subscription returns the logs of the
+// requested range, capped at the chain head.
+func TestReplayLogs(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.HexToAddress("0x1000")
+		topic   = common.BytesToHash([]byte("topic"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i%3 == 1 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}, BlockNumber: gen.Number().Uint64()}}
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		for _, receipt := range receipts[i] {
+			for _, log := range receipt.Logs {
+				log.BlockHash = block.Hash()
+			}
+		}
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Logs live in blocks 2, 5 and 8, the head is block 10
+	tests := []struct {
+		from *big.Int
+		want []uint64
+	}{
+		{big.NewInt(0), []uint64{2, 5, 8}},
+		{big.NewInt(3), []uint64{5, 8}},
+		{big.NewInt(11), nil},
+	}
+	for i, tt := range tests {
+		crit := FilterCriteria{FromBlock: tt.from, Addresses: []common.Address{addr}}
+		logs, replay, err := replayLogs(context.Background(), backend, crit)
+		if err != nil {
+			t.Fatalf("test %d: failed to replay logs: %v", i, err)
+		}
+		if len(logs) != len(tt.want) {
+			t.Fatalf("test %d: log count mismatch: have %d, want %d", i, len(logs), len(tt.want))
+		}
+		for j, log := range logs {
+			if log.BlockNumber != tt.want[j] {
+				t.Errorf("test %d, log %d: block mismatch: have %d, want %d", i, j, log.BlockNumber, tt.want[j])
+			}
+			if !replay.delivered[log.BlockHash] {
+				t.Errorf("test %d, log %d: replayed block not tracked", i, j)
+			}
+		}
+	}
+}
+
+// Tests that a log subscription whose historical logs cannot be replayed fails
+// instead of silently skipping them.
+func TestLogsSubscriptionReplayFailure(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase() // no head block to replay up to
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		server  = rpc.NewServer()
+	)
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan types.Log)
+	if sub, err := client.EthSubscribe(context.Background(), logs, "logs", map[string]interface{}{"fromBlock": "0x0"}); err == nil {
+		sub.Unsubscribe()
+		t.Fatal("subscription with failing replay succeeded")
+	}
+	// Subscriptions can't end, so end blocks are rejected
+	if sub, err := client.EthSubscribe(context.Background(), logs, "logs", map[string]interface{}{"fromBlock": "0x0", "toBlock": "0x1"}); err == nil {
+		sub.Unsubscribe()
+		t.Fatal("subscription with end block succeeded")
+	}
+	// Subscriptions without a starting block don't replay anything
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("failed to subscribe to live logs: %v", err)
+	}
+	sub.Unsubscribe()
+}
+
+// Tests that the historical replay of log subscriptions is bounded.
+func TestReplayLogsRangeLimit(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		head    = &types.Header{Number: big.NewInt(maxReplayBlocks + 10)}
+	)
+	rawdb.WriteHeader(db, head)
+	rawdb.WriteCanonicalHash(db, head.Hash(), head.Number.Uint64())
+	rawdb.WriteHeadBlockHash(db, head.Hash())
+
+	for _, from := range []int64{0, 10} {
+		crit := FilterCriteria{FromBlock: big.NewInt(from)}
+		if _, _, err := replayLogs(context.Background(), backend, crit); err == nil {
+			t.Errorf("replay of %d blocks succeeded", head.Number.Int64()-from+1)
+		}
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/fetcher"
+	"github.com/ethereum/go-ethereum/eth/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -70,6 +71,8 @@ type ProtocolManager struct {
 	networkID uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should use the snap protocol for state retrieval
+	beamSync  uint32 // Flag whether fast sync should execute blocks right away, retrieving state on demand
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
@@ -119,18 +122,25 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		quitSync:    make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	fast := mode == downloader.FastSync || mode == downloader.SnapSync || mode == downloader.BeamSync
+	if fast && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
-		mode = downloader.FullSync
+		mode, fast = downloader.FullSync, false
 	}
-	if mode == downloader.FastSync {
+	if fast {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
+	if mode == downloader.BeamSync {
+		manager.beamSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if fast && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -167,6 +177,14 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
+	// Serve the state ranges to snap syncing peers, and retrieve them if needed
+	manager.SubProtocols = append(manager.SubProtocols, snap.MakeProtocols(blockchain.StateCache(), manager.downloader.SnapSyncer)...)
+
+	// Retrieve any state missing during block execution on demand while beam syncing
+	if mode == downloader.BeamSync {
+		blockchain.StateCache().TrieDB().SetFetcher(manager.downloader.FetchNode)
+	}
+
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
 	}
@@ -0,0 +1,326 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// softResponseLimit is the target maximum size of replies to data retrievals.
+	softResponseLimit = 2 * 1024 * 1024
+
+	// maxCodeLookups is the maximum number of bytecodes to serve. This number is
+	// there to limit the number of disk lookups.
+	maxCodeLookups = 1024
+)
+
+// MakeProtocols constructs the P2P protocol definitions for snap, serving the
+// state from the given database and feeding the responses into the syncer.
+func MakeProtocols(db state.Database, syncer *Syncer) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+
+		protocols[i] = p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := newPeer(version, p, rw)
+
+				syncer.Register(peer)
+				defer syncer.Unregister(peer.id)
+
+				return handle(db, syncer, peer)
+			},
+			NodeInfo: func() interface{} {
+				return nil
+			},
+			PeerInfo: func(id enode.ID) interface{} {
+				return nil
+			},
+		}
+	}
+	return protocols
+}
+
+// handle is the callback invoked to manage the life cycle of a snap peer. When
+// this function terminates, the peer is disconnected.
+func handle(db state.Database, syncer *Syncer, peer *Peer) error {
+	peer.Log().Debug("Snapshot peer connected", "name", peer.Name())
+	defer peer.Log().Debug("Snapshot peer disconnected")
+
+	for {
+		if err := handleMessage(db, syncer, peer); err != nil {
+			peer.Log().Debug("Snapshot message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMessage is invoked whenever an inbound message is received from a remote
+// peer. The remote connection is torn down upon returning any error.
+func handleMessage(db state.Database, syncer *Syncer, peer *Peer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return fmt.Errorf("%v: %v > %v", errMsgTooLarge, msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	// Handle the message depending on its contents
+	switch msg.Code {
+	case GetAccountRangeMsg:
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, AccountRangeMsg, serveAccountRange(db, &req))
+
+	case AccountRangeMsg:
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		hashes, accounts := res.unpack()
+		return syncer.OnAccounts(peer, res.ID, hashes, accounts, res.Proof)
+
+	case GetStorageRangesMsg:
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, StorageRangesMsg, serveStorageRanges(db, &req))
+
+	case StorageRangesMsg:
+		var res storageRangesData
+		if err := msg.Decode(&res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		hashes, slots := res.unpack()
+		return syncer.OnStorage(peer, res.ID, hashes, slots, res.Proof)
+
+	case GetByteCodesMsg:
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return p2p.Send(peer.rw, ByteCodesMsg, serveByteCodes(db, &req))
+
+	case ByteCodesMsg:
+		var res byteCodesData
+		if err := msg.Decode(&res); err != nil {
+			return fmt.Errorf("%v: message %v: %v", errDecode, msg, err)
+		}
+		return syncer.OnByteCodes(peer, res.ID, res.Codes)
+
+	default:
+		return fmt.Errorf("%v: %v", errInvalidMsgCode, msg.Code)
+	}
+}
+
+// responseLimit caps the requested response size at the soft response limit.
+func responseLimit(bytes uint64) uint64 {
+	if bytes > softResponseLimit {
+		return softResponseLimit
+	}
+	return bytes
+}
+
+// serveAccountRange retrieves the accounts of the requested range from the local
+// state, along with the proofs of the range edges. If the requested state is not
+// available, an empty response without proofs is returned.
+func serveAccountRange(db state.Database, req *getAccountRangeData) *accountRangeData {
+	res := &accountRangeData{ID: req.ID}
+
+	tr, err := trie.New(req.Root, db.TrieDB())
+	if err != nil {
+		return res
+	}
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+		it    = trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	)
+	for size < limit && it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if bytes.Compare(hash[:], req.Limit[:]) > 0 {
+			break
+		}
+		res.Accounts = append(res.Accounts, &accountData{Hash: hash, Body: common.CopyBytes(it.Value)})
+		size += uint64(common.HashLength + len(it.Value))
+	}
+	if it.Err != nil {
+		return &accountRangeData{ID: req.ID}
+	}
+	// Generate the Merkle proofs for the first and last account, or for the range
+	// limit if there are no accounts in the requested range
+	keys := [][]byte{req.Origin[:]}
+	if len(res.Accounts) > 0 {
+		keys = append(keys, res.Accounts[len(res.Accounts)-1].Hash[:])
+	} else if req.Limit != maxHash {
+		keys = append(keys, req.Limit[:])
+	}
+	if res.Proof, err = proveKeys(tr, keys); err != nil {
+		log.Warn("Failed to prove account range", "origin", req.Origin, "err", err)
+		return &accountRangeData{ID: req.ID}
+	}
+	return res
+}
+
+// serveStorageRanges retrieves the storage slots of the requested accounts from
+// the local state. If the response is capped by the size limit, or if the first
+// account is served from a non-zero origin, the last slot range is proven.
+func serveStorageRanges(db state.Database, req *getStorageRangesData) *storageRangesData {
+	res := &storageRangesData{ID: req.ID}
+
+	accTrie, err := trie.New(req.Root, db.TrieDB())
+	if err != nil {
+		return res
+	}
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, account := range req.Accounts {
+		// If we've exceeded the requested data limit, abort without opening
+		// a new storage range (that we'd need to prove due to exceeded size)
+		if size >= limit {
+			break
+		}
+		blob, err := accTrie.TryGet(account[:])
+		if err != nil {
+			break
+		}
+		// Accounts missing from the state (requester syncing an older root) are
+		// served with an empty storage, the requester will heal them later
+		if blob == nil {
+			res.Slots = append(res.Slots, nil)
+			continue
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			break
+		}
+		stTrie, err := trie.New(acc.Root, db.TrieDB())
+		if err != nil {
+			break
+		}
+		// The first account might start from a different origin and the last
+		// might end before the storage trie does
+		var origin, last common.Hash
+		if i == 0 && len(req.Origin) > 0 {
+			origin = common.BytesToHash(req.Origin)
+		}
+		last = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		if i == len(req.Accounts)-1 && len(req.Limit) > 0 {
+			last = common.BytesToHash(req.Limit)
+		}
+		var (
+			slots []*storageData
+			abort bool
+			it    = trie.NewIterator(stTrie.NodeIterator(origin[:]))
+		)
+		for it.Next() {
+			if size >= limit {
+				abort = true
+				break
+			}
+			hash := common.BytesToHash(it.Key)
+			if bytes.Compare(hash[:], last[:]) > 0 {
+				break
+			}
+			slots = append(slots, &storageData{Hash: hash, Body: common.CopyBytes(it.Value)})
+			size += uint64(common.HashLength + len(it.Value))
+		}
+		if it.Err != nil {
+			break
+		}
+		res.Slots = append(res.Slots, slots)
+
+		// If the range is incomplete or started at an origin, prove it and stop
+		if abort || origin != (common.Hash{}) {
+			keys := [][]byte{origin[:]}
+			if len(slots) > 0 {
+				keys = append(keys, slots[len(slots)-1].Hash[:])
+			}
+			if res.Proof, err = proveKeys(stTrie, keys); err != nil {
+				log.Warn("Failed to prove storage range", "account", account, "origin", origin, "err", err)
+				return &storageRangesData{ID: req.ID}
+			}
+			break
+		}
+	}
+	return res
+}
+
+// serveByteCodes retrieves the requested contract codes from the local database,
+// skipping any that are not available.
+func serveByteCodes(db state.Database, req *getByteCodesData) *byteCodesData {
+	var (
+		res   = &byteCodesData{ID: req.ID}
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, hash := range req.Hashes {
+		if i >= maxCodeLookups || size >= limit {
+			break
+		}
+		if hash == emptyCode {
+			// Peers should not request the empty code, but if they do, at
+			// least sent them back a correct response without db lookups
+			res.Codes = append(res.Codes, []byte{})
+			continue
+		}
+		if code, err := db.ContractCode(common.Hash{}, hash); err == nil && len(code) > 0 {
+			res.Codes = append(res.Codes, code)
+			size += uint64(len(code))
+		}
+	}
+	return res
+}
+
+// proveKeys generates the deduplicated union of the Merkle proofs of the given
+// keys in a trie.
+func proveKeys(tr *trie.Trie, keys [][]byte) ([][]byte, error) {
+	proof := ethdb.NewMemDatabase()
+	for _, key := range keys {
+		if err := tr.Prove(key, 0, proof); err != nil {
+			return nil, err
+		}
+	}
+	nodes := make([][]byte, 0, proof.Len())
+	for _, key := range proof.Keys() {
+		node, _ := proof.Get(key)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,1016 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+
+	// maxHash is the last hash of the key space.
+	maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+const (
+	// maxRequestSize is the maximum number of bytes to request from a remote peer.
+	maxRequestSize = 512 * 1024
+
+	// maxStorageSetRequestCount is the maximum number of contracts to request the
+	// storage of in a single query. If this number is too low, we're not filling
+	// responses fully and waste round trip times. If it's too high, we're capping
+	// responses and waste bandwidth.
+	maxStorageSetRequestCount = maxRequestSize / 1024
+
+	// maxCodeRequestCount is the maximum number of bytecode blobs to request in a
+	// single query. If this number is too low, we're not filling responses fully
+	// and waste round trip times. If it's too high, we're capping responses and
+	// waste bandwidth.
+	maxCodeRequestCount = maxRequestSize / (24 * 1024) * 16
+
+	// accountConcurrency is the number of chunks to split the account trie into
+	// to allow concurrent retrievals.
+	accountConcurrency = 16
+
+	// requestTimeout is the maximum time a peer is allowed to spend on serving a
+	// single network request.
+	requestTimeout = 10 * time.Second
+
+	// trieFlushSize is the size of the in-memory trie node cache above which the
+	// account trie is committed to disk.
+	trieFlushSize = 64 * 1024 * 1024
+)
+
+// errCancelled is returned if a sync is aborted by the user.
+var errCancelled = errors.New("sync cancelled")
+
+// accountRequest tracks a pending account range request to ensure responses are
+// to actual requests and to validate any security constraints.
+type accountRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	root   common.Hash // State root the range is requested from
+	origin common.Hash // First account requested to allow continuation checks
+	limit  common.Hash // Last account requested to allow non-overlapping chunking
+
+	task    *accountTask  // Task which this request is filling
+	timeout *time.Timer   // Timer to track delivery timeout
+	stale   chan struct{} // Channel to signal the request was dropped
+}
+
+// accountResponse is an already Merkle-verified remote response to an account
+// range request. It contains the subtrie for the requested account range and
+// the database that's going to be filled with the internal nodes on commit.
+type accountResponse struct {
+	req      *accountRequest // Original request to match up the response with
+	hashes   []common.Hash   // Account hashes in the returned range
+	accounts [][]byte        // Expanded accounts in the returned range
+	proof    [][]byte        // Merkle proof of the range edges
+}
+
+// storageRequest tracks a pending storage ranges request to ensure responses are
+// to actual requests and to validate any security constraints.
+type storageRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	root  common.Hash    // State root the ranges are requested from
+	tasks []*storageTask // Tasks which this request is filling
+
+	timeout *time.Timer   // Timer to track delivery timeout
+	stale   chan struct{} // Channel to signal the request was dropped
+}
+
+// storageResponse is a remote response to a storage ranges request.
+type storageResponse struct {
+	req    *storageRequest // Original request to match up the response with
+	hashes [][]common.Hash // Storage slot hashes in the returned ranges
+	slots  [][][]byte      // Storage slot values in the returned ranges
+	proof  [][]byte        // Merkle proof of the last range edges
+}
+
+// bytecodeRequest tracks a pending bytecode request to ensure responses are to
+// actual requests and to validate any security constraints.
+type bytecodeRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	hashes []common.Hash // Bytecode hashes to validate responses
+
+	timeout *time.Timer   // Timer to track delivery timeout
+	stale   chan struct{} // Channel to signal the request was dropped
+}
+
+// bytecodeResponse is a remote response to a bytecode request.
+type bytecodeResponse struct {
+	req   *bytecodeRequest // Original request to match up the response with
+	codes [][]byte         // Actual bytecodes to store into the database
+}
+
+// accountTask represents the sync task for a chunk of the account snapshot.
+type accountTask struct {
+	next common.Hash // Next account to sync in this interval
+	last common.Hash // Last account to sync in this interval
+	done bool        // Flag whether the whole interval was retrieved
+
+	req *accountRequest // Pending request to fill this task
+}
+
+// storageTask represents the sync task for the storage trie of an account.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage
+	root    common.Hash // Expected storage root to verify the trie against
+	next    common.Hash // Next storage slot to sync (non-zero for continuations)
+	trie    *trie.Trie  // Partially retrieved storage trie (nil if nothing yet)
+
+	req *storageRequest // Pending request to fill this task
+}
+
+// Syncer is an Ethereum state synchroniser retrieving the account and storage
+// tries of a recent state root as contiguous, Merkle proven ranges, along with
+// the contract codes. The ranges may be retrieved from different (moving) state
+// roots, so the result needs to be healed via trie node sync afterwards.
+type Syncer struct {
+	db     ethdb.Database // Database to store the trie nodes into
+	triedb *trie.Database // Trie node cache in front of the database
+
+	root         common.Hash              // Current state trie root being synced
+	tasks        []*accountTask           // Current account task set being synced
+	accountTrie  *trie.Trie               // Account trie being reconstructed
+	storageTasks []*storageTask           // Storage tries still to be synced
+	codeTasks    map[common.Hash]struct{} // Code hashes still to be synced
+
+	peers     map[string]*Peer    // Currently active peers to download from
+	stateless map[string]struct{} // Peers that failed to deliver the current root
+	update    chan struct{}       // Notification channel for possible sync progression
+
+	accountReqs  map[uint64]*accountRequest  // Account requests currently running
+	storageReqs  map[uint64]*storageRequest  // Storage requests currently running
+	bytecodeReqs map[uint64]*bytecodeRequest // Bytecode requests currently running
+
+	accountReqFails  chan *accountRequest  // Failed account range requests to revert
+	storageReqFails  chan *storageRequest  // Failed storage range requests to revert
+	bytecodeReqFails chan *bytecodeRequest // Failed bytecode requests to revert
+
+	accountResps  chan *accountResponse  // Account range responses to process
+	storageResps  chan *storageResponse  // Storage range responses to process
+	bytecodeResps chan *bytecodeResponse // Bytecode responses to process
+
+	accountSynced  uint64             // Number of accounts downloaded
+	accountBytes   common.StorageSize // Number of account trie bytes persisted to disk
+	storageSynced  uint64             // Number of storage slots downloaded
+	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
+	bytecodeSynced uint64             // Number of bytecodes downloaded
+	bytecodeBytes  common.StorageSize // Number of bytecode bytes downloaded
+
+	startTime time.Time // Time instance when snapshot sync started
+	logTime   time.Time // Time instance when status was last reported
+
+	lock sync.RWMutex // Protects fields that can change outside of sync (peers, reqs, root)
+}
+
+// NewSyncer creates a new snapshot syncer to download the Ethereum state over the
+// snap protocol.
+func NewSyncer(db ethdb.Database) *Syncer {
+	return &Syncer{
+		db:               db,
+		triedb:           trie.NewDatabase(db),
+		peers:            make(map[string]*Peer),
+		stateless:        make(map[string]struct{}),
+		update:           make(chan struct{}, 1),
+		accountReqs:      make(map[uint64]*accountRequest),
+		storageReqs:      make(map[uint64]*storageRequest),
+		bytecodeReqs:     make(map[uint64]*bytecodeRequest),
+		accountReqFails:  make(chan *accountRequest),
+		storageReqFails:  make(chan *storageRequest),
+		bytecodeReqFails: make(chan *bytecodeRequest),
+		accountResps:     make(chan *accountResponse),
+		storageResps:     make(chan *storageResponse),
+		bytecodeResps:    make(chan *bytecodeResponse),
+	}
+}
+
+// Register injects a new data source into the syncer's peerset.
+func (s *Syncer) Register(peer *Peer) {
+	s.lock.Lock()
+	s.peers[peer.id] = peer
+	s.lock.Unlock()
+
+	s.notify()
+}
+
+// Unregister removes a data source from the syncer's peerset, rescheduling any
+// of its pending requests.
+func (s *Syncer) Unregister(id string) {
+	s.lock.Lock()
+	delete(s.peers, id)
+	s.lock.Unlock()
+
+	s.notify()
+}
+
+// notify signals the sync loop that peers or requests changed.
+func (s *Syncer) notify() {
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+}
+
+// Sync starts (or resumes a previous) sync cycle to iterate over a state trie
+// with the given root and reconstruct the nodes based on the snapshot leaves.
+// Previously downloaded segments will not be redownloaded or fixed, rather any
+// errors will be healed after the leaves are fully accumulated.
+func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
+	s.lock.Lock()
+	if s.root != root {
+		s.root = root
+		s.stateless = make(map[string]struct{})
+	}
+	if s.tasks == nil {
+		s.initTasks()
+	}
+	s.lock.Unlock()
+
+	s.startTime, s.logTime = time.Now(), time.Now()
+	defer s.revertRequests(true)
+
+	log.Debug("Starting snapshot sync cycle", "root", root)
+	for {
+		// Drop any requests whose peers disconnected and check for completion
+		s.revertRequests(false)
+		if s.complete() {
+			return s.commit()
+		}
+		// Assign all the data retrieval tasks to any free peers
+		s.assignAccountTasks()
+		s.assignBytecodeTasks()
+		s.assignStorageTasks()
+
+		// Wait for something to happen
+		select {
+		case <-s.update:
+			// Something happened (new peer, delivery, timeout), recheck tasks
+		case <-cancel:
+			return errCancelled
+
+		case req := <-s.accountReqFails:
+			s.revertAccountRequest(req)
+		case req := <-s.bytecodeReqFails:
+			s.revertBytecodeRequest(req)
+		case req := <-s.storageReqFails:
+			s.revertStorageRequest(req)
+
+		case res := <-s.accountResps:
+			s.processAccountResponse(res)
+		case res := <-s.bytecodeResps:
+			s.processBytecodeResponse(res)
+		case res := <-s.storageResps:
+			s.processStorageResponse(res)
+		}
+		if err := s.flush(false); err != nil {
+			return err
+		}
+		s.report(false)
+	}
+}
+
+// initTasks splits the account hash space into equal chunks to sync concurrently.
+func (s *Syncer) initTasks() {
+	var (
+		next = common.Hash{}
+		step = new(big.Int).Sub(
+			new(big.Int).Div(
+				new(big.Int).Exp(common.Big2, common.Big256, nil),
+				big.NewInt(accountConcurrency),
+			), common.Big1,
+		)
+	)
+	s.tasks = make([]*accountTask, 0, accountConcurrency)
+	for i := 0; i < accountConcurrency; i++ {
+		last := common.BigToHash(new(big.Int).Add(next.Big(), step))
+		if i == accountConcurrency-1 {
+			last = maxHash
+		}
+		s.tasks = append(s.tasks, &accountTask{next: next, last: last})
+		next = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+	}
+	s.accountTrie, _ = trie.New(common.Hash{}, s.triedb)
+	s.codeTasks = make(map[common.Hash]struct{})
+}
+
+// complete returns whether all the ranges, storage tries and codes have been
+// retrieved and there are no more requests in flight.
+func (s *Syncer) complete() bool {
+	for _, task := range s.tasks {
+		if !task.done {
+			return false
+		}
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.storageTasks) == 0 && len(s.codeTasks) == 0 &&
+		len(s.accountReqs) == 0 && len(s.storageReqs) == 0 && len(s.bytecodeReqs) == 0
+}
+
+// commit writes the reconstructed account trie to disk once all the ranges are
+// retrieved. The remaining differences to the requested root (ranges synced
+// from earlier roots, unproven gaps) are left for the trie node healing.
+func (s *Syncer) commit() error {
+	if s.accountTrie == nil {
+		return nil // Already done in a previous cycle, only healing remains
+	}
+	root, err := s.accountTrie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := s.triedb.Commit(root, false); err != nil {
+		return err
+	}
+	s.accountTrie = nil
+
+	s.report(true)
+	if root != s.root {
+		log.Info("Snapshot sync complete, healing required", "root", s.root, "have", root)
+	} else {
+		log.Info("Snapshot sync complete", "root", root)
+	}
+	return nil
+}
+
+// flush persists the reconstructed part of the account trie if the trie node
+// cache grew too large.
+func (s *Syncer) flush(force bool) error {
+	if s.accountTrie == nil {
+		return nil
+	}
+	if size, _ := s.triedb.Size(); !force && size < trieFlushSize {
+		return nil
+	}
+	root, err := s.accountTrie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	size, _ := s.triedb.Size()
+	if err := s.triedb.Commit(root, false); err != nil {
+		return err
+	}
+	s.accountBytes += size
+	return nil
+}
+
+// idlePeers returns the peers without any pending requests, which may serve the
+// current root.
+func (s *Syncer) idlePeers() []*Peer {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	busy := make(map[string]bool)
+	for _, req := range s.accountReqs {
+		busy[req.peer] = true
+	}
+	for _, req := range s.storageReqs {
+		busy[req.peer] = true
+	}
+	for _, req := range s.bytecodeReqs {
+		busy[req.peer] = true
+	}
+	var idle []*Peer
+	for id, peer := range s.peers {
+		if _, stateless := s.stateless[id]; !busy[id] && !stateless {
+			idle = append(idle, peer)
+		}
+	}
+	return idle
+}
+
+// requestID generates a new request identifier not used by any pending request.
+func (s *Syncer) requestID() uint64 {
+	for {
+		id := rand.Uint64()
+		if _, ok := s.accountReqs[id]; ok {
+			continue
+		}
+		if _, ok := s.storageReqs[id]; ok {
+			continue
+		}
+		if _, ok := s.bytecodeReqs[id]; ok {
+			continue
+		}
+		return id
+	}
+}
+
+// assignAccountTasks attempts to match idle peers to pending account range
+// retrievals.
+func (s *Syncer) assignAccountTasks() {
+	peers := s.idlePeers()
+	for _, task := range s.tasks {
+		if len(peers) == 0 {
+			return
+		}
+		if task.done || task.req != nil {
+			continue
+		}
+		peer := peers[0]
+		peers = peers[1:]
+
+		s.lock.Lock()
+		req := &accountRequest{
+			peer:   peer.id,
+			id:     s.requestID(),
+			root:   s.root,
+			origin: task.next,
+			limit:  task.last,
+			task:   task,
+			stale:  make(chan struct{}),
+		}
+		req.timeout = time.AfterFunc(requestTimeout, func() {
+			log.Debug("Account range request timed out", "peer", req.peer, "reqid", req.id)
+			s.scheduleRevertAccountRequest(req)
+		})
+		s.accountReqs[req.id] = req
+		task.req = req
+		s.lock.Unlock()
+
+		go func(peer *Peer) {
+			if err := peer.RequestAccountRange(req.id, req.root, req.origin, req.limit, maxRequestSize); err != nil {
+				log.Debug("Failed to request account range", "peer", peer.id, "err", err)
+				s.scheduleRevertAccountRequest(req)
+			}
+		}(peer)
+	}
+}
+
+// assignStorageTasks attempts to match idle peers to pending storage range
+// retrievals. Fresh storage tries are requested in batches, continuations of
+// partially retrieved ones on their own.
+func (s *Syncer) assignStorageTasks() {
+	for _, peer := range s.idlePeers() {
+		var tasks []*storageTask
+		for _, task := range s.storageTasks {
+			if task.req != nil {
+				continue
+			}
+			if task.trie != nil {
+				if len(tasks) == 0 {
+					tasks = append(tasks, task)
+					break
+				}
+				continue
+			}
+			if tasks = append(tasks, task); len(tasks) >= maxStorageSetRequestCount {
+				break
+			}
+		}
+		if len(tasks) == 0 {
+			return
+		}
+		accounts := make([]common.Hash, len(tasks))
+		for i, task := range tasks {
+			accounts[i] = task.account
+		}
+		var origin []byte
+		if tasks[0].next != (common.Hash{}) {
+			origin = tasks[0].next[:]
+		}
+		s.lock.Lock()
+		req := &storageRequest{
+			peer:  peer.id,
+			id:    s.requestID(),
+			root:  s.root,
+			tasks: tasks,
+			stale: make(chan struct{}),
+		}
+		req.timeout = time.AfterFunc(requestTimeout, func() {
+			log.Debug("Storage request timed out", "peer", req.peer, "reqid", req.id)
+			s.scheduleRevertStorageRequest(req)
+		})
+		s.storageReqs[req.id] = req
+		for _, task := range tasks {
+			task.req = req
+		}
+		s.lock.Unlock()
+
+		go func(peer *Peer) {
+			if err := peer.RequestStorageRanges(req.id, req.root, accounts, origin, nil, maxRequestSize); err != nil {
+				log.Debug("Failed to request storage", "peer", peer.id, "err", err)
+				s.scheduleRevertStorageRequest(req)
+			}
+		}(peer)
+	}
+}
+
+// assignBytecodeTasks attempts to match idle peers to pending code retrievals.
+func (s *Syncer) assignBytecodeTasks() {
+	for _, peer := range s.idlePeers() {
+		if len(s.codeTasks) == 0 {
+			return
+		}
+		hashes := make([]common.Hash, 0, maxCodeRequestCount)
+		for hash := range s.codeTasks {
+			delete(s.codeTasks, hash)
+
+			if hashes = append(hashes, hash); len(hashes) >= maxCodeRequestCount {
+				break
+			}
+		}
+		s.lock.Lock()
+		req := &bytecodeRequest{
+			peer:   peer.id,
+			id:     s.requestID(),
+			hashes: hashes,
+			stale:  make(chan struct{}),
+		}
+		req.timeout = time.AfterFunc(requestTimeout, func() {
+			log.Debug("Bytecode request timed out", "peer", req.peer, "reqid", req.id)
+			s.scheduleRevertBytecodeRequest(req)
+		})
+		s.bytecodeReqs[req.id] = req
+		s.lock.Unlock()
+
+		go func(peer *Peer) {
+			if err := peer.RequestByteCodes(req.id, hashes, maxRequestSize); err != nil {
+				log.Debug("Failed to request bytecodes", "peer", peer.id, "err", err)
+				s.scheduleRevertBytecodeRequest(req)
+			}
+		}(peer)
+	}
+}
+
+// revertRequests reverts the pending requests of disconnected peers, or all of
+// them if the sync cycle is terminating.
+func (s *Syncer) revertRequests(all bool) {
+	s.lock.RLock()
+	var (
+		accountReqs  []*accountRequest
+		storageReqs  []*storageRequest
+		bytecodeReqs []*bytecodeRequest
+	)
+	for _, req := range s.accountReqs {
+		if _, ok := s.peers[req.peer]; all || !ok {
+			accountReqs = append(accountReqs, req)
+		}
+	}
+	for _, req := range s.storageReqs {
+		if _, ok := s.peers[req.peer]; all || !ok {
+			storageReqs = append(storageReqs, req)
+		}
+	}
+	for _, req := range s.bytecodeReqs {
+		if _, ok := s.peers[req.peer]; all || !ok {
+			bytecodeReqs = append(bytecodeReqs, req)
+		}
+	}
+	s.lock.RUnlock()
+
+	for _, req := range accountReqs {
+		s.revertAccountRequest(req)
+	}
+	for _, req := range storageReqs {
+		s.revertStorageRequest(req)
+	}
+	for _, req := range bytecodeReqs {
+		s.revertBytecodeRequest(req)
+	}
+}
+
+// scheduleRevertAccountRequest asks the event loop to clean up an account range
+// request and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertAccountRequest(req *accountRequest) {
+	select {
+	case s.accountReqFails <- req:
+		// Sync event loop notified
+	case <-req.stale:
+		// Sync cycle got cancelled
+	}
+}
+
+// revertAccountRequest cleans up an account range request and returns the task
+// to the scheduler for reassignment. It's a no-op for already handled requests.
+func (s *Syncer) revertAccountRequest(req *accountRequest) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.accountReqs[req.id]; !ok {
+		return
+	}
+	delete(s.accountReqs, req.id)
+	req.timeout.Stop()
+	close(req.stale)
+
+	req.task.req = nil
+}
+
+// scheduleRevertStorageRequest asks the event loop to clean up a storage range
+// request and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertStorageRequest(req *storageRequest) {
+	select {
+	case s.storageReqFails <- req:
+		// Sync event loop notified
+	case <-req.stale:
+		// Sync cycle got cancelled
+	}
+}
+
+// revertStorageRequest cleans up a storage range request and returns the tasks
+// to the scheduler for reassignment. It's a no-op for already handled requests.
+func (s *Syncer) revertStorageRequest(req *storageRequest) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.storageReqs[req.id]; !ok {
+		return
+	}
+	delete(s.storageReqs, req.id)
+	req.timeout.Stop()
+	close(req.stale)
+
+	for _, task := range req.tasks {
+		task.req = nil
+	}
+}
+
+// scheduleRevertBytecodeRequest asks the event loop to clean up a bytecode
+// request and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertBytecodeRequest(req *bytecodeRequest) {
+	select {
+	case s.bytecodeReqFails <- req:
+		// Sync event loop notified
+	case <-req.stale:
+		// Sync cycle got cancelled
+	}
+}
+
+// revertBytecodeRequest cleans up a bytecode request and returns the code hashes
+// to the scheduler for reassignment. It's a no-op for already handled requests.
+func (s *Syncer) revertBytecodeRequest(req *bytecodeRequest) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.bytecodeReqs[req.id]; !ok {
+		return
+	}
+	delete(s.bytecodeReqs, req.id)
+	req.timeout.Stop()
+	close(req.stale)
+
+	for _, hash := range req.hashes {
+		s.codeTasks[hash] = struct{}{}
+	}
+}
+
+// markStateless flags a peer as unable to serve the current sync root, so it's
+// not assigned any more tasks until the root changes.
+func (s *Syncer) markStateless(peer string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stateless[peer] = struct{}{}
+}
+
+// processAccountResponse integrates an already validated account range response
+// into the account trie, scheduling the retrieval of the referenced storage
+// tries and codes.
+func (s *Syncer) processAccountResponse(res *accountResponse) {
+	// Ensure the request is still pending (not reverted in the meantime)
+	s.lock.Lock()
+	if _, ok := s.accountReqs[res.req.id]; !ok {
+		s.lock.Unlock()
+		return
+	}
+	delete(s.accountReqs, res.req.id)
+	res.req.timeout.Stop()
+	close(res.req.stale)
+	s.lock.Unlock()
+
+	task := res.req.task
+	task.req = nil
+
+	// An empty response without proofs signals that the peer doesn't have the
+	// requested state (anymore), don't bother it until the root changes
+	if len(res.hashes) == 0 && len(res.proof) == 0 {
+		log.Debug("Peer rejected account range request", "peer", res.req.peer, "root", res.req.root)
+		s.markStateless(res.req.peer)
+		return
+	}
+	more, err := verifyRange(res.req.root, res.req.origin, res.req.limit, res.hashes, res.accounts, res.proof)
+	if err != nil {
+		log.Warn("Invalid account range delivered", "peer", res.req.peer, "err", err)
+		s.markStateless(res.req.peer)
+		return
+	}
+	for i, hash := range res.hashes {
+		var acc state.Account
+		if err := rlp.DecodeBytes(res.accounts[i], &acc); err != nil {
+			log.Warn("Invalid account delivered", "peer", res.req.peer, "hash", hash, "err", err)
+			s.markStateless(res.req.peer)
+			return
+		}
+		if err := s.accountTrie.TryUpdate(hash[:], res.accounts[i]); err != nil {
+			log.Error("Failed to insert synced account", "hash", hash, "err", err)
+			return
+		}
+		if acc.Root != emptyRoot {
+			if ok, _ := s.db.Has(acc.Root[:]); !ok {
+				s.storageTasks = append(s.storageTasks, &storageTask{account: hash, root: acc.Root})
+			}
+		}
+		if code := common.BytesToHash(acc.CodeHash); code != emptyCode {
+			if ok, _ := s.db.Has(code[:]); !ok {
+				s.codeTasks[code] = struct{}{}
+			}
+		}
+		s.accountSynced++
+	}
+	// Move the task forward, or mark it done if the end of the chunk is reached.
+	// A range proven to be the tail of the trie means there are no more accounts.
+	if !more || len(res.hashes) == 0 {
+		task.done = true
+		return
+	}
+	last := res.hashes[len(res.hashes)-1]
+	if next, overflow := incHash(last); overflow || bytes.Compare(last[:], task.last[:]) >= 0 {
+		task.done = true
+	} else {
+		task.next = next
+	}
+}
+
+// processStorageResponse integrates a storage ranges response into the storage
+// tries being synced, committing those that completed.
+func (s *Syncer) processStorageResponse(res *storageResponse) {
+	// Ensure the request is still pending (not reverted in the meantime)
+	s.lock.Lock()
+	if _, ok := s.storageReqs[res.req.id]; !ok {
+		s.lock.Unlock()
+		return
+	}
+	delete(s.storageReqs, res.req.id)
+	res.req.timeout.Stop()
+	close(res.req.stale)
+	s.lock.Unlock()
+
+	for _, task := range res.req.tasks {
+		task.req = nil
+	}
+	// An empty response signals that the peer doesn't have the requested state
+	// (anymore), since only non-empty storage tries are ever requested
+	if len(res.hashes) == 0 {
+		log.Debug("Peer rejected storage request", "peer", res.req.peer, "root", res.req.root)
+		s.markStateless(res.req.peer)
+		return
+	}
+	if len(res.hashes) > len(res.req.tasks) {
+		log.Warn("Too many storage ranges delivered", "peer", res.req.peer, "have", len(res.hashes), "want", len(res.req.tasks))
+		s.markStateless(res.req.peer)
+		return
+	}
+	completed := make(map[*storageTask]bool)
+	for i, hashes := range res.hashes {
+		task := res.req.tasks[i]
+
+		// Only the last range may be partial and proven, the others must be the
+		// entire storage tries
+		var proof [][]byte
+		if i == len(res.hashes)-1 {
+			proof = res.proof
+		}
+		more, err := verifyRange(task.root, task.next, maxHash, hashes, res.slots[i], proof)
+		if err != nil {
+			log.Warn("Invalid storage range delivered", "peer", res.req.peer, "err", err)
+			s.markStateless(res.req.peer)
+			break
+		}
+		if task.trie == nil {
+			task.trie, _ = trie.New(common.Hash{}, s.triedb)
+		}
+		for j, hash := range hashes {
+			if err := task.trie.TryUpdate(hash[:], res.slots[i][j]); err != nil {
+				log.Error("Failed to insert synced storage slot", "hash", hash, "err", err)
+				return
+			}
+		}
+		s.storageSynced += uint64(len(hashes))
+
+		if more && len(hashes) > 0 {
+			if next, overflow := incHash(hashes[len(hashes)-1]); !overflow {
+				task.next = next
+				continue
+			}
+		}
+		// Storage trie fully retrieved and verified, commit it
+		root, err := task.trie.Commit(nil)
+		if err != nil {
+			log.Error("Failed to commit synced storage trie", "account", task.account, "err", err)
+			return
+		}
+		size, _ := s.triedb.Size()
+		if err := s.triedb.Commit(root, false); err != nil {
+			log.Error("Failed to persist synced storage trie", "account", task.account, "err", err)
+			return
+		}
+		s.storageBytes += size
+		completed[task] = true
+	}
+	tasks := s.storageTasks[:0]
+	for _, task := range s.storageTasks {
+		if !completed[task] {
+			tasks = append(tasks, task)
+		}
+	}
+	s.storageTasks = tasks
+}
+
+// processBytecodeResponse stores the delivered contract codes, rescheduling the
+// ones missing from the response.
+func (s *Syncer) processBytecodeResponse(res *bytecodeResponse) {
+	// Ensure the request is still pending (not reverted in the meantime)
+	s.lock.Lock()
+	if _, ok := s.bytecodeReqs[res.req.id]; !ok {
+		s.lock.Unlock()
+		return
+	}
+	delete(s.bytecodeReqs, res.req.id)
+	res.req.timeout.Stop()
+	close(res.req.stale)
+	s.lock.Unlock()
+
+	// Cross reference the delivered codes with the requested hashes
+	delivered := make(map[common.Hash][]byte)
+	for _, code := range res.codes {
+		delivered[crypto.Keccak256Hash(code)] = code
+	}
+	batch := s.db.NewBatch()
+	for _, hash := range res.req.hashes {
+		code, ok := delivered[hash]
+		if !ok {
+			s.codeTasks[hash] = struct{}{}
+			continue
+		}
+		batch.Put(hash[:], code)
+		s.bytecodeSynced++
+		s.bytecodeBytes += common.StorageSize(len(code))
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to persist bytecodes", "err", err)
+	}
+	if len(delivered) == 0 {
+		log.Debug("Peer rejected bytecode request", "peer", res.req.peer)
+		s.markStateless(res.req.peer)
+	}
+}
+
+// OnAccounts is a callback method to invoke when a range of accounts are
+// received from a remote peer.
+func (s *Syncer) OnAccounts(peer *Peer, id uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	s.lock.RLock()
+	req, ok := s.accountReqs[id]
+	s.lock.RUnlock()
+
+	if !ok || req.peer != peer.id {
+		// Request stale, perhaps the peer timed out but came through in the end
+		peer.Log().Debug("Unexpected account range packet", "reqid", id)
+		return nil
+	}
+	select {
+	case s.accountResps <- &accountResponse{req: req, hashes: hashes, accounts: accounts, proof: proof}:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// OnStorage is a callback method to invoke when ranges of storage slots are
+// received from a remote peer.
+func (s *Syncer) OnStorage(peer *Peer, id uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) error {
+	s.lock.RLock()
+	req, ok := s.storageReqs[id]
+	s.lock.RUnlock()
+
+	if !ok || req.peer != peer.id {
+		// Request stale, perhaps the peer timed out but came through in the end
+		peer.Log().Debug("Unexpected storage ranges packet", "reqid", id)
+		return nil
+	}
+	select {
+	case s.storageResps <- &storageResponse{req: req, hashes: hashes, slots: slots, proof: proof}:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// OnByteCodes is a callback method to invoke when a batch of contract bytecodes
+// are received from a remote peer.
+func (s *Syncer) OnByteCodes(peer *Peer, id uint64, codes [][]byte) error {
+	s.lock.RLock()
+	req, ok := s.bytecodeReqs[id]
+	s.lock.RUnlock()
+
+	if !ok || req.peer != peer.id {
+		// Request stale, perhaps the peer timed out but came through in the end
+		peer.Log().Debug("Unexpected bytecode packet", "reqid", id)
+		return nil
+	}
+	if len(codes) > len(req.hashes) {
+		return fmt.Errorf("%v: too many bytecodes: have %d, want %d", errBadRequest, len(codes), len(req.hashes))
+	}
+	select {
+	case s.bytecodeResps <- &bytecodeResponse{req: req, codes: codes}:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// report calculates various status reports and provides it to the user.
+func (s *Syncer) report(force bool) {
+	// Don't report all the events, just occasionally
+	if !force && time.Since(s.logTime) < 8*time.Second {
+		return
+	}
+	s.logTime = time.Now()
+
+	log.Info("State sync in progress", "accounts", s.accountSynced, "slots", s.storageSynced,
+		"codes", s.bytecodeSynced, "state", s.accountBytes+s.storageBytes+s.bytecodeBytes,
+		"pending", len(s.storageTasks)+len(s.codeTasks), "elapsed", common.PrettyDuration(time.Since(s.startTime)))
+}
+
+// verifyRange checks that a delivered range of trie leaves is exactly the set
+// of entries of the trie with the given root, starting at origin and bounded by
+// the requested limit. If no proof is given, the range must be the entire trie.
+// The returned flag reports whether the trie has more entries after the range.
+func verifyRange(root common.Hash, origin, limit common.Hash, keys []common.Hash, values [][]byte, proof [][]byte) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("inconsistent range: %d keys, %d values", len(keys), len(values))
+	}
+	if len(keys) > 0 && bytes.Compare(keys[len(keys)-1][:], limit[:]) > 0 {
+		return false, fmt.Errorf("key %x beyond range limit %x", keys[len(keys)-1], limit)
+	}
+	rawKeys := make([][]byte, len(keys))
+	for i := range keys {
+		rawKeys[i] = keys[i][:]
+	}
+	// A range without proofs must be the entire trie
+	if len(proof) == 0 {
+		return trie.VerifyRangeProof(root, nil, nil, rawKeys, values, nil)
+	}
+	proofDb := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	// An empty range is proven by the origin and the limit (if not the end of
+	// the key space), otherwise by the origin and the last key
+	var last []byte
+	if len(keys) > 0 {
+		last = rawKeys[len(rawKeys)-1]
+	} else if limit != maxHash {
+		last = limit[:]
+	}
+	return trie.VerifyRangeProof(root, origin[:], last, rawKeys, values, proofDb)
+}
+
+// incHash returns the next hash, in lexicographical order (a.k.a plus one),
+// along with whether the increment overflowed.
+func incHash(h common.Hash) (common.Hash, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			return h, false
+		}
+	}
+	return h, true
+}
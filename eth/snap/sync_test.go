@@ -0,0 +1,221 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// makeTestState creates a state with plain accounts, small contracts and a
+// contract with a storage large enough to not fit into a single response.
+func makeTestState(t *testing.T, accounts int, slots int) (state.Database, common.Hash) {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+
+	for i := 0; i < accounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i)))
+		statedb.SetNonce(addr, uint64(i))
+
+		if i%10 == 0 {
+			statedb.SetCode(addr, []byte{0x60, byte(i), 0x60, byte(i >> 8)})
+			for j := 0; j < 5; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j+1))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	large := common.HexToAddress("0xdeadbeef")
+	for j := 0; j < slots; j++ {
+		statedb.SetState(large, common.BigToHash(big.NewInt(int64(j+1))), common.BigToHash(big.NewInt(int64(j+1))))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit test state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to persist test state: %v", err)
+	}
+	return db, root
+}
+
+// connectSyncer connects a syncer to a peer serving the given state, returning
+// a function to tear down the connection.
+func connectSyncer(source state.Database, syncer *Syncer, id byte) func() {
+	app, net := p2p.MsgPipe()
+
+	local := newPeer(snap1, p2p.NewPeer(enode.ID{id}, "local", nil), app)
+	remote := newPeer(snap1, p2p.NewPeer(enode.ID{id}, "remote", nil), net)
+
+	go handle(source, NewSyncer(ethdb.NewMemDatabase()), remote)
+	go handle(state.NewDatabase(ethdb.NewMemDatabase()), syncer, local)
+
+	syncer.Register(local)
+	return func() {
+		syncer.Unregister(local.id)
+		app.Close()
+	}
+}
+
+// checkState verifies that the entire state of the given root is present in
+// the database and matches the source.
+func checkState(t *testing.T, source state.Database, db ethdb.Database, root common.Hash) {
+	t.Helper()
+
+	synced, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(synced)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state incomplete: %v", it.Error)
+	}
+	original, _ := state.New(root, source)
+	for _, addr := range []common.Address{common.BigToAddress(big.NewInt(1)), common.BigToAddress(big.NewInt(42)), common.HexToAddress("0xdeadbeef")} {
+		if have, want := synced.GetBalance(addr), original.GetBalance(addr); have.Cmp(want) != 0 {
+			t.Errorf("account %x: balance mismatch: have %v, want %v", addr, have, want)
+		}
+		if have, want := synced.GetCode(addr), original.GetCode(addr); !bytes.Equal(have, want) {
+			t.Errorf("account %x: code mismatch: have %x, want %x", addr, have, want)
+		}
+		if !original.Exist(addr) {
+			continue
+		}
+		if have, want := synced.StorageTrie(addr).Hash(), original.StorageTrie(addr).Hash(); have != want {
+			t.Errorf("account %x: storage root mismatch: have %x, want %x", addr, have, want)
+		}
+	}
+}
+
+// Tests that a state can be fully retrieved via account and storage ranges from
+// a single peer, including storage tries that span multiple responses.
+func TestSync(t *testing.T) {
+	source, root := makeTestState(t, 1000, 20000)
+
+	db := ethdb.NewMemDatabase()
+	syncer := NewSyncer(db)
+	defer connectSyncer(source, syncer, 1)()
+
+	cancel := make(chan struct{})
+	timer := time.AfterFunc(30*time.Second, func() { close(cancel) })
+	defer timer.Stop()
+
+	if err := syncer.Sync(root, cancel); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	checkState(t, source, db, root)
+}
+
+// Tests that peers not having the requested state are skipped, and the sync
+// continues as soon as a peer with the state becomes available.
+func TestSyncStatelessPeer(t *testing.T) {
+	source, root := makeTestState(t, 100, 0)
+	empty := state.NewDatabase(ethdb.NewMemDatabase())
+
+	db := ethdb.NewMemDatabase()
+	syncer := NewSyncer(db)
+	defer connectSyncer(empty, syncer, 1)()
+
+	cancel := make(chan struct{})
+	timer := time.AfterFunc(30*time.Second, func() { close(cancel) })
+	defer timer.Stop()
+
+	done := make(chan error)
+	go func() { done <- syncer.Sync(root, cancel) }()
+
+	// Wait until the stateless peer is detected, then connect a useful one
+	for {
+		syncer.lock.RLock()
+		stateless := len(syncer.stateless)
+		syncer.lock.RUnlock()
+
+		if stateless > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer connectSyncer(source, syncer, 2)()
+
+	if err := <-done; err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	checkState(t, source, db, root)
+}
+
+// Tests that the served account ranges are rejected if tampered with.
+func TestVerifyAccountRange(t *testing.T) {
+	source, root := makeTestState(t, 100, 0)
+
+	res := serveAccountRange(source, &getAccountRangeData{Root: root, Limit: maxHash, Bytes: 1000})
+	if len(res.Accounts) == 0 || len(res.Proof) == 0 {
+		t.Fatalf("no proven accounts served: %d accounts, %d proof nodes", len(res.Accounts), len(res.Proof))
+	}
+	hashes, accounts := res.unpack()
+	more, err := verifyRange(root, common.Hash{}, maxHash, hashes, accounts, res.Proof)
+	if err != nil {
+		t.Fatalf("failed to verify served range: %v", err)
+	}
+	if !more {
+		t.Errorf("partial range reported as complete")
+	}
+	// Drop the first account, which is proven by the origin proof
+	if _, err := verifyRange(root, common.Hash{}, maxHash, hashes[1:], accounts[1:], res.Proof); err == nil {
+		t.Errorf("range with missing first account accepted")
+	}
+	// Drop an account from the middle of the range
+	gapped, gappedAccounts := append([]common.Hash{}, hashes[:1]...), append([][]byte{}, accounts[:1]...)
+	gapped, gappedAccounts = append(gapped, hashes[2:]...), append(gappedAccounts, accounts[2:]...)
+	if _, err := verifyRange(root, common.Hash{}, maxHash, gapped, gappedAccounts, res.Proof); err == nil {
+		t.Errorf("range with missing middle account accepted")
+	}
+	// Change the last account, which is proven by the edge proof
+	last := len(accounts) - 1
+	tampered := append([][]byte{}, accounts...)
+	tampered[last] = append(common.CopyBytes(tampered[last][:len(tampered[last])-1]), 0x00)
+	if _, err := verifyRange(root, common.Hash{}, maxHash, hashes, tampered, res.Proof); err == nil {
+		t.Errorf("range with modified last account accepted")
+	}
+	// Reorder the accounts
+	hashes[0], hashes[1] = hashes[1], hashes[0]
+	if _, err := verifyRange(root, common.Hash{}, maxHash, hashes, accounts, res.Proof); err == nil {
+		t.Errorf("unordered range accepted")
+	}
+	// The tail of the account trie must be reported as complete
+	res = serveAccountRange(source, &getAccountRangeData{Root: root, Limit: maxHash, Bytes: 1 << 20})
+	hashes, accounts = res.unpack()
+	if len(hashes) != 100 {
+		t.Fatalf("served account count mismatch: have %d, want %d", len(hashes), 100)
+	}
+	if more, err := verifyRange(root, common.Hash{}, maxHash, hashes, accounts, res.Proof); err != nil || more {
+		t.Errorf("failed to verify complete range: more %v, err %v", more, err)
+	}
+	// Unknown roots must be rejected without proofs
+	res = serveAccountRange(source, &getAccountRangeData{Root: common.Hash{0x01}, Limit: maxHash, Bytes: 1000})
+	if len(res.Accounts) != 0 || len(res.Proof) != 0 {
+		t.Errorf("unknown root served: %d accounts, %d proof nodes", len(res.Accounts), len(res.Proof))
+	}
+}
@@ -189,7 +189,15 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		mode = downloader.FastSync
 	}
 
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync && atomic.LoadUint32(&pm.snapSync) == 1 {
+		// Snap sync requested, retrieve the fast sync state via the snap protocol
+		mode = downloader.SnapSync
+	}
+	if mode == downloader.FastSync && atomic.LoadUint32(&pm.beamSync) == 1 {
+		// Beam sync requested, execute blocks after the pivot with on-demand state
+		mode = downloader.BeamSync
+	}
+	if mode != downloader.FullSync {
 		// Make sure the peer's total difficulty we are synchronizing is higher.
 		if pm.blockchain.GetTdByHash(pm.blockchain.CurrentFastBlock().Hash()).Cmp(pTd) >= 0 {
 			return
@@ -203,6 +211,8 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		log.Info("Fast sync complete, auto disabling")
 		atomic.StoreUint32(&pm.fastSync, 0)
+		atomic.StoreUint32(&pm.snapSync, 0)
+		atomic.StoreUint32(&pm.beamSync, 0)
 	}
 	atomic.StoreUint32(&pm.acceptTxs, 1) // Mark initial sync done
 	if head := pm.blockchain.CurrentBlock(); head.NumberU64() > 0 {
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -443,6 +444,81 @@ func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 
 // TODO: SubscribePendingTransactions (needs server side)
 
+// Transaction Pool
+
+// TxPoolStatus is the number of transactions in the pool of the remote node,
+// broken down into local and remote ones.
+type TxPoolStatus struct {
+	Pending       uint
+	Queued        uint
+	LocalPending  uint
+	LocalQueued   uint
+	RemotePending uint
+	RemoteQueued  uint
+}
+
+// TxPoolStatus returns the number of pending and queued transactions in the pool.
+func (ec *Client) TxPoolStatus(ctx context.Context) (*TxPoolStatus, error) {
+	var status struct {
+		Pending       hexutil.Uint `json:"pending"`
+		Queued        hexutil.Uint `json:"queued"`
+		LocalPending  hexutil.Uint `json:"localPending"`
+		LocalQueued   hexutil.Uint `json:"localQueued"`
+		RemotePending hexutil.Uint `json:"remotePending"`
+		RemoteQueued  hexutil.Uint `json:"remoteQueued"`
+	}
+	if err := ec.c.CallContext(ctx, &status, "txpool_status"); err != nil {
+		return nil, err
+	}
+	return &TxPoolStatus{
+		Pending:       uint(status.Pending),
+		Queued:        uint(status.Queued),
+		LocalPending:  uint(status.LocalPending),
+		LocalQueued:   uint(status.LocalQueued),
+		RemotePending: uint(status.RemotePending),
+		RemoteQueued:  uint(status.RemoteQueued),
+	}, nil
+}
+
+// TxPoolContentFrom returns the pending and queued transactions of the given
+// account in the pool, sorted by nonce.
+func (ec *Client) TxPoolContentFrom(ctx context.Context, account common.Address) (pending []*types.Transaction, queued []*types.Transaction, err error) {
+	var content map[string]map[string]*rpcTransaction
+	if err := ec.c.CallContext(ctx, &content, "txpool_contentFrom", account); err != nil {
+		return nil, nil, err
+	}
+	flatten := func(txs map[string]*rpcTransaction) []*types.Transaction {
+		flat := make([]*types.Transaction, 0, len(txs))
+		for _, tx := range txs {
+			flat = append(flat, tx.tx)
+		}
+		sort.Sort(types.TxByNonce(flat))
+		return flat
+	}
+	return flatten(content["pending"]), flatten(content["queued"]), nil
+}
+
+// TxPoolContentPage returns a page of the pending (or queued) transactions in
+// the pool sorted by decreasing gas price, along with the total number of them.
+func (ec *Client) TxPoolContentPage(ctx context.Context, pending bool, offset, limit uint) ([]*types.Transaction, uint, error) {
+	kind := "queued"
+	if pending {
+		kind = "pending"
+	}
+	var page struct {
+		Total        hexutil.Uint      `json:"total"`
+		Transactions []*rpcTransaction `json:"transactions"`
+	}
+	if err := ec.c.CallContext(ctx, &page, "txpool_contentPage", kind, hexutil.Uint(offset), hexutil.Uint(limit)); err != nil {
+		return nil, 0, err
+	}
+	txs := make([]*types.Transaction, len(page.Transactions))
+	for i, tx := range page.Transactions {
+		txs[i] = tx.tx
+	}
+	return txs, uint(page.Total), nil
+}
+
 // Contract Calling
 
 // CallContract executes a message call transaction, which is directly executed in the VM
@@ -460,6 +536,39 @@ func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockN
 	return hex, nil
 }
 
+// BatchCallContract executes multiple message calls in a single batch request,
+// all of them against the state of the same block. The outputs and errors of
+// the individual calls are returned in the order of the messages, whereas the
+// error return is only set if the batch as a whole failed.
+//
+// blockNumber selects the block height at which the calls run. It can be nil, in
+// which case the code is taken from the latest known block. Note that state from
+// very old blocks might not be available.
+func (ec *Client) BatchCallContract(ctx context.Context, msgs []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	var (
+		reqs    = make([]rpc.BatchElem, len(msgs))
+		results = make([]hexutil.Bytes, len(msgs))
+	)
+	for i, msg := range msgs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{toCallArg(msg), toBlockNumArg(blockNumber)},
+			Result: &results[i],
+		}
+	}
+	if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, nil, err
+	}
+	var (
+		outputs = make([][]byte, len(msgs))
+		errs    = make([]error, len(msgs))
+	)
+	for i := range reqs {
+		outputs[i], errs[i] = results[i], reqs[i].Error
+	}
+	return outputs, errs, nil
+}
+
 // PendingCallContract executes a message call transaction using the EVM.
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
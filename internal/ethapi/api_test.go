@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// testBackend is an API backend providing only the account manager and the chain
+// configuration, which is enough for the account APIs.
+type testBackend struct {
+	Backend
+	am *accounts.Manager
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *testBackend) ChainConfig() *params.ChainConfig  { return params.AllEthashProtocolChanges }
+func (b *testBackend) CurrentBlock() *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+}
+
+// MockTxArgs are the transaction fields received by the mock signer.
+type MockTxArgs struct {
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice hexutil.Big              `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     *hexutil.Bytes           `json:"data"`
+}
+
+// MockTxResult is the signed transaction returned by the mock signer.
+type MockTxResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// MockSigner is a minimal external signer holding a single account, approving
+// all text and transaction signing requests.
+type MockSigner struct {
+	requests []string
+}
+
+func (s *MockSigner) List() []common.Address {
+	return []common.Address{testAddr}
+}
+
+func (s *MockSigner) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	s.requests = append(s.requests, "account_signData")
+
+	sig, err := crypto.Sign(accounts.TextHash(data), testKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+func (s *MockSigner) SignTransaction(args MockTxArgs, methodSelector *string) (*MockTxResult, error) {
+	s.requests = append(s.requests, "account_signTransaction")
+
+	tx := types.NewTransaction(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), *args.Data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID), testKey)
+	if err != nil {
+		return nil, err
+	}
+	return &MockTxResult{Tx: signed}, nil
+}
+
+// Tests that the personal APIs delegate signing to an external signer, and that
+// the operations requiring a local keystore are rejected instead of crashing.
+func TestPersonalExternalSigner(t *testing.T) {
+	service := new(MockSigner)
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", service); err != nil {
+		t.Fatalf("failed to register signer service: %v", err)
+	}
+	endpoint := httptest.NewServer(server)
+	defer endpoint.Close()
+	defer server.Stop()
+
+	backend, err := external.NewExternalBackend(endpoint.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	am := accounts.NewManager(backend)
+	defer am.Close()
+
+	api := NewPrivateAccountAPI(&testBackend{am: am}, new(AddrLocker))
+	ctx := context.Background()
+
+	// Signing requests should be approved by the external signer
+	if accs := api.ListAccounts(); len(accs) != 1 || accs[0] != testAddr {
+		t.Fatalf("account list mismatch: have %v, want [%x]", accs, testAddr)
+	}
+	data := hexutil.Bytes("hello clef")
+	sig, err := api.Sign(ctx, data, testAddr, "")
+	if err != nil {
+		t.Fatalf("failed to sign data: %v", err)
+	}
+	if signer, err := api.EcRecover(ctx, data, sig); err != nil || signer != testAddr {
+		t.Errorf("recovered signer mismatch: have %x/%v, want %x", signer, err, testAddr)
+	}
+	var (
+		to       = common.Address{0xbb}
+		gas      = hexutil.Uint64(21000)
+		gasPrice = (*hexutil.Big)(big.NewInt(1))
+		nonce    = hexutil.Uint64(3)
+	)
+	res, err := api.SignTransaction(ctx, SendTxArgs{From: testAddr, To: &to, Gas: &gas, GasPrice: gasPrice, Nonce: &nonce}, "")
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if res.Tx.Nonce() != 3 || *res.Tx.To() != to {
+		t.Errorf("signed transaction mismatch: have nonce %d to %x, want nonce 3 to %x", res.Tx.Nonce(), res.Tx.To(), to)
+	}
+	if len(service.requests) != 2 || service.requests[0] != "account_signData" || service.requests[1] != "account_signTransaction" {
+		t.Errorf("signer requests mismatch: have %v", service.requests)
+	}
+	// Keystore operations are unavailable, the keys are held by the signer
+	if _, err := api.NewAccount("pass"); err != accounts.ErrNotSupported {
+		t.Errorf("account creation error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	if _, err := api.ImportRawKey("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291", "pass"); err != accounts.ErrNotSupported {
+		t.Errorf("key import error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	if _, err := api.UnlockAccount(testAddr, "pass", nil); err != accounts.ErrNotSupported {
+		t.Errorf("unlock error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+	if _, err := api.LockAccount(testAddr); err != accounts.ErrNotSupported {
+		t.Errorf("lock error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
@@ -24,7 +24,9 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"ethash":     Ethash_JS,
 	"debug":      Debug_JS,
+	"dev":        Dev_JS,
 	"eth":        Eth_JS,
+	"les":        LES_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
@@ -101,6 +103,12 @@ web3._extend({
 			call: 'clique_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'status',
+			call: 'clique_status',
+			params: 1,
+			inputFormatter: [null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -415,6 +423,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'simulateCall',
+			call: 'debug_simulateCall',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -448,6 +462,36 @@ web3._extend({
 });
 `
 
+const Dev_JS = `
+web3._extend({
+	property: 'dev',
+	methods: [
+		new web3._extend.Method({
+			name: 'mine',
+			call: 'dev_mine',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'setNextBlockTimestamp',
+			call: 'dev_setNextBlockTimestamp',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'snapshot',
+			call: 'dev_snapshot',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'revert',
+			call: 'dev_revert',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	]
+});
+`
+
 const Eth_JS = `
 web3._extend({
 	property: 'eth',
@@ -558,6 +602,12 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'miner_sendBundle',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.fromDecimal]
+		}),
 	],
 	properties: []
 });
@@ -679,10 +729,56 @@ web3._extend({
 });
 `
 
+const LES_JS = `
+web3._extend({
+	property: 'les',
+	methods: [
+		new web3._extend.Method({
+			name: 'getCheckpoint',
+			call: 'les_getCheckpoint',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addBalance',
+			call: 'les_addBalance',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'setClientParams',
+			call: 'les_setClientParams',
+			params: 2
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'latestCheckpoint',
+			getter: 'les_latestCheckpoint'
+		}),
+		new web3._extend.Property({
+			name: 'checkpointContractAddress',
+			getter: 'les_getCheckpointContractAddress'
+		}),
+	]
+});
+`
+
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'contentPage',
+			call: 'txpool_contentPage',
+			params: 3,
+			inputFormatter: [null, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -699,6 +795,10 @@ web3._extend({
 			outputFormatter: function(status) {
 				status.pending = web3._extend.utils.toDecimal(status.pending);
 				status.queued = web3._extend.utils.toDecimal(status.queued);
+				status.localPending = web3._extend.utils.toDecimal(status.localPending);
+				status.localQueued = web3._extend.utils.toDecimal(status.localQueued);
+				status.remotePending = web3._extend.utils.toDecimal(status.remotePending);
+				status.remoteQueued = web3._extend.utils.toDecimal(status.remoteQueued);
 				return status;
 			}
 		}),
@@ -0,0 +1,129 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errNoCheckpoint     = errors.New("no local checkpoint provided")
+	errNotActivated     = errors.New("checkpoint registrar is not activated")
+	errInvalidCapacity  = errors.New("invalid capacity")
+	errClientPoolClosed = errors.New("client pool is not running")
+)
+
+// PrivateLightAPI provides an API to access the LES light server or light client.
+type PrivateLightAPI struct {
+	backend *lesCommons
+}
+
+// NewPrivateLightAPI creates a new LES service API.
+func NewPrivateLightAPI(backend *lesCommons) *PrivateLightAPI {
+	return &PrivateLightAPI{backend: backend}
+}
+
+// LatestCheckpoint returns the latest local checkpoint package, consisting of
+// the section index, section head hash, canonical hash trie root and bloom
+// trie root.
+func (api *PrivateLightAPI) LatestCheckpoint() (*params.TrustedCheckpoint, error) {
+	cp := api.backend.latestLocalCheckpoint()
+	if cp.Empty() {
+		return nil, errNoCheckpoint
+	}
+	return &cp, nil
+}
+
+// GetCheckpoint returns the specific local checkpoint package.
+func (api *PrivateLightAPI) GetCheckpoint(index uint64) (*params.TrustedCheckpoint, error) {
+	cp := api.backend.getLocalCheckpoint(index)
+	if cp.Empty() {
+		return nil, errNoCheckpoint
+	}
+	return &cp, nil
+}
+
+// GetCheckpointContractAddress returns the contract address of the checkpoint
+// registrar.
+func (api *PrivateLightAPI) GetCheckpointContractAddress() (common.Address, error) {
+	if api.backend.oracle == nil {
+		return common.Address{}, errNotActivated
+	}
+	return api.backend.oracle.config.Address, nil
+}
+
+// PrivateLightServerAPI provides an API to manage the clients of the LES light
+// server.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new LES light server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// clientPool returns the client pool of the server, or nil if it is not running.
+func (api *PrivateLightServerAPI) clientPool() *clientPool {
+	return api.server.protocolManager.clientPool
+}
+
+// AddBalance adds the given amount to the balance of a client, putting it on the
+// priority list while the balance is positive. A negative amount reduces the
+// balance. The balances before and after the change are returned.
+func (api *PrivateLightServerAPI) AddBalance(id enode.ID, value int64) ([2]uint64, error) {
+	pool := api.clientPool()
+	if pool == nil {
+		return [2]uint64{}, errClientPoolClosed
+	}
+	old, balance, err := pool.addBalance(id, value)
+	return [2]uint64{old, balance}, err
+}
+
+// SetClientParams sets the parameters of the given clients on the priority
+// list. Currently the only supported parameter is "capacity", a value of zero
+// meaning the free client capacity. The changes take effect on the next
+// connection of the clients.
+func (api *PrivateLightServerAPI) SetClientParams(ids []enode.ID, params map[string]interface{}) error {
+	pool := api.clientPool()
+	if pool == nil {
+		return errClientPoolClosed
+	}
+	for name, value := range params {
+		switch name {
+		case "capacity":
+			capacity, ok := value.(float64)
+			if !ok || capacity < 0 || capacity > math.MaxUint64 || capacity != math.Trunc(capacity) {
+				return errInvalidCapacity
+			}
+			for _, id := range ids {
+				if err := pool.setCapacity(id, uint64(capacity)); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown client parameter %q", name)
+		}
+	}
+	return nil
+}
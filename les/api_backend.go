@@ -19,6 +19,7 @@ package les
 import (
 	"context"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
@@ -127,6 +128,10 @@ func (b *LesApiBackend) GetPoolTransaction(txHash common.Hash) *types.Transactio
 	return b.eth.txPool.GetTransaction(txHash)
 }
 
+func (b *LesApiBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return light.GetTransaction(ctx, b.eth.odr, txHash)
+}
+
 func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.eth.txPool.GetNonce(ctx, addr)
 }
@@ -139,6 +144,33 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return b.eth.txPool.ContentFrom(addr)
+}
+
+func (b *LesApiBackend) TxPoolContentByPrice(pending bool, offset, limit int) (types.Transactions, int) {
+	// The light pool only holds the few pending local transactions, sort them all
+	if !pending {
+		return nil, 0
+	}
+	txs, _ := b.eth.txPool.GetTransactions()
+	sort.Sort(types.TxByPrice(txs))
+
+	total := len(txs)
+	if offset < 0 || limit <= 0 || offset >= total {
+		return nil, total
+	}
+	if limit < total-offset {
+		txs = txs[:offset+limit]
+	}
+	return txs[offset:], total
+}
+
+func (b *LesApiBackend) TxPoolLocalStats() (pending, queued, localPending, localQueued int) {
+	pending = b.eth.txPool.Stats()
+	return pending, 0, pending, 0
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -139,6 +139,10 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	if leth.protocolManager, err = NewProtocolManager(leth.chainConfig, light.DefaultClientIndexerConfig, true, config.NetworkId, leth.eventMux, leth.engine, leth.peers, leth.blockchain, nil, chainDb, leth.odr, leth.relay, leth.serverPool, quitSync, &leth.wg); err != nil {
 		return nil, err
 	}
+	leth.oracle = newCheckpointOracle(config.CheckpointOracle, leth.getLocalCheckpoint)
+	leth.protocolManager.reg = leth.oracle
+	leth.protocolManager.ulc = newULC(config.ULC)
+
 	leth.ApiBackend = &LesApiBackend{leth, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -207,6 +211,11 @@ func (s *LightEthereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightAPI(&s.lesCommons),
+			Public:    false,
 		},
 	}...)
 }
@@ -237,6 +246,13 @@ func (s *LightEthereum) Start(srvr *p2p.Server) error {
 	// clients are searching for the first advertised protocol in the list
 	protocolVersion := AdvertiseProtocolVersions[0]
 	s.serverPool.start(srvr, lesTopic(s.blockchain.Genesis().Hash(), protocolVersion))
+	if ulc := s.protocolManager.ulc; ulc != nil {
+		// Keep the connections to the trusted servers of the ultra light client
+		for _, node := range ulc.trustedNodes {
+			srvr.AddTrustedPeer(node)
+			srvr.AddPeer(node)
+		}
+	}
 	s.protocolManager.Start(s.config.LightPeers)
 	return nil
 }
@@ -0,0 +1,128 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// signCheckpoint signs the checkpoint hash in the format published by the
+// checkpoint oracle contract.
+func signCheckpoint(oracle common.Address, key *ecdsa.PrivateKey, index uint64, hash common.Hash) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index)
+	data := append([]byte{0x19, 0x00}, append(oracle.Bytes(), append(buf, hash.Bytes()...)...)...)
+
+	sig, _ := crypto.Sign(crypto.Keccak256(data), key)
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return sig
+}
+
+// Tests that checkpoint signatures are only accepted if enough distinct trusted
+// signers approved the checkpoint.
+func TestCheckpointSignerVerification(t *testing.T) {
+	var (
+		keys    []*ecdsa.PrivateKey
+		signers []common.Address
+	)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		signers = append(signers, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i][:], signers[j][:]) < 0 })
+	outsider, _ := crypto.GenerateKey()
+
+	config := &params.CheckpointOracleConfig{
+		Address:   common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314"),
+		Signers:   signers,
+		Threshold: 2,
+	}
+	oracle := newCheckpointOracle(config, nil)
+
+	checkpoint := &params.TrustedCheckpoint{
+		SectionIndex: 1,
+		SectionHead:  common.HexToHash("0x01"),
+		CHTRoot:      common.HexToHash("0x02"),
+		BloomRoot:    common.HexToHash("0x03"),
+	}
+	hash := checkpoint.Hash()
+
+	tests := []struct {
+		keys  []*ecdsa.PrivateKey
+		index uint64
+		valid bool
+	}{
+		{keys: keys[:1], index: 1, valid: false},                               // Not enough signatures
+		{keys: keys[:2], index: 1, valid: true},                                // Exactly enough signatures
+		{keys: keys, index: 1, valid: true},                                    // All signers approved
+		{keys: []*ecdsa.PrivateKey{keys[0], keys[0]}, index: 1, valid: false},  // Duplicate signatures
+		{keys: []*ecdsa.PrivateKey{keys[0], outsider}, index: 1, valid: false}, // Untrusted signer
+		{keys: keys[:2], index: 2, valid: false},                               // Signatures of a different section
+	}
+	for i, tt := range tests {
+		var sigs [][]byte
+		for _, key := range tt.keys {
+			sigs = append(sigs, signCheckpoint(config.Address, key, tt.index, hash))
+		}
+		valid, approved := oracle.verifySigners(checkpoint.SectionIndex, hash, sigs)
+		if valid != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, valid, tt.valid)
+		}
+		if valid && len(approved) != len(tt.keys) {
+			t.Errorf("test %d: approving signer count mismatch: have %d, want %d", i, len(approved), len(tt.keys))
+		}
+	}
+}
+
+// Tests that checkpoint oracle configs which couldn't protect the light client
+// are rejected.
+func TestCheckpointOracleConfig(t *testing.T) {
+	var (
+		address = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+		signers = []common.Address{{0x01}, {0x02}}
+	)
+	tests := []struct {
+		config *params.CheckpointOracleConfig
+		valid  bool
+	}{
+		{&params.CheckpointOracleConfig{Address: address, Signers: signers, Threshold: 1}, true},
+		{&params.CheckpointOracleConfig{Address: address, Signers: signers, Threshold: 2}, true},
+		{&params.CheckpointOracleConfig{Address: address, Signers: signers, Threshold: 0}, false}, // No signatures needed
+		{&params.CheckpointOracleConfig{Address: address, Signers: signers, Threshold: 3}, false}, // Threshold unreachable
+		{&params.CheckpointOracleConfig{Signers: signers, Threshold: 1}, false},                   // No oracle address
+		{&params.CheckpointOracleConfig{Address: address, Signers: nil, Threshold: 0}, false},     // No signers at all
+	}
+	for i, tt := range tests {
+		if oracle := newCheckpointOracle(tt.config, nil); (oracle != nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, oracle != nil, tt.valid)
+		}
+	}
+	// Even a zero threshold slipping through must never accept unsigned checkpoints
+	oracle := &checkpointOracle{config: &params.CheckpointOracleConfig{Address: address, Signers: signers}}
+	if valid, _ := oracle.verifySigners(0, common.Hash{0x01}, nil); valid {
+		t.Errorf("checkpoint without signatures accepted")
+	}
+}
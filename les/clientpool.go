@@ -0,0 +1,369 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	balanceCheckInterval = time.Minute // interval of charging the connected priority clients
+	maxFreeClientRecords = 10000       // maximum number of clients remembered by the free client pool
+)
+
+var (
+	errNegativeBalance  = errors.New("balance cannot be negative")
+	errBalanceOverflow  = errors.New("balance overflow")
+	errCapacityTooLarge = errors.New("capacity exceeds the total server capacity")
+)
+
+// clientPool assigns a capacity to every connected client and decides which
+// clients are allowed to connect. Clients with a positive balance on the
+// priority list are served with their individually assigned capacity (or the
+// free client capacity if none was assigned), spending their balance while
+// connected at a rate of one unit per capacity unit per second. When the total
+// capacity is exhausted, connecting priority clients kick out free clients in
+// the order determined by the free client pool. All other clients are free
+// clients, handled by the free client pool with a fixed capacity.
+//
+// Note: changes of the priority list take effect for the next connection of
+// the client, except for exhausted balances which disconnect the client.
+type clientPool struct {
+	db     ethdb.Database
+	lock   sync.Mutex
+	clock  mclock.Clock
+	closed bool
+	quit   chan struct{}
+
+	freePool                              *freeClientPool
+	freeClientCap, totalCap, connectedCap uint64
+
+	connected map[enode.ID]*clientInfo
+	priority  map[enode.ID]*priorityClient
+}
+
+// clientInfo represents a connected client.
+type clientInfo struct {
+	id           enode.ID
+	address      string
+	capacity     uint64
+	priority     bool
+	lastCharged  mclock.AbsTime
+	disconnectFn func()
+}
+
+// priorityClient is an entry of the priority list.
+type priorityClient struct {
+	balance  uint64 // remaining balance of the client
+	capacity uint64 // capacity assigned to the client, zero means free client capacity
+}
+
+// newClientPool creates a new client pool able to serve the given number of
+// free clients simultaneously.
+func newClientPool(db ethdb.Database, freeClientCap uint64, freeClients int, clock mclock.Clock) *clientPool {
+	pool := &clientPool{
+		db:            db,
+		clock:         clock,
+		quit:          make(chan struct{}),
+		freePool:      newFreeClientPool(db, freeClients, maxFreeClientRecords, clock),
+		freeClientCap: freeClientCap,
+		totalCap:      freeClientCap * uint64(freeClients),
+		connected:     make(map[enode.ID]*clientInfo),
+		priority:      make(map[enode.ID]*priorityClient),
+	}
+	pool.loadFromDb()
+	go pool.loop()
+	return pool
+}
+
+// stop shuts down the client pool, saving the priority list and the free client
+// usage statistics.
+func (f *clientPool) stop() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+	now := f.clock.Now()
+	for _, c := range f.connected {
+		if c.priority {
+			f.charge(c, now)
+		}
+	}
+	f.saveToDb()
+	f.closed = true
+	close(f.quit)
+	f.freePool.stop()
+}
+
+// loop periodically charges the connected priority clients and disconnects the
+// ones which exhausted their balance.
+func (f *clientPool) loop() {
+	for {
+		select {
+		case <-f.clock.After(balanceCheckInterval):
+			f.lock.Lock()
+			if !f.closed {
+				f.checkBalances()
+			}
+			f.lock.Unlock()
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// checkBalances charges all connected priority clients, disconnecting the ones
+// without remaining balance.
+//
+// Note: this function should be called while clientPool.lock is locked.
+func (f *clientPool) checkBalances() {
+	var (
+		now     = f.clock.Now()
+		changed bool
+	)
+	for _, c := range f.connected {
+		if !c.priority {
+			continue
+		}
+		changed = true
+		if f.charge(c, now) == 0 {
+			log.Debug("Priority client balance exhausted", "id", c.id)
+			f.drop(c)
+			c.disconnectFn()
+		}
+	}
+	if changed {
+		f.saveToDb()
+	}
+}
+
+// connect should be called before the handshake of a new client. It returns the
+// capacity assigned to the client and whether the client was accepted. If the
+// connection was rejected, there is no need to call disconnect.
+//
+// Note: the disconnectFn callback should not block.
+func (f *clientPool) connect(id enode.ID, address string, disconnectFn func()) (uint64, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return 0, false
+	}
+	if _, ok := f.connected[id]; ok {
+		log.Debug("Client already connected", "id", id)
+		return 0, false
+	}
+	c := &clientInfo{id: id, address: address, capacity: f.freeClientCap, disconnectFn: disconnectFn}
+	if pc := f.priority[id]; pc != nil && pc.balance > 0 {
+		if pc.capacity != 0 {
+			c.capacity = pc.capacity
+		}
+		// Make room for the priority client by kicking out free clients
+		for f.connectedCap+c.capacity > f.totalCap {
+			if !f.freePool.kick() {
+				log.Debug("Priority client rejected", "id", id, "capacity", c.capacity)
+				return 0, false
+			}
+		}
+		c.priority = true
+		c.lastCharged = f.clock.Now()
+	} else {
+		if !f.freePool.connect(address, func() { f.drop(c); c.disconnectFn() }) {
+			return 0, false
+		}
+		if f.connectedCap+c.capacity > f.totalCap {
+			f.freePool.disconnect(address)
+			log.Debug("Free client rejected", "id", id, "address", address)
+			return 0, false
+		}
+	}
+	f.connected[id] = c
+	f.connectedCap += c.capacity
+	log.Debug("Client accepted", "id", id, "priority", c.priority, "capacity", c.capacity)
+	return c.capacity, true
+}
+
+// disconnect should be called when a connection is terminated. If the
+// disconnection was initiated by the pool itself using disconnectFn then calling
+// disconnect is not necessary but permitted.
+func (f *clientPool) disconnect(id enode.ID) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+	c := f.connected[id]
+	if c == nil {
+		log.Debug("Client already disconnected", "id", id)
+		return
+	}
+	if c.priority {
+		f.charge(c, f.clock.Now())
+	} else {
+		f.freePool.disconnect(c.address)
+	}
+	f.drop(c)
+	log.Debug("Client disconnected", "id", id)
+}
+
+// drop removes a client from the set of connected clients, releasing its
+// capacity.
+//
+// Note: this function should be called while clientPool.lock is locked.
+func (f *clientPool) drop(c *clientInfo) {
+	if f.connected[c.id] != c {
+		return
+	}
+	delete(f.connected, c.id)
+	f.connectedCap -= c.capacity
+}
+
+// charge deducts the cost of the service provided since the last charge from
+// the balance of a connected priority client, returning the remaining balance.
+//
+// Note: this function should be called while clientPool.lock is locked.
+func (f *clientPool) charge(c *clientInfo, now mclock.AbsTime) uint64 {
+	pc := f.priority[c.id]
+	if pc == nil {
+		return 0
+	}
+	if now > c.lastCharged {
+		cost := c.capacity * uint64(now-c.lastCharged) / uint64(time.Second)
+		if cost > pc.balance {
+			cost = pc.balance
+		}
+		pc.balance -= cost
+	}
+	c.lastCharged = now
+	return pc.balance
+}
+
+// addBalance adds the given amount to the balance of a client (removing it if
+// negative), returning the balances before and after the change. Clients with a
+// positive balance are treated as priority clients.
+func (f *clientPool) addBalance(id enode.ID, amount int64) (uint64, uint64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pc := f.priority[id]
+	if pc == nil {
+		pc = &priorityClient{}
+	}
+	if c := f.connected[id]; c != nil && c.priority {
+		f.charge(c, f.clock.Now())
+	}
+	old := pc.balance
+	switch {
+	case amount < 0 && uint64(-amount) > old:
+		return old, old, errNegativeBalance
+	case amount < 0:
+		pc.balance -= uint64(-amount)
+	case old > math.MaxUint64-uint64(amount):
+		return old, old, errBalanceOverflow
+	default:
+		pc.balance += uint64(amount)
+	}
+	f.update(id, pc)
+	return old, pc.balance, nil
+}
+
+// setCapacity assigns a capacity to a client on the priority list, zero meaning
+// the free client capacity.
+func (f *clientPool) setCapacity(id enode.ID, capacity uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if capacity > f.totalCap {
+		return errCapacityTooLarge
+	}
+	pc := f.priority[id]
+	if pc == nil {
+		pc = &priorityClient{}
+	}
+	pc.capacity = capacity
+	f.update(id, pc)
+	return nil
+}
+
+// update stores a changed entry of the priority list, removing it if it has no
+// information left.
+//
+// Note: this function should be called while clientPool.lock is locked.
+func (f *clientPool) update(id enode.ID, pc *priorityClient) {
+	if pc.balance == 0 && pc.capacity == 0 {
+		delete(f.priority, id)
+	} else {
+		f.priority[id] = pc
+	}
+	f.saveToDb()
+}
+
+// clientPoolStorage is the RLP representation of the pool's database storage
+type clientPoolStorage struct {
+	List []clientPoolStorageEntry
+}
+
+// clientPoolStorageEntry is the RLP representation of a priority list entry
+type clientPoolStorageEntry struct {
+	ID       enode.ID
+	Balance  uint64
+	Capacity uint64
+}
+
+// loadFromDb restores the priority list from the database storage
+// (automatically called at initialization)
+func (f *clientPool) loadFromDb() {
+	enc, err := f.db.Get([]byte("clientPool"))
+	if err != nil {
+		return
+	}
+	var storage clientPoolStorage
+	if err := rlp.DecodeBytes(enc, &storage); err != nil {
+		log.Error("Failed to decode priority client list", "err", err)
+		return
+	}
+	for _, e := range storage.List {
+		log.Debug("Loaded priority client record", "id", e.ID, "balance", e.Balance, "capacity", e.Capacity)
+		f.priority[e.ID] = &priorityClient{balance: e.Balance, capacity: e.Capacity}
+	}
+}
+
+// saveToDb saves the priority list to the database storage
+func (f *clientPool) saveToDb() {
+	storage := clientPoolStorage{List: make([]clientPoolStorageEntry, 0, len(f.priority))}
+	for id, pc := range f.priority {
+		storage.List = append(storage.List, clientPoolStorageEntry{ID: id, Balance: pc.balance, Capacity: pc.capacity})
+	}
+	enc, err := rlp.EncodeToBytes(storage)
+	if err != nil {
+		log.Error("Failed to encode priority client list", "err", err)
+	} else {
+		f.db.Put([]byte("clientPool"), enc)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func testClientID(i int) enode.ID {
+	return enode.ID{byte(i >> 8), byte(i)}
+}
+
+func testClientAddress(i int) string {
+	return fmt.Sprintf("10.0.%d.%d", i>>8, i&0xff)
+}
+
+func TestClientPoolPriority(t *testing.T) {
+	var (
+		clock     mclock.Simulated
+		db        = ethdb.NewMemDatabase()
+		pool      = newClientPool(db, 10, 4, &clock)
+		disconnCh = make(chan int, 10)
+	)
+	defer pool.stop()
+
+	connect := func(i int) (uint64, bool) {
+		return pool.connect(testClientID(i), testClientAddress(i), func() { disconnCh <- i })
+	}
+	// Fill up the pool with free clients
+	for i := 0; i < 4; i++ {
+		if capacity, ok := connect(i); !ok || capacity != 10 {
+			t.Fatalf("free client %d: have (%d, %v), want (10, true)", i, capacity, ok)
+		}
+	}
+	if _, ok := connect(4); ok {
+		t.Fatalf("free client accepted over the limit")
+	}
+	// A priority client with double capacity should kick out two free clients
+	if _, _, err := pool.addBalance(testClientID(5), 1000); err != nil {
+		t.Fatalf("failed to add balance: %v", err)
+	}
+	if err := pool.setCapacity(testClientID(5), 20); err != nil {
+		t.Fatalf("failed to set capacity: %v", err)
+	}
+	if capacity, ok := connect(5); !ok || capacity != 20 {
+		t.Fatalf("priority client: have (%d, %v), want (20, true)", capacity, ok)
+	}
+	if len(disconnCh) != 2 {
+		t.Fatalf("kicked out client count mismatch: have %d, want 2", len(disconnCh))
+	}
+	if pool.connectedCap != 40 {
+		t.Fatalf("connected capacity mismatch: have %d, want 40", pool.connectedCap)
+	}
+	// Capacities exceeding the total should be rejected
+	if err := pool.setCapacity(testClientID(6), 50); err != errCapacityTooLarge {
+		t.Fatalf("capacity error mismatch: have %v, want %v", err, errCapacityTooLarge)
+	}
+	// Disconnecting the priority client should make room for free clients
+	pool.disconnect(testClientID(5))
+	if pool.connectedCap != 20 {
+		t.Fatalf("connected capacity mismatch: have %d, want 20", pool.connectedCap)
+	}
+	if _, ok := connect(4); !ok {
+		t.Fatalf("free client rejected after priority client left")
+	}
+}
+
+func TestClientPoolBalance(t *testing.T) {
+	var (
+		clock     mclock.Simulated
+		db        = ethdb.NewMemDatabase()
+		pool      = newClientPool(db, 10, 4, &clock)
+		id        = testClientID(1)
+		disconnCh = make(chan struct{}, 1)
+	)
+	// Balance changes should be validated
+	if _, _, err := pool.addBalance(id, -1); err != errNegativeBalance {
+		t.Fatalf("balance error mismatch: have %v, want %v", err, errNegativeBalance)
+	}
+	if old, balance, err := pool.addBalance(id, 1500); err != nil || old != 0 || balance != 1500 {
+		t.Fatalf("balance mismatch: have (%d, %d, %v), want (0, 1500, nil)", old, balance, err)
+	}
+	if _, ok := pool.connect(id, testClientAddress(1), func() { disconnCh <- struct{}{} }); !ok {
+		t.Fatalf("priority client rejected")
+	}
+	// The balance should be spent proportionally to the capacity
+	clock.WaitForTimers(1)
+	clock.Run(balanceCheckInterval)
+	clock.WaitForTimers(1)
+	if old, balance, _ := pool.addBalance(id, 0); old != 900 || balance != 900 {
+		t.Fatalf("balance mismatch after a minute: have (%d, %d), want (900, 900)", old, balance)
+	}
+	// Restarting the pool should preserve the balance
+	pool.stop()
+	pool = newClientPool(db, 10, 4, &clock)
+	defer pool.stop()
+
+	if _, balance, _ := pool.addBalance(id, 0); balance != 900 {
+		t.Fatalf("balance mismatch after restart: have %d, want 900", balance)
+	}
+	if _, ok := pool.connect(id, testClientAddress(1), func() { disconnCh <- struct{}{} }); !ok {
+		t.Fatalf("priority client rejected")
+	}
+	// Exhausting the balance should disconnect the client (the stale timer of
+	// the stopped pool is still scheduled)
+	clock.WaitForTimers(2)
+	clock.Run(balanceCheckInterval)
+	clock.WaitForTimers(1)
+	clock.Run(balanceCheckInterval)
+	select {
+	case <-disconnCh:
+	case <-time.After(time.Second):
+		t.Fatalf("client not disconnected after exhausting its balance")
+	}
+	if pool.connectedCap != 0 {
+		t.Fatalf("connected capacity mismatch: have %d, want 0", pool.connectedCap)
+	}
+}
@@ -419,7 +419,7 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64, bool) {
 
 	for p, fp := range f.peers {
 		for hash, n := range fp.nodeByHash {
-			if !f.checkKnownNode(p, n) && !n.requested && (bestTd == nil || n.td.Cmp(bestTd) >= 0) {
+			if !f.checkKnownNode(p, n) && !n.requested && (bestTd == nil || n.td.Cmp(bestTd) >= 0) && f.isTrustedHash(hash) {
 				amount := f.requestAmount(p, n)
 				if bestTd == nil || n.td.Cmp(bestTd) > 0 || amount < bestAmount {
 					bestHash = hash
@@ -505,6 +505,26 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64, bool) {
 	return rq, reqID, bestSyncing
 }
 
+// isTrustedHash checks whether the block with the given hash has been announced
+// by enough trusted servers. It always returns true if the ultra light client
+// mode is disabled. Note: this function should be called while fetcher.lock
+// is locked.
+func (f *lightFetcher) isTrustedHash(hash common.Hash) bool {
+	if !f.pm.isULCEnabled() {
+		return true
+	}
+	var agreed int
+	for p, fp := range f.peers {
+		if !p.isTrusted {
+			continue
+		}
+		if _, ok := fp.nodeByHash[hash]; ok {
+			agreed++
+		}
+	}
+	return f.pm.ulc.trusted(agreed)
+}
+
 // deliverHeaders delivers header download request responses for processing
 func (f *lightFetcher) deliverHeaders(peer *peer, reqID uint64, headers []*types.Header) {
 	f.deliverChn <- fetchResponse{reqID: reqID, headers: headers, peer: peer}
@@ -520,7 +540,13 @@ func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) boo
 	for i, header := range resp.headers {
 		headers[int(req.amount)-1-i] = header
 	}
-	if _, err := f.chain.InsertHeaderChain(headers, 1); err != nil {
+	// Ultra light clients skip the verification of headers vouched for by their
+	// trusted servers
+	checkFreq := 1
+	if f.pm.isULCEnabled() {
+		checkFreq = 0
+	}
+	if _, err := f.chain.InsertHeaderChain(headers, checkFreq); err != nil {
 		if err == consensus.ErrFutureBlock {
 			return true
 		}
@@ -104,7 +104,7 @@ func (self *ClientManager) addNode(cnode *ClientNode) *cmNode {
 		node:           cnode,
 		lastUpdate:     time,
 		finishRecharge: time,
-		rcWeight:       1,
+		rcWeight:       cnode.params.MinRecharge, // share the recharge proportionally to the capacity
 	}
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -163,6 +163,25 @@ func (f *freeClientPool) disconnect(address string) {
 	log.Debug("Client disconnected", "address", address)
 }
 
+// kick disconnects the connected client with the highest recent usage in order
+// to make room for a priority client. It returns false if there was no client
+// to kick out.
+func (f *freeClientPool) kick() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed || f.connPool.Size() == 0 {
+		return false
+	}
+	i := f.connPool.PopItem().(*freeClientPoolEntry)
+	f.calcLogUsage(i, f.clock.Now())
+	i.connected = false
+	f.disconnPool.Push(i, -i.logUsage)
+	log.Debug("Client kicked out for priority client", "address", i.address)
+	i.disconnectFn()
+	return true
+}
+
 // logOffset calculates the time-dependent offset for the logarithmic
 // representation of recent usage
 func (f *freeClientPool) logOffset(now mclock.AbsTime) int64 {
@@ -99,8 +99,10 @@ type ProtocolManager struct {
 	chainDb     ethdb.Database
 	odr         *LesOdr
 	server      *LesServer
+	reg         *checkpointOracle // Checkpoint oracle verifying the advertised checkpoints, nil if disabled
+	ulc         *ulc              // Ultra light client configuration, nil if disabled
 	serverPool  *serverPool
-	clientPool  *freeClientPool
+	clientPool  *clientPool
 	lesTopic    discv5.Topic
 	reqDist     *requestDistributor
 	retriever   *retrieveManager
@@ -174,7 +176,7 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	if pm.lightSync {
 		go pm.syncer()
 	} else {
-		pm.clientPool = newFreeClientPool(pm.chainDb, maxPeers, 10000, mclock.System{})
+		pm.clientPool = newClientPool(pm.chainDb, pm.server.defParams.MinRecharge, maxPeers, mclock.System{})
 		go func() {
 			for range pm.newPeerCh {
 			}
@@ -237,6 +239,11 @@ func (pm *ProtocolManager) newPeer(pv int, nv uint64, p *p2p.Peer, rw p2p.MsgRea
 	return newPeer(pv, nv, p, newMeteredMsgWriter(rw))
 }
 
+// isULCEnabled returns whether the ultra light client mode is enabled.
+func (pm *ProtocolManager) isULCEnabled() bool {
+	return pm.ulc != nil
+}
+
 // handle is the callback invoked to manage the life cycle of a les peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
@@ -248,6 +255,33 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	p.Log().Debug("Light Ethereum peer connected", "name", p.Name())
 
+	// Ultra light clients request signed announcements from their trusted servers
+	p.isTrusted = pm.isULCEnabled() && pm.ulc.isTrusted(p.Peer.ID())
+
+	// Assign a capacity to the client, rejecting it if the server is full
+	if !pm.lightSync {
+		p.fcParams = pm.server.defParams
+		if !p.Peer.Info().Network.Trusted {
+			addr, ok := p.RemoteAddr().(*net.TCPAddr)
+			// test peer address is not a tcp address, don't use client pool if can not typecast
+			if ok {
+				// The pool may kick the client before it's registered, so drop
+				// the connection itself to abort any handshake still pending
+				kick := func() {
+					go func() {
+						p.Peer.Disconnect(p2p.DiscTooManyPeers)
+						pm.removePeer(p.id)
+					}()
+				}
+				capacity, accepted := pm.clientPool.connect(p.Peer.ID(), addr.IP.String(), kick)
+				if !accepted {
+					return p2p.DiscTooManyPeers
+				}
+				defer pm.clientPool.disconnect(p.Peer.ID())
+				p.fcParams = pm.server.clientParams(capacity)
+			}
+		}
+	}
 	// Execute the LES handshake
 	var (
 		genesis = pm.blockchain.Genesis()
@@ -261,18 +295,6 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		return err
 	}
 
-	if !pm.lightSync && !p.Peer.Info().Network.Trusted {
-		addr, ok := p.RemoteAddr().(*net.TCPAddr)
-		// test peer address is not a tcp address, don't use client pool if can not typecast
-		if ok {
-			id := addr.IP.String()
-			if !pm.clientPool.connect(id, func() { go pm.removePeer(p.id) }) {
-				return p2p.DiscTooManyPeers
-			}
-			defer pm.clientPool.disconnect(id)
-		}
-	}
-
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
@@ -343,11 +365,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
@@ -1100,6 +1122,15 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
 
+		// Replies to relayed transactions are not retrieval responses
+		if pm.retriever.requested(resp.ReqID) {
+			deliverMsg = &Msg{
+				MsgType: MsgTxStatus,
+				ReqID:   resp.ReqID,
+				Obj:     resp.Status,
+			}
+		}
+
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -202,3 +202,66 @@ func testOdr(t *testing.T, protocol int, expFail uint64, fn odrTestFn) {
 	time.Sleep(time.Millisecond * 10) // ensure that all peerSetNotify callbacks are executed
 	test(5)
 }
+
+func TestOdrTxStatusLes2(t *testing.T) {
+	// Assemble the test environment with a transaction pool on the server side
+	server, client, tearDown := newClientServerEnv(t, 4, 2, nil, true)
+	defer tearDown()
+	client.pm.synchronise(client.rPeer)
+
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+	txpool := core.NewTxPool(config, params.TestChainConfig, server.pm.blockchain.(*core.BlockChain))
+	defer txpool.Stop()
+	server.pm.txpool = txpool
+
+	client.peers.lock.Lock()
+	client.rPeer.hasBlock = func(common.Hash, uint64, bool) bool { return true }
+	client.peers.lock.Unlock()
+
+	// All canonical transactions should be found along with their position
+	for i := uint64(1); i <= server.pm.blockchain.CurrentHeader().Number.Uint64(); i++ {
+		block := server.pm.blockchain.(*core.BlockChain).GetBlockByNumber(i)
+		for index, tx := range block.Transactions() {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			ltx, hash, number, lindex, err := light.GetTransaction(ctx, client.pm.odr, tx.Hash())
+			cancel()
+			if err != nil {
+				t.Fatalf("block %d, tx %d: failed to retrieve transaction: %v", i, index, err)
+			}
+			if ltx == nil || ltx.Hash() != tx.Hash() {
+				t.Fatalf("block %d, tx %d: transaction mismatch: have %v, want %x", i, index, ltx, tx.Hash())
+			}
+			if hash != block.Hash() || number != i || lindex != uint64(index) {
+				t.Errorf("block %d, tx %d: position mismatch: have (%x, %d, %d), want (%x, %d, %d)", i, index, hash, number, lindex, block.Hash(), i, index)
+			}
+		}
+	}
+	// Unknown transactions should be reported as such without an error
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if tx, _, _, _, err := light.GetTransaction(ctx, client.pm.odr, common.Hash{0x01}); tx != nil || err != nil {
+		t.Errorf("unknown transaction: have (%v, %v), want (nil, nil)", tx, err)
+	}
+}
+
+// Tests that transaction status replies claiming an inclusion without saying
+// where are rejected.
+func TestTxStatusValidate(t *testing.T) {
+	lookup := &rawdb.TxLookupEntry{BlockHash: common.Hash{0x01}, BlockIndex: 1, Index: 0}
+	tests := []struct {
+		status []txStatus
+		err    error
+	}{
+		{[]txStatus{{Status: core.TxStatusIncluded, Lookup: lookup}, {Status: core.TxStatusUnknown}}, nil},
+		{[]txStatus{{Status: core.TxStatusPending}}, nil},
+		{[]txStatus{{Status: core.TxStatusIncluded}}, errTxLookupMissing},
+		{[]txStatus{{Status: core.TxStatusUnknown}, {Status: core.TxStatusIncluded}}, errTxLookupMissing},
+	}
+	for i, tt := range tests {
+		req := &TxStatusRequest{Hashes: make([]common.Hash, len(tt.status))}
+		if err := req.Validate(nil, &Msg{MsgType: MsgTxStatus, Obj: tt.status}); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
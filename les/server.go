@@ -23,6 +23,7 @@ import (
 	"math"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -36,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type LesServer struct {
@@ -74,6 +76,8 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 		lesTopics: lesTopics,
 	}
 
+	srv.oracle = newCheckpointOracle(config.CheckpointOracle, srv.getLocalCheckpoint)
+
 	logger := log.New()
 
 	chtV1SectionCount, _, _ := srv.chtIndexer.Sections() // indexer still uses LES/1 4k section size for backwards server compatibility
@@ -107,6 +111,34 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	return srv, nil
 }
 
+// APIs returns the collection of RPC services the les server offers.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightAPI(&s.lesCommons),
+			Public:    false,
+		},
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// clientParams returns the flow control parameters of a client with the given
+// capacity. The buffer limit is proportional to the capacity, keeping the ratio
+// of the default parameters.
+func (s *LesServer) clientParams(capacity uint64) *flowcontrol.ServerParams {
+	return &flowcontrol.ServerParams{
+		BufLimit:    capacity * (s.defParams.BufLimit / s.defParams.MinRecharge),
+		MinRecharge: capacity,
+	}
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.makeProtocols(ServerProtocolVersions)
 }
@@ -130,6 +162,14 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 	s.protocolManager.blockLoop()
 }
 
+// SetContractBackend sets the backend used by the checkpoint oracle to query
+// the checkpoints registered on-chain.
+func (s *LesServer) SetContractBackend(backend bind.ContractBackend) {
+	if s.oracle != nil {
+		s.oracle.start(backend)
+	}
+}
+
 func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
 	bloomIndexer.AddChildIndexer(s.bloomTrieIndexer)
 }
@@ -18,13 +18,19 @@ package les
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
 )
 
+// errInvalidCheckpoint is returned if the checkpoint advertised by a server
+// isn't approved by enough of the oracle signers.
+var errInvalidCheckpoint = errors.New("invalid advertised checkpoint")
+
 // syncer is responsible for periodically synchronising with the network, both
 // downloading hashes and blocks as well as handling the announcement handler.
 func (pm *ProtocolManager) syncer() {
@@ -72,8 +78,57 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
+	// Inject the checkpoint advertised by the server if it's ahead of the local
+	// CHT and was approved on-chain by enough of the oracle signers
+	if pm.reg != nil && !peer.checkpoint.Empty() {
+		if sections, _, _ := pm.odr.ChtIndexer().Sections(); peer.checkpoint.SectionIndex >= sections {
+			if err := pm.validateCheckpoint(peer); err != nil {
+				log.Debug("Failed to validate checkpoint", "peer", peer.id, "err", err)
+				pm.removePeer(peer.id)
+				return
+			}
+			checkpoint := peer.checkpoint
+			pm.blockchain.(*light.LightChain).AddTrustedCheckpoint(&checkpoint)
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	pm.blockchain.(*light.LightChain).SyncCht(ctx)
 	pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), downloader.LightSync)
 }
+
+// validateCheckpoint verifies that the checkpoint advertised by the peer was
+// registered in the checkpoint oracle with the approval of enough signers. The
+// registration block and its receipts are retrieved from the peer itself: they
+// can't be proven by any trusted CHT, but the signatures authenticate the
+// checkpoint on their own.
+func (pm *ProtocolManager) validateCheckpoint(peer *peer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Fetch the block header corresponding to the checkpoint registration
+	checkpoint := peer.checkpoint
+	header, err := light.GetUntrustedHeaderByNumber(ctx, pm.odr, peer.checkpointNumber, peer.id)
+	if err != nil {
+		return err
+	}
+	// Fetch the block logs associated with the block header
+	logs, err := light.GetUntrustedBlockLogs(ctx, pm.odr, header)
+	if err != nil {
+		return err
+	}
+	events := pm.reg.contract.LookupCheckpointEvents(logs, checkpoint.SectionIndex, checkpoint.Hash())
+	if len(events) == 0 {
+		return errInvalidCheckpoint
+	}
+	var signatures [][]byte
+	for _, event := range events {
+		signatures = append(signatures, append(append(event.R[:], event.S[:]...), event.V))
+	}
+	valid, signers := pm.reg.verifySigners(checkpoint.SectionIndex, checkpoint.Hash(), signatures)
+	if !valid {
+		return errInvalidCheckpoint
+	}
+	log.Info("Verified advertised checkpoint", "peer", peer.id, "section", checkpoint.SectionIndex, "signers", len(signers))
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// ulc is the configuration of the ultra light client mode, in which new heads
+// are accepted without verification once enough trusted servers announced them.
+type ulc struct {
+	trustedNodes       []*enode.Node
+	trustedKeys        map[enode.ID]struct{}
+	minTrustedFraction int
+}
+
+// newULC creates the ultra light client configuration, returning nil if the
+// mode is not enabled.
+func newULC(config *eth.ULCConfig) *ulc {
+	if config == nil {
+		return nil
+	}
+	u := &ulc{
+		trustedKeys:        make(map[enode.ID]struct{}),
+		minTrustedFraction: config.MinTrustedFraction,
+	}
+	for _, url := range config.TrustedServers {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			log.Warn("Failed to parse trusted server", "url", url, "err", err)
+			continue
+		}
+		if _, ok := u.trustedKeys[node.ID()]; !ok {
+			u.trustedNodes = append(u.trustedNodes, node)
+			u.trustedKeys[node.ID()] = struct{}{}
+		}
+	}
+	if len(u.trustedKeys) == 0 {
+		log.Warn("No trusted servers configured, ultra light client disabled")
+		return nil
+	}
+	if u.minTrustedFraction <= 0 || u.minTrustedFraction > 100 {
+		log.Warn("Invalid minimum trusted fraction, using default", "fraction", u.minTrustedFraction, "default", eth.DefaultULCMinTrustedFraction)
+		u.minTrustedFraction = eth.DefaultULCMinTrustedFraction
+	}
+	log.Info("Enabled ultra light client mode", "servers", len(u.trustedKeys), "fraction", u.minTrustedFraction)
+	return u
+}
+
+// isTrusted returns whether the server with the given node ID is trusted.
+func (u *ulc) isTrusted(id enode.ID) bool {
+	_, ok := u.trustedKeys[id]
+	return ok
+}
+
+// trusted returns whether enough trusted servers agreed upon something.
+func (u *ulc) trusted(agreed int) bool {
+	return 100*agreed >= u.minTrustedFraction*len(u.trustedKeys)
+}
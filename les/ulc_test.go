@@ -0,0 +1,143 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// newTestServerURL generates a random server key and returns it along with its
+// enode URL.
+func newTestServerURL(t *testing.T, port int) (*ecdsa.PrivateKey, string) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key, fmt.Sprintf("enode://%x@127.0.0.1:%d", crypto.FromECDSAPub(&key.PublicKey)[1:], port)
+}
+
+func TestULCConfig(t *testing.T) {
+	// Disabled modes should not create an ultra light client
+	if u := newULC(nil); u != nil {
+		t.Errorf("ulc created without config")
+	}
+	if u := newULC(&eth.ULCConfig{TrustedServers: []string{"invalid"}, MinTrustedFraction: 50}); u != nil {
+		t.Errorf("ulc created without valid servers")
+	}
+	// Valid servers should be trusted, duplicates and invalid ones ignored
+	key1, url1 := newTestServerURL(t, 30303)
+	key2, url2 := newTestServerURL(t, 30304)
+	key3, _ := newTestServerURL(t, 30305)
+
+	u := newULC(&eth.ULCConfig{TrustedServers: []string{url1, url2, url1, "invalid"}, MinTrustedFraction: 0})
+	if u == nil {
+		t.Fatalf("ulc not created")
+	}
+	if len(u.trustedNodes) != 2 {
+		t.Fatalf("trusted node count mismatch: have %d, want %d", len(u.trustedNodes), 2)
+	}
+	if u.minTrustedFraction != eth.DefaultULCMinTrustedFraction {
+		t.Errorf("trusted fraction mismatch: have %d, want %d", u.minTrustedFraction, eth.DefaultULCMinTrustedFraction)
+	}
+	for i, key := range []*ecdsa.PrivateKey{key1, key2} {
+		if !u.isTrusted(enode.PubkeyToIDV4(&key.PublicKey)) {
+			t.Errorf("server %d: not trusted", i)
+		}
+	}
+	if u.isTrusted(enode.PubkeyToIDV4(&key3.PublicKey)) {
+		t.Errorf("unknown server trusted")
+	}
+}
+
+func TestULCTrustedFraction(t *testing.T) {
+	var urls []string
+	for i := 0; i < 4; i++ {
+		_, url := newTestServerURL(t, 30303+i)
+		urls = append(urls, url)
+	}
+	tests := []struct {
+		fraction int
+		agreed   int
+		trusted  bool
+	}{
+		{fraction: 75, agreed: 0, trusted: false},
+		{fraction: 75, agreed: 2, trusted: false},
+		{fraction: 75, agreed: 3, trusted: true},
+		{fraction: 75, agreed: 4, trusted: true},
+		{fraction: 50, agreed: 2, trusted: true},
+		{fraction: 100, agreed: 3, trusted: false},
+		{fraction: 100, agreed: 4, trusted: true},
+		{fraction: 1, agreed: 1, trusted: true},
+	}
+	for i, tt := range tests {
+		u := newULC(&eth.ULCConfig{TrustedServers: urls, MinTrustedFraction: tt.fraction})
+		if have := u.trusted(tt.agreed); have != tt.trusted {
+			t.Errorf("test %d: trust mismatch: have %v, want %v", i, have, tt.trusted)
+		}
+	}
+}
+
+func TestULCTrustedHash(t *testing.T) {
+	var (
+		keys []*ecdsa.PrivateKey
+		urls []string
+	)
+	for i := 0; i < 4; i++ {
+		key, url := newTestServerURL(t, 30303+i)
+		keys, urls = append(keys, key), append(urls, url)
+	}
+	pm := &ProtocolManager{ulc: newULC(&eth.ULCConfig{TrustedServers: urls[:3], MinTrustedFraction: 60})}
+	f := &lightFetcher{pm: pm, peers: make(map[*peer]*fetcherPeerInfo)}
+
+	// Register three trusted and one untrusted server
+	var peers []*peer
+	for _, key := range keys {
+		id := enode.PubkeyToIDV4(&key.PublicKey)
+		p := &peer{Peer: p2p.NewPeer(id, "", nil), isTrusted: pm.ulc.isTrusted(id)}
+		f.peers[p] = &fetcherPeerInfo{nodeByHash: make(map[common.Hash]*fetcherTreeNode)}
+		peers = append(peers, p)
+	}
+	hash := common.HexToHash("0xdeadbeef")
+	announce := func(p *peer) {
+		f.peers[p].nodeByHash[hash] = &fetcherTreeNode{hash: hash}
+	}
+	if f.isTrustedHash(hash) {
+		t.Fatalf("unannounced hash trusted")
+	}
+	announce(peers[0])
+	announce(peers[3])
+	if f.isTrustedHash(hash) {
+		t.Fatalf("hash trusted with one trusted announcement")
+	}
+	announce(peers[1])
+	if !f.isTrustedHash(hash) {
+		t.Fatalf("hash not trusted with two trusted announcements")
+	}
+	// Without the ultra light client mode, everything is trusted
+	pm.ulc = nil
+	if !f.isTrustedHash(common.Hash{}) {
+		t.Fatalf("hash not trusted with disabled ulc")
+	}
+}
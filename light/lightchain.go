@@ -101,7 +101,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		return nil, core.ErrNoGenesis
 	}
 	if cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]; ok {
-		bc.addTrustedCheckpoint(cp)
+		bc.AddTrustedCheckpoint(cp)
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -117,8 +117,8 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	return bc, nil
 }
 
-// addTrustedCheckpoint adds a trusted checkpoint to the blockchain
-func (self *LightChain) addTrustedCheckpoint(cp *params.TrustedCheckpoint) {
+// AddTrustedCheckpoint adds a trusted checkpoint to the blockchain
+func (self *LightChain) AddTrustedCheckpoint(cp *params.TrustedCheckpoint) {
 	if self.odr.ChtIndexer() != nil {
 		StoreChtRoot(self.chainDb, cp.SectionIndex, cp.SectionHead, cp.CHTRoot)
 		self.odr.ChtIndexer().AddCheckpoint(cp.SectionIndex, cp.SectionHead)
@@ -350,7 +350,9 @@ func (self *LightChain) postChainEvents(events []interface{}) {
 // The verify parameter can be used to fine tune whether nonce verification
 // should be done or not. The reason behind the optional check is because some
 // of the header retrieval mechanisms already need to verfy nonces, as well as
-// because nonces can be verified sparsely, not needing to check each.
+// because nonces can be verified sparsely, not needing to check each. A zero
+// check frequency skips the header verification altogether, which is used by
+// the ultra light client trusting the head announcements of its servers.
 //
 // In the case of a light chain, InsertHeaderChain also creates and posts light
 // chain events when necessary.
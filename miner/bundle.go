@@ -0,0 +1,240 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// maxBundles is the maximum number of transaction bundles waiting for inclusion.
+	maxBundles = 256
+
+	// maxBundleSize is the maximum number of transactions in a single bundle.
+	maxBundleSize = 64
+)
+
+var (
+	// errEmptyBundle is returned if a bundle without transactions is submitted.
+	errEmptyBundle = errors.New("empty bundle")
+
+	// errBundleExpired is returned if a bundle is submitted for an already mined block.
+	errBundleExpired = errors.New("bundle target block already mined")
+
+	// errTooManyBundles is returned if the bundle pool is full.
+	errTooManyBundles = errors.New("too many pending bundles")
+
+	// errBundleTxFailed is returned if a bundled transaction executed but failed.
+	errBundleTxFailed = errors.New("bundled transaction failed")
+)
+
+// txBundle is a group of transactions that must be included consecutively in a
+// single block, or not at all.
+type txBundle struct {
+	hash   common.Hash        // Identifier of the bundle, the hash of its transaction hashes
+	txs    types.Transactions // Transactions to include, in order
+	target uint64             // Last block number the bundle may be included in
+}
+
+// newTxBundle creates a bundle of transactions to be included at most at the
+// target block.
+func newTxBundle(txs types.Transactions, target uint64) *txBundle {
+	hashes := make([][]byte, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash().Bytes()
+	}
+	return &txBundle{
+		hash:   crypto.Keccak256Hash(hashes...),
+		txs:    txs,
+		target: target,
+	}
+}
+
+// addBundle schedules a bundle of transactions for atomic inclusion in one of
+// the blocks up to and including the target one.
+func (w *worker) addBundle(txs types.Transactions, target uint64) (common.Hash, error) {
+	if len(txs) == 0 {
+		return common.Hash{}, errEmptyBundle
+	}
+	if len(txs) > maxBundleSize {
+		return common.Hash{}, fmt.Errorf("bundle too large: have %d, max %d", len(txs), maxBundleSize)
+	}
+	if target <= w.chain.CurrentBlock().NumberU64() {
+		return common.Hash{}, errBundleExpired
+	}
+	signer := types.MakeSigner(w.config, w.chain.CurrentBlock().Number())
+	for i, tx := range txs {
+		if _, err := types.Sender(signer, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+	}
+	w.bundleMu.Lock()
+	defer w.bundleMu.Unlock()
+
+	if len(w.bundles) >= maxBundles {
+		return common.Hash{}, errTooManyBundles
+	}
+	bundle := newTxBundle(txs, target)
+	w.bundles = append(w.bundles, bundle)
+
+	log.Debug("Scheduled transaction bundle", "hash", bundle.hash, "txs", len(txs), "target", target)
+	return bundle.hash, nil
+}
+
+// pendingBundles drops the bundles expired before the given block number and
+// returns the ones that may still be included in it.
+func (w *worker) pendingBundles(number uint64) []*txBundle {
+	w.bundleMu.Lock()
+	defer w.bundleMu.Unlock()
+
+	bundles := w.bundles[:0]
+	for _, bundle := range w.bundles {
+		if bundle.target >= number {
+			bundles = append(bundles, bundle)
+		} else {
+			log.Debug("Dropping expired transaction bundle", "hash", bundle.hash, "target", bundle.target)
+		}
+	}
+	w.bundles = bundles
+	return append([]*txBundle(nil), bundles...)
+}
+
+// commitBundles simulates the pending bundles on top of the current state,
+// committing each of them only if all of its transactions succeed. Bundles that
+// were already included in the chain are dropped.
+func (w *worker) commitBundles(coinbase common.Address) {
+	if w.current == nil {
+		return
+	}
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	var (
+		included      []*txBundle
+		coalescedLogs []*types.Log
+	)
+	for _, bundle := range w.pendingBundles(w.current.header.Number.Uint64()) {
+		if w.bundleIncluded(bundle) {
+			included = append(included, bundle)
+			continue
+		}
+		if logs, err := w.commitBundle(bundle, coinbase); err != nil {
+			log.Trace("Transaction bundle rejected", "hash", bundle.hash, "err", err)
+		} else {
+			log.Debug("Committed transaction bundle", "hash", bundle.hash, "txs", len(bundle.txs))
+			coalescedLogs = append(coalescedLogs, logs...)
+		}
+	}
+	w.dropBundles(included)
+	w.postPendingLogs(coalescedLogs)
+}
+
+// bundleIncluded reports whether a transaction of the bundle, or another one with
+// the same nonce, is already part of the chain the current block builds upon, so
+// the bundle can't be included anymore.
+func (w *worker) bundleIncluded(bundle *txBundle) bool {
+	for _, tx := range bundle.txs {
+		from, _ := types.Sender(w.current.signer, tx)
+		if w.current.state.GetNonce(from) > tx.Nonce() {
+			return true
+		}
+	}
+	return false
+}
+
+// dropBundles removes the given bundles from the ones waiting for inclusion.
+func (w *worker) dropBundles(drop []*txBundle) {
+	if len(drop) == 0 {
+		return
+	}
+	w.bundleMu.Lock()
+	defer w.bundleMu.Unlock()
+
+	bundles := w.bundles[:0]
+	for _, bundle := range w.bundles {
+		keep := true
+		for _, dropped := range drop {
+			if bundle == dropped {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			bundles = append(bundles, bundle)
+		} else {
+			log.Debug("Dropping included transaction bundle", "hash", bundle.hash)
+		}
+	}
+	w.bundles = bundles
+}
+
+// commitBundle executes all transactions of a bundle, reverting the current
+// environment to its original state if any of them fails. The logs of the bundle
+// are returned if it was committed.
+//
+// Note, state snapshots do not survive transaction finalisation, so a copy of
+// the entire state is held until the bundle completes.
+func (w *worker) commitBundle(bundle *txBundle, coinbase common.Address) ([]*types.Log, error) {
+	var (
+		logs    []*types.Log
+		env     = w.current
+		snap    = env.state.Copy()
+		gas     = env.gasPool.Gas()
+		gasUsed = env.header.GasUsed
+		txs     = len(env.txs)
+		tcount  = env.tcount
+	)
+	for _, tx := range bundle.txs {
+		if tx.Protected() && !w.config.IsEIP155(env.header.Number) {
+			return nil, w.revertBundle(snap, gas, gasUsed, txs, tcount, errors.New("replay protected transaction before EIP155"))
+		}
+		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
+		txLogs, err := w.commitTransaction(tx, coinbase)
+		if err != nil {
+			return nil, w.revertBundle(snap, gas, gasUsed, txs, tcount, err)
+		}
+		if env.receipts[len(env.receipts)-1].Status == types.ReceiptStatusFailed {
+			return nil, w.revertBundle(snap, gas, gasUsed, txs, tcount, errBundleTxFailed)
+		}
+		logs = append(logs, txLogs...)
+		env.tcount++
+	}
+	return logs, nil
+}
+
+// revertBundle restores the current environment to the state before a bundle
+// was started and returns the error that caused it.
+func (w *worker) revertBundle(snap *state.StateDB, gas, gasUsed uint64, txs, tcount int, err error) error {
+	env := w.current
+
+	env.state = snap
+	*env.gasPool = core.GasPool(gas)
+	env.header.GasUsed = gasUsed
+	env.txs = env.txs[:txs]
+	env.receipts = env.receipts[:txs]
+	env.tcount = tcount
+
+	return err
+}
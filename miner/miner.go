@@ -39,6 +39,14 @@ type Backend interface {
 	TxPool() *core.TxPool
 }
 
+// Config is the configuration parameters of mining.
+type Config struct {
+	Recommit  time.Duration // The time interval for miner to re-create mining work.
+	GasFloor  uint64        // Target gas floor for mined blocks.
+	GasCeil   uint64        // Target gas ceiling for mined blocks.
+	TxOrderer TxOrderer     // Policy ordering the transactions in mined blocks (price and nonce if nil).
+}
+
 // Miner creates blocks and searches for proof-of-work values.
 type Miner struct {
 	mux      *event.TypeMux
@@ -52,13 +60,13 @@ type Miner struct {
 	shouldStart int32 // should start indicates whether we should start after sync
 }
 
-func New(eth Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, recommit time.Duration, gasFloor, gasCeil uint64, isLocalBlock func(block *types.Block) bool) *Miner {
+func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, isLocalBlock func(block *types.Block) bool) *Miner {
 	miner := &Miner{
 		eth:      eth,
 		mux:      mux,
 		engine:   engine,
 		exitCh:   make(chan struct{}),
-		worker:   newWorker(config, engine, eth, mux, recommit, gasFloor, gasCeil, isLocalBlock),
+		worker:   newWorker(config, chainConfig, engine, eth, mux, isLocalBlock),
 		canStart: 1,
 	}
 	go miner.update()
@@ -164,6 +172,21 @@ func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
 
+// SealBlock assembles a new block out of the pending transactions on top of the
+// current head, seals it and inserts it into the chain, regardless of whether the
+// miner is running. It is meant for consensus engines sealing on demand, as the
+// call blocks until the engine delivers the sealed block.
+func (self *Miner) SealBlock() (*types.Block, error) {
+	return self.worker.sealBlock()
+}
+
+// SendBundle schedules a group of transactions for atomic inclusion in one of
+// the blocks up to and including the target block number. The transactions are
+// either all included consecutively and successfully, or none of them is.
+func (self *Miner) SendBundle(txs types.Transactions, target uint64) (common.Hash, error) {
+	return self.worker.addBundle(txs, target)
+}
+
 func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
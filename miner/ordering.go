@@ -0,0 +1,389 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"container/heap"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fifoArrivalLimit is the number of transaction arrivals tracked by the FIFO
+// orderer. Transactions that arrived earlier are considered the oldest ones.
+const fifoArrivalLimit = 65536
+
+// TxIterator is a nonce-honouring iterator over a set of transactions, as used
+// by the miner to fill a block.
+type TxIterator interface {
+	// Peek returns the next transaction to include, or nil if none is left.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one from the same account.
+	Shift()
+
+	// Pop removes the current transaction, *not* replacing it with the next one
+	// from the same account.
+	Pop()
+}
+
+// TxGasTracker is an optional interface of the iterators created by orderers
+// which need to know the gas actually used by the transactions of the block.
+type TxGasTracker interface {
+	// Included is invoked with every transaction of the block being filled and
+	// the gas it used, including the ones already in the block before iterating.
+	Included(tx *types.Transaction, gasUsed uint64)
+}
+
+// TxOrderer is the policy deciding the order in which the pending transactions
+// of the pool are committed into the blocks created by the miner.
+type TxOrderer interface {
+	// Arrived is invoked with every batch of transactions becoming executable
+	// in the pool, in arrival order.
+	Arrived(txs []*types.Transaction)
+
+	// Order creates an iterator over the given local and remote transactions,
+	// grouped by account and sorted by nonce.
+	//
+	// Note, the input maps are reowned so the caller should not interact any
+	// more with them after providing them to the orderer.
+	Order(signer types.Signer, locals, remotes map[common.Address]types.Transactions) TxIterator
+}
+
+// txIterators chains multiple transaction iterators, draining them in order.
+type txIterators []TxIterator
+
+// Peek returns the next transaction of the first non-exhausted iterator.
+func (its *txIterators) Peek() *types.Transaction {
+	for len(*its) > 0 {
+		if tx := (*its)[0].Peek(); tx != nil {
+			return tx
+		}
+		*its = (*its)[1:]
+	}
+	return nil
+}
+
+// Shift replaces the current transaction with the next one from the same account.
+func (its *txIterators) Shift() {
+	if its.Peek() != nil {
+		(*its)[0].Shift()
+	}
+}
+
+// Pop removes the current transaction from the iterator.
+func (its *txIterators) Pop() {
+	if its.Peek() != nil {
+		(*its)[0].Pop()
+	}
+}
+
+// Included implements TxGasTracker, forwarding the gas used to the iterators not
+// yet drained.
+func (its *txIterators) Included(tx *types.Transaction, gasUsed uint64) {
+	for _, it := range *its {
+		if tracker, ok := it.(TxGasTracker); ok {
+			tracker.Included(tx, gasUsed)
+		}
+	}
+}
+
+// PriceOrderer is the default transaction ordering policy, including all local
+// transactions first, followed by the remote ones, both sorted by gas price.
+type PriceOrderer struct{}
+
+// NewPriceOrderer creates a price and nonce based transaction orderer.
+func NewPriceOrderer() *PriceOrderer {
+	return new(PriceOrderer)
+}
+
+// Arrived implements TxOrderer, ignoring the transaction arrivals.
+func (o *PriceOrderer) Arrived(txs []*types.Transaction) {}
+
+// Order implements TxOrderer, returning the locals then the remotes by price.
+func (o *PriceOrderer) Order(signer types.Signer, locals, remotes map[common.Address]types.Transactions) TxIterator {
+	its := make(txIterators, 0, 2)
+	if len(locals) > 0 {
+		its = append(its, types.NewTransactionsByPriceAndNonce(signer, locals))
+	}
+	if len(remotes) > 0 {
+		its = append(its, types.NewTransactionsByPriceAndNonce(signer, remotes))
+	}
+	return &its
+}
+
+// FIFOOrderer is a transaction ordering policy including transactions in the
+// order they became executable in the pool, irrespective of their price and of
+// their origin. Transactions with unknown arrival are considered the oldest.
+type FIFOOrderer struct {
+	arrivals map[common.Hash]uint64 // Arrival sequence numbers of the tracked transactions
+	seq      uint64                 // Sequence number of the last arrived transaction
+	lock     sync.Mutex
+}
+
+// NewFIFOOrderer creates an arrival time based transaction orderer.
+func NewFIFOOrderer() *FIFOOrderer {
+	return &FIFOOrderer{
+		arrivals: make(map[common.Hash]uint64),
+	}
+}
+
+// Arrived implements TxOrderer, tracking the arrival order of the transactions.
+func (o *FIFOOrderer) Arrived(txs []*types.Transaction) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, tx := range txs {
+		if _, ok := o.arrivals[tx.Hash()]; !ok {
+			o.seq++
+			o.arrivals[tx.Hash()] = o.seq
+		}
+	}
+	// Forget the oldest arrivals if too many accumulated
+	if len(o.arrivals) > 2*fifoArrivalLimit {
+		for hash, seq := range o.arrivals {
+			if seq+fifoArrivalLimit <= o.seq {
+				delete(o.arrivals, hash)
+			}
+		}
+	}
+}
+
+// Order implements TxOrderer, returning all transactions by arrival order, not
+// distinguishing between local and remote ones.
+func (o *FIFOOrderer) Order(signer types.Signer, locals, remotes map[common.Address]types.Transactions) TxIterator {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	it := &txsByArrivalAndNonce{
+		txs:    make(map[common.Address]types.Transactions),
+		heads:  arrivalHeap{arrivals: make(map[common.Hash]uint64)},
+		signer: signer,
+	}
+	for _, set := range []map[common.Address]types.Transactions{locals, remotes} {
+		for _, txs := range set {
+			if len(txs) == 0 {
+				continue
+			}
+			for _, tx := range txs {
+				it.heads.arrivals[tx.Hash()] = o.arrivals[tx.Hash()]
+			}
+			acc, _ := types.Sender(signer, txs[0])
+			it.heads.txs = append(it.heads.txs, txs[0])
+			it.txs[acc] = txs[1:]
+		}
+	}
+	heap.Init(&it.heads)
+	return it
+}
+
+// arrivalHeap is a heap.Interface implementation over transactions, keeping the
+// earliest arrived one on top.
+type arrivalHeap struct {
+	txs      []*types.Transaction
+	arrivals map[common.Hash]uint64
+}
+
+func (h arrivalHeap) Len() int      { return len(h.txs) }
+func (h arrivalHeap) Swap(i, j int) { h.txs[i], h.txs[j] = h.txs[j], h.txs[i] }
+
+func (h arrivalHeap) Less(i, j int) bool {
+	ai, aj := h.arrivals[h.txs[i].Hash()], h.arrivals[h.txs[j].Hash()]
+	if ai != aj {
+		return ai < aj
+	}
+	return bytes.Compare(h.txs[i].Hash().Bytes(), h.txs[j].Hash().Bytes()) < 0
+}
+
+func (h *arrivalHeap) Push(x interface{}) {
+	h.txs = append(h.txs, x.(*types.Transaction))
+}
+
+func (h *arrivalHeap) Pop() interface{} {
+	old := h.txs
+	n := len(old)
+	x := old[n-1]
+	h.txs = old[0 : n-1]
+	return x
+}
+
+// txsByArrivalAndNonce is a TxIterator returning transactions in arrival order
+// in a nonce-honouring way.
+type txsByArrivalAndNonce struct {
+	txs    map[common.Address]types.Transactions // Per account nonce-sorted list of transactions
+	heads  arrivalHeap                           // Next transaction for each unique account
+	signer types.Signer                          // Signer for the set of transactions
+}
+
+// Peek returns the earliest arrived executable transaction.
+func (t *txsByArrivalAndNonce) Peek() *types.Transaction {
+	if len(t.heads.txs) == 0 {
+		return nil
+	}
+	return t.heads.txs[0]
+}
+
+// Shift replaces the current head with the next one from the same account.
+func (t *txsByArrivalAndNonce) Shift() {
+	acc, _ := types.Sender(t.signer, t.heads.txs[0])
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+// Pop removes the current head, *not* replacing it with the next one from the
+// same account.
+func (t *txsByArrivalAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// PriorityOrderer is a transaction ordering policy including the transactions
+// of a set of priority senders before any other, each group ordered by the
+// wrapped policy.
+type PriorityOrderer struct {
+	senders map[common.Address]bool
+	orderer TxOrderer
+}
+
+// NewPriorityOrderer creates a transaction orderer preferring the given senders
+// and falling back to the given orderer within the priority and other groups.
+func NewPriorityOrderer(senders []common.Address, orderer TxOrderer) *PriorityOrderer {
+	o := &PriorityOrderer{
+		senders: make(map[common.Address]bool),
+		orderer: orderer,
+	}
+	for _, sender := range senders {
+		o.senders[sender] = true
+	}
+	return o
+}
+
+// Arrived implements TxOrderer, forwarding the arrivals to the wrapped orderer.
+func (o *PriorityOrderer) Arrived(txs []*types.Transaction) {
+	o.orderer.Arrived(txs)
+}
+
+// Order implements TxOrderer, returning the priority senders' transactions first.
+func (o *PriorityOrderer) Order(signer types.Signer, locals, remotes map[common.Address]types.Transactions) TxIterator {
+	priorityLocals, priorityRemotes := o.split(locals), o.split(remotes)
+
+	its := txIterators{
+		o.orderer.Order(signer, priorityLocals, priorityRemotes),
+		o.orderer.Order(signer, locals, remotes),
+	}
+	return &its
+}
+
+// split moves the transactions of the priority senders out of the given set.
+func (o *PriorityOrderer) split(txs map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	priority := make(map[common.Address]types.Transactions)
+	for addr, accTxs := range txs {
+		if o.senders[addr] {
+			priority[addr] = accTxs
+			delete(txs, addr)
+		}
+	}
+	return priority
+}
+
+// QuotaOrderer is a transaction ordering policy limiting the total gas that the
+// transactions of a single sender may use within a block. The transactions are
+// ordered by the wrapped policy, skipping any that might exceed the quota.
+type QuotaOrderer struct {
+	quota   uint64
+	orderer TxOrderer
+}
+
+// NewQuotaOrderer creates a transaction orderer capping the gas used by the
+// transactions included per sender and block to quota.
+func NewQuotaOrderer(quota uint64, orderer TxOrderer) *QuotaOrderer {
+	return &QuotaOrderer{
+		quota:   quota,
+		orderer: orderer,
+	}
+}
+
+// Arrived implements TxOrderer, forwarding the arrivals to the wrapped orderer.
+func (o *QuotaOrderer) Arrived(txs []*types.Transaction) {
+	o.orderer.Arrived(txs)
+}
+
+// Order implements TxOrderer, enforcing the gas quota on the wrapped ordering.
+func (o *QuotaOrderer) Order(signer types.Signer, locals, remotes map[common.Address]types.Transactions) TxIterator {
+	return &txsWithinQuota{
+		it:     o.orderer.Order(signer, locals, remotes),
+		quota:  o.quota,
+		used:   make(map[common.Address]uint64),
+		signer: signer,
+	}
+}
+
+// txsWithinQuota is a TxIterator dropping the transactions of any sender which
+// might exceed its gas quota.
+//
+// Note, the gas used by a transaction is only known after executing it, so the
+// quota is charged with the gas used reported by the miner, whereas the next
+// transaction is only admitted if its entire gas limit fits in the quota.
+type txsWithinQuota struct {
+	it     TxIterator
+	quota  uint64
+	used   map[common.Address]uint64
+	signer types.Signer
+}
+
+// Peek returns the next transaction fitting in its sender's quota.
+func (t *txsWithinQuota) Peek() *types.Transaction {
+	for {
+		tx := t.it.Peek()
+		if tx == nil {
+			return nil
+		}
+		from, _ := types.Sender(t.signer, tx)
+		if t.used[from]+tx.Gas() <= t.quota {
+			return tx
+		}
+		t.it.Pop()
+	}
+}
+
+// Shift moves to the next transaction from the same account as the peeked one.
+//
+// Note, the current transaction is not checked against the quota again, as it
+// was already charged if it was included.
+func (t *txsWithinQuota) Shift() {
+	t.it.Shift()
+}
+
+// Pop removes the peeked transaction from the iterator.
+func (t *txsWithinQuota) Pop() {
+	t.it.Pop()
+}
+
+// Included implements TxGasTracker, charging the gas used to the sender's quota.
+func (t *txsWithinQuota) Included(tx *types.Transaction, gasUsed uint64) {
+	from, _ := types.Sender(t.signer, tx)
+	t.used[from] += gasUsed
+
+	if tracker, ok := t.it.(TxGasTracker); ok {
+		tracker.Included(tx, gasUsed)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var orderingSigner = types.HomesteadSigner{}
+
+// orderingTx creates a signed transaction for the ordering tests.
+func orderingTx(key *ecdsa.PrivateKey, nonce uint64, gas uint64, price int64) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), gas, big.NewInt(price), nil), orderingSigner, key)
+	return tx
+}
+
+// drain iterates over all the transactions of an iterator, as if all of them
+// were successfully included, using up their entire gas limit.
+func drain(it TxIterator) []*types.Transaction {
+	return drainUsing(it, func(tx *types.Transaction) uint64 { return tx.Gas() })
+}
+
+// drainUsing iterates over all the transactions of an iterator, as if all of them
+// were successfully included, using the given amount of gas.
+func drainUsing(it TxIterator, gasUsed func(tx *types.Transaction) uint64) []*types.Transaction {
+	var txs []*types.Transaction
+	for tx := it.Peek(); tx != nil; tx = it.Peek() {
+		txs = append(txs, tx)
+		if tracker, ok := it.(TxGasTracker); ok {
+			tracker.Included(tx, gasUsed(tx))
+		}
+		it.Shift()
+	}
+	return txs
+}
+
+// checkOrder verifies that the iterated transactions match the expected ones.
+func checkOrder(t *testing.T, have, want []*types.Transaction) {
+	t.Helper()
+
+	if len(have) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range have {
+		if have[i].Hash() != want[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, have[i].Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that the default orderer includes local transactions before remote ones,
+// each group sorted by price.
+func TestPriceOrderer(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	key3, _ := crypto.GenerateKey()
+
+	local := orderingTx(key1, 0, params.TxGas, 1)
+	remote1 := orderingTx(key2, 0, params.TxGas, 5)
+	remote2 := orderingTx(key3, 0, params.TxGas, 10)
+
+	it := NewPriceOrderer().Order(orderingSigner,
+		map[common.Address]types.Transactions{crypto.PubkeyToAddress(key1.PublicKey): {local}},
+		map[common.Address]types.Transactions{
+			crypto.PubkeyToAddress(key2.PublicKey): {remote1},
+			crypto.PubkeyToAddress(key3.PublicKey): {remote2},
+		})
+	checkOrder(t, drain(it), []*types.Transaction{local, remote2, remote1})
+}
+
+// Tests that the FIFO orderer includes transactions in arrival order while still
+// honouring the account nonces.
+func TestFIFOOrderer(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+
+	var (
+		a0 = orderingTx(key1, 0, params.TxGas, 1)
+		a1 = orderingTx(key1, 1, params.TxGas, 100)
+		b0 = orderingTx(key2, 0, params.TxGas, 50)
+		b1 = orderingTx(key2, 1, params.TxGas, 1)
+	)
+	orderer := NewFIFOOrderer()
+	orderer.Arrived([]*types.Transaction{b1, a0})
+	orderer.Arrived([]*types.Transaction{b0})
+	orderer.Arrived([]*types.Transaction{a1})
+
+	it := orderer.Order(orderingSigner,
+		map[common.Address]types.Transactions{crypto.PubkeyToAddress(key1.PublicKey): {a0, a1}},
+		map[common.Address]types.Transactions{crypto.PubkeyToAddress(key2.PublicKey): {b0, b1}})
+
+	// b1 arrived first, but needs b0 (arrived third) to be executed first
+	checkOrder(t, drain(it), []*types.Transaction{a0, b0, b1, a1})
+}
+
+// Tests that the priority orderer includes the priority senders first, and that
+// the quota orderer skips transactions exceeding their sender's gas quota.
+func TestPriorityAndQuotaOrderers(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+
+	var (
+		addr1 = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2 = crypto.PubkeyToAddress(key2.PublicKey)
+
+		a0 = orderingTx(key1, 0, params.TxGas, 1)
+		a1 = orderingTx(key1, 1, params.TxGas, 1)
+		a2 = orderingTx(key1, 2, params.TxGas, 1)
+		b0 = orderingTx(key2, 0, params.TxGas, 10)
+	)
+	pending := func() map[common.Address]types.Transactions {
+		return map[common.Address]types.Transactions{addr1: {a0, a1, a2}, addr2: {b0}}
+	}
+	orderer := NewPriorityOrderer([]common.Address{addr1}, NewPriceOrderer())
+	checkOrder(t, drain(orderer.Order(orderingSigner, nil, pending())), []*types.Transaction{a0, a1, a2, b0})
+
+	quota := NewQuotaOrderer(2*params.TxGas, orderer)
+	checkOrder(t, drain(quota.Order(orderingSigner, nil, pending())), []*types.Transaction{a0, a1, b0})
+}
+
+// Tests that the quota orderer charges the gas actually used by the included
+// transactions, not their gas limit, including the ones in the block before.
+func TestQuotaOrdererGasUsed(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+
+	var (
+		addr1 = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2 = crypto.PubkeyToAddress(key2.PublicKey)
+
+		a0 = orderingTx(key1, 0, 2*params.TxGas, 1)
+		a1 = orderingTx(key1, 1, 2*params.TxGas, 1)
+		a2 = orderingTx(key1, 2, 2*params.TxGas, 1)
+		b0 = orderingTx(key2, 0, params.TxGas, 1)
+		b1 = orderingTx(key2, 1, params.TxGas, 1)
+	)
+	used := func(tx *types.Transaction) uint64 { return params.TxGas }
+	orderer := NewQuotaOrderer(3*params.TxGas, NewPriceOrderer())
+
+	// Charging the gas limit would only admit a0, but a1 fits after a0 used less
+	it := orderer.Order(orderingSigner, nil, map[common.Address]types.Transactions{addr1: {a0, a1, a2}})
+	checkOrder(t, drainUsing(it, used), []*types.Transaction{a0, a1})
+
+	// Transactions already in the block count against the quota too
+	prior := orderingTx(key2, 5, params.TxGas, 1)
+	it = orderer.Order(orderingSigner, nil, map[common.Address]types.Transactions{addr2: {b0, b1}})
+	it.(TxGasTracker).Included(prior, 2*params.TxGas)
+	checkOrder(t, drainUsing(it, used), []*types.Transaction{b0})
+}
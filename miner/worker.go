@@ -76,6 +76,9 @@ const (
 	staleThreshold = 7
 )
 
+// errWorkerClosed is returned if sealing is requested from a closed worker.
+var errWorkerClosed = errors.New("worker closed")
+
 // environment is the worker's current environment and holds all of the current state information.
 type environment struct {
 	signer types.Signer
@@ -113,6 +116,14 @@ type newWorkReq struct {
 	timestamp int64
 }
 
+// sealReq represents a request for sealing work assembled on demand, bypassing
+// the mining loop.
+type sealReq struct {
+	task *task
+	err  error
+	done chan struct{}
+}
+
 // intervalAdjust represents a resubmitting interval adjustment.
 type intervalAdjust struct {
 	ratio float64
@@ -129,6 +140,7 @@ type worker struct {
 
 	gasFloor uint64
 	gasCeil  uint64
+	orderer  TxOrderer
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -141,6 +153,7 @@ type worker struct {
 
 	// Channels
 	newWorkCh          chan *newWorkReq
+	sealCh             chan *sealReq
 	taskCh             chan *task
 	resultCh           chan *types.Block
 	startCh            chan struct{}
@@ -160,6 +173,9 @@ type worker struct {
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
 
+	bundleMu sync.Mutex  // The lock used to protect the transaction bundles
+	bundles  []*txBundle // Transaction bundles waiting for atomic inclusion
+
 	snapshotMu    sync.RWMutex // The lock used to protect the block snapshot and state snapshot
 	snapshotBlock *types.Block
 	snapshotState *state.StateDB
@@ -178,15 +194,16 @@ type worker struct {
 	resubmitHook func(time.Duration, time.Duration) // Method to call upon updating resubmitting interval.
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, recommit time.Duration, gasFloor, gasCeil uint64, isLocalBlock func(*types.Block) bool) *worker {
+func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(*types.Block) bool) *worker {
 	worker := &worker{
-		config:             config,
+		config:             chainConfig,
 		engine:             engine,
 		eth:                eth,
 		mux:                mux,
 		chain:              eth.BlockChain(),
-		gasFloor:           gasFloor,
-		gasCeil:            gasCeil,
+		gasFloor:           config.GasFloor,
+		gasCeil:            config.GasCeil,
+		orderer:            config.TxOrderer,
 		isLocalBlock:       isLocalBlock,
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
@@ -196,6 +213,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, eth Backend,
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
 		newWorkCh:          make(chan *newWorkReq),
+		sealCh:             make(chan *sealReq),
 		taskCh:             make(chan *task),
 		resultCh:           make(chan *types.Block, resultQueueSize),
 		exitCh:             make(chan struct{}),
@@ -209,7 +227,11 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, eth Backend,
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
 	worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
 
+	if worker.orderer == nil {
+		worker.orderer = NewPriceOrderer()
+	}
 	// Sanitize recommit interval if the user-specified one is too short.
+	recommit := config.Recommit
 	if recommit < minRecommitInterval {
 		log.Warn("Sanitizing miner recommit interval", "provided", recommit, "updated", minRecommitInterval)
 		recommit = minRecommitInterval
@@ -264,6 +286,35 @@ func (w *worker) pendingBlock() *types.Block {
 	return w.snapshotBlock
 }
 
+// sealBlock assembles a new block on top of the current head out of the pending
+// transactions, seals it and inserts it into the chain, waiting for the consensus
+// engine to deliver the result. It is meant for engines sealing on demand.
+func (w *worker) sealBlock() (*types.Block, error) {
+	req := &sealReq{done: make(chan struct{})}
+	select {
+	case w.sealCh <- req:
+	case <-w.exitCh:
+		return nil, errWorkerClosed
+	}
+	<-req.done
+	if req.err != nil {
+		return nil, req.err
+	}
+	results := make(chan *types.Block, 1)
+	if err := w.engine.Seal(w.chain, req.task.block, results, w.exitCh); err != nil {
+		return nil, err
+	}
+	select {
+	case block := <-results:
+		if err := w.writeBlock(block, req.task); err != nil {
+			return nil, err
+		}
+		return block, nil
+	case <-w.exitCh:
+		return nil, errWorkerClosed
+	}
+}
+
 // start sets the running status as 1 and triggers new work submitting.
 func (w *worker) start() {
 	atomic.StoreInt32(&w.running, 1)
@@ -409,6 +460,10 @@ func (w *worker) mainLoop() {
 		case req := <-w.newWorkCh:
 			w.commitNewWork(req.interrupt, req.noempty, req.timestamp)
 
+		case req := <-w.sealCh:
+			req.task, req.err = w.sealNewWork()
+			close(req.done)
+
 		case ev := <-w.chainSideCh:
 			// Short circuit for duplicate side blocks
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
@@ -449,6 +504,8 @@ func (w *worker) mainLoop() {
 			}
 
 		case ev := <-w.txsCh:
+			w.orderer.Arrived(ev.Txs)
+
 			// Apply transactions to the pending state if we're not mining.
 			//
 			// Note all transactions received may not be continuous with transactions
@@ -464,8 +521,7 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs)
-				w.commitTransactions(txset, coinbase, nil)
+				w.commitTransactions(w.orderTransactions(txs), coinbase, nil)
 				w.updateSnapshot()
 			} else {
 				// If we're mining, but nothing is being processed, wake on new transactions
@@ -560,45 +616,9 @@ func (w *worker) resultLoop() {
 				log.Error("Block found but no relative pending task", "number", block.Number(), "sealhash", sealhash, "hash", hash)
 				continue
 			}
-			// Different block could share same sealhash, deep copy here to prevent write-write conflict.
-			var (
-				receipts = make([]*types.Receipt, len(task.receipts))
-				logs     []*types.Log
-			)
-			for i, receipt := range task.receipts {
-				receipts[i] = new(types.Receipt)
-				*receipts[i] = *receipt
-				// Update the block hash in all logs since it is now available and not when the
-				// receipt/log of individual transactions were created.
-				for _, log := range receipt.Logs {
-					log.BlockHash = hash
-				}
-				logs = append(logs, receipt.Logs...)
-			}
-			// Commit block and state to database.
-			stat, err := w.chain.WriteBlockWithState(block, receipts, task.state)
-			if err != nil {
+			if err := w.writeBlock(block, task); err != nil {
 				log.Error("Failed writing block to chain", "err", err)
-				continue
-			}
-			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
-				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
-
-			// Broadcast the block and announce chain insertion event
-			w.mux.Post(core.NewMinedBlockEvent{Block: block})
-
-			var events []interface{}
-			switch stat {
-			case core.CanonStatTy:
-				events = append(events, core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
-				events = append(events, core.ChainHeadEvent{Block: block})
-			case core.SideStatTy:
-				events = append(events, core.ChainSideEvent{Block: block})
 			}
-			w.chain.PostChainEvents(events, logs)
-
-			// Insert the block into the set of pending ones to resultLoop for confirmations
-			w.unconfirmed.Insert(block.NumberU64(), block.Hash())
 
 		case <-w.exitCh:
 			return
@@ -606,6 +626,55 @@ func (w *worker) resultLoop() {
 	}
 }
 
+// writeBlock commits a sealed block and the state of its task to the database,
+// announcing it to the network and the chain event subscribers.
+func (w *worker) writeBlock(block *types.Block, task *task) error {
+	var (
+		sealhash = w.engine.SealHash(block.Header())
+		hash     = block.Hash()
+	)
+
+	// Different block could share same sealhash, deep copy here to prevent write-write conflict.
+	var (
+		receipts = make([]*types.Receipt, len(task.receipts))
+		logs     []*types.Log
+	)
+	for i, receipt := range task.receipts {
+		receipts[i] = new(types.Receipt)
+		*receipts[i] = *receipt
+		// Update the block hash in all logs since it is now available and not when the
+		// receipt/log of individual transactions were created.
+		for _, log := range receipt.Logs {
+			log.BlockHash = hash
+		}
+		logs = append(logs, receipt.Logs...)
+	}
+	// Commit block and state to database.
+	stat, err := w.chain.WriteBlockWithState(block, receipts, task.state)
+	if err != nil {
+		return err
+	}
+	log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
+		"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
+
+	// Broadcast the block and announce chain insertion event
+	w.mux.Post(core.NewMinedBlockEvent{Block: block})
+
+	var events []interface{}
+	switch stat {
+	case core.CanonStatTy:
+		events = append(events, core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
+		events = append(events, core.ChainHeadEvent{Block: block})
+	case core.SideStatTy:
+		events = append(events, core.ChainSideEvent{Block: block})
+	}
+	w.chain.PostChainEvents(events, logs)
+
+	// Insert the block into the set of pending ones to resultLoop for confirmations
+	w.unconfirmed.Insert(block.NumberU64(), block.Hash())
+	return nil
+}
+
 // makeCurrent creates a new environment for the current cycle.
 func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 	state, err := w.chain.StateAt(parent.Root())
@@ -702,7 +771,20 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
+// orderTransactions splits the given transactions into locals and remotes, and
+// orders them for inclusion into the current block with the configured policy.
+func (w *worker) orderTransactions(txs map[common.Address]types.Transactions) TxIterator {
+	localTxs, remoteTxs := make(map[common.Address]types.Transactions), txs
+	for _, account := range w.eth.TxPool().Locals() {
+		if accTxs := remoteTxs[account]; len(accTxs) > 0 {
+			delete(remoteTxs, account)
+			localTxs[account] = accTxs
+		}
+	}
+	return w.orderer.Order(w.current.signer, localTxs, remoteTxs)
+}
+
+func (w *worker) commitTransactions(txs TxIterator, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
 		return true
@@ -712,6 +794,13 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
 	}
 
+	// Let the ordering policy account for the transactions already in the block
+	tracker, _ := txs.(TxGasTracker)
+	if tracker != nil {
+		for i, tx := range w.current.txs {
+			tracker.Included(tx, w.current.receipts[i].GasUsed)
+		}
+	}
 	var coalescedLogs []*types.Log
 
 	for {
@@ -782,6 +871,9 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			w.current.tcount++
+			if tracker != nil {
+				tracker.Included(tx, w.current.receipts[len(w.current.receipts)-1].GasUsed)
+			}
 			txs.Shift()
 
 		default:
@@ -792,7 +884,19 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		}
 	}
 
-	if !w.isRunning() && len(coalescedLogs) > 0 {
+	w.postPendingLogs(coalescedLogs)
+
+	// Notify resubmit loop to decrease resubmitting interval if current interval is larger
+	// than the user-specified one.
+	if interrupt != nil {
+		w.resubmitAdjustCh <- &intervalAdjust{inc: false}
+	}
+	return false
+}
+
+// postPendingLogs announces the logs of transactions added to the pending block.
+func (w *worker) postPendingLogs(logs []*types.Log) {
+	if !w.isRunning() && len(logs) > 0 {
 		// We don't push the pendingLogsEvent while we are mining. The reason is that
 		// when we are mining, the worker will regenerate a mining block every 3 seconds.
 		// In order to avoid pushing the repeated pendingLog, we disable the pending log pushing.
@@ -800,19 +904,13 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		// make a copy, the state caches the logs and these logs get "upgraded" from pending to mined
 		// logs by filling in the block hash when the block was mined by the local miner. This can
 		// cause a race condition if a log was "upgraded" before the PendingLogsEvent is processed.
-		cpy := make([]*types.Log, len(coalescedLogs))
-		for i, l := range coalescedLogs {
+		cpy := make([]*types.Log, len(logs))
+		for i, l := range logs {
 			cpy[i] = new(types.Log)
 			*cpy[i] = *l
 		}
 		go w.mux.Post(core.PendingLogsEvent{Logs: cpy})
 	}
-	// Notify resubmit loop to decrease resubmitting interval if current interval is larger
-	// than the user-specified one.
-	if interrupt != nil {
-		w.resubmitAdjustCh <- &intervalAdjust{inc: false}
-	}
-	return false
 }
 
 // commitNewWork generates several new sealing tasks based on the parent block.
@@ -826,8 +924,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	if parent.Time().Cmp(new(big.Int).SetInt64(timestamp)) >= 0 {
 		timestamp = parent.Time().Int64() + 1
 	}
-	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); timestamp > now+1 {
+	// this will ensure we're not going off too far in the future (only when mining,
+	// the pending block of a chain already ahead of time is not delayed otherwise)
+	if now := time.Now().Unix(); timestamp > now+1 && w.isRunning() {
 		wait := time.Duration(timestamp-now) * time.Second
 		log.Info("Mining too far in the future", "wait", common.PrettyDuration(wait))
 		time.Sleep(wait)
@@ -908,6 +1007,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		w.commit(uncles, nil, false, tstart)
 	}
 
+	// Commit the transaction bundles ahead of any pool transaction.
+	w.commitBundles(w.coinbase)
+
 	// Fill the block with all available pending transactions.
 	pending, err := w.eth.TxPool().Pending()
 	if err != nil {
@@ -915,31 +1017,67 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		return
 	}
 	// Short circuit if there is no available pending transactions
-	if len(pending) == 0 {
+	if len(pending) == 0 && len(env.txs) == 0 {
 		w.updateSnapshot()
 		return
 	}
-	// Split the pending transactions into locals and remotes
-	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
-	for _, account := range w.eth.TxPool().Locals() {
-		if txs := remoteTxs[account]; len(txs) > 0 {
-			delete(remoteTxs, account)
-			localTxs[account] = txs
-		}
+	if w.commitTransactions(w.orderTransactions(pending), w.coinbase, interrupt) {
+		return
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
-		if w.commitTransactions(txs, w.coinbase, interrupt) {
-			return
-		}
+	w.commit(uncles, w.fullTaskHook, true, tstart)
+}
+
+// sealNewWork generates a sealing task on top of the current head, containing all
+// the pending transactions. Contrary to commitNewWork, the block is left to the
+// consensus engine to be timed and the etherbase is credited even if the miner
+// is not running.
+func (w *worker) sealNewWork() (*task, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	tstart := time.Now()
+	parent := w.chain.CurrentBlock()
+
+	num := parent.Number()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     num.Add(num, common.Big1),
+		GasLimit:   core.CalcGasLimit(parent, w.gasFloor, w.gasCeil),
+		Extra:      w.extra,
+		Time:       big.NewInt(tstart.Unix()),
+		Coinbase:   w.coinbase,
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs)
-		if w.commitTransactions(txs, w.coinbase, interrupt) {
-			return
-		}
+	if err := w.engine.Prepare(w.chain, header); err != nil {
+		return nil, err
 	}
-	w.commit(uncles, w.fullTaskHook, true, tstart)
+	if err := w.makeCurrent(parent, header); err != nil {
+		return nil, err
+	}
+	// Commit the transaction bundles and all pending transactions
+	w.commitBundles(w.coinbase)
+
+	pending, err := w.eth.TxPool().Pending()
+	if err != nil {
+		return nil, err
+	}
+	w.commitTransactions(w.orderTransactions(pending), w.coinbase, nil)
+
+	// Assemble the block, deep copying the receipts and state for the task
+	receipts := make([]*types.Receipt, len(w.current.receipts))
+	for i, l := range w.current.receipts {
+		receipts[i] = new(types.Receipt)
+		*receipts[i] = *l
+	}
+	s := w.current.state.Copy()
+	block, err := w.engine.Finalize(w.chain, w.current.header, s, w.current.txs, nil, w.current.receipts)
+	if err != nil {
+		return nil, err
+	}
+	w.updateSnapshot()
+
+	log.Info("Commit new sealing work", "number", block.Number(), "txs", w.current.tcount, "gas", block.GasUsed(),
+		"elapsed", common.PrettyDuration(time.Since(tstart)))
+	return &task{receipts: receipts, state: s, block: block, createdAt: tstart}, nil
 }
 
 // commit runs any post-transaction state modifications, assembles the final block
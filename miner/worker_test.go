@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/instant"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -36,9 +37,11 @@ import (
 
 var (
 	// Test chain configurations
-	testTxPoolConfig  core.TxPoolConfig
-	ethashChainConfig *params.ChainConfig
-	cliqueChainConfig *params.ChainConfig
+	testTxPoolConfig   core.TxPoolConfig
+	testConfig         *Config
+	ethashChainConfig  *params.ChainConfig
+	cliqueChainConfig  *params.ChainConfig
+	instantChainConfig *params.ChainConfig
 
 	// Test accounts
 	testBankKey, _  = crypto.GenerateKey()
@@ -56,12 +59,19 @@ var (
 func init() {
 	testTxPoolConfig = core.DefaultTxPoolConfig
 	testTxPoolConfig.Journal = ""
+	testConfig = &Config{
+		Recommit: time.Second,
+		GasFloor: params.GenesisGasLimit,
+		GasCeil:  params.GenesisGasLimit,
+	}
 	ethashChainConfig = params.TestChainConfig
 	cliqueChainConfig = params.TestChainConfig
 	cliqueChainConfig.Clique = &params.CliqueConfig{
 		Period: 10,
 		Epoch:  30000,
 	}
+	instantChainConfig = params.AllInstantProtocolChanges
+
 	tx1, _ := types.SignTx(types.NewTransaction(0, testUserAddress, big.NewInt(1000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
 	pendingTxs = append(pendingTxs, tx1)
 	tx2, _ := types.SignTx(types.NewTransaction(1, testUserAddress, big.NewInt(1000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
@@ -90,7 +100,7 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 	case *clique.Clique:
 		gspec.ExtraData = make([]byte, 32+common.AddressLength+65)
 		copy(gspec.ExtraData[32:], testBankAddress[:])
-	case *ethash.Ethash:
+	case *ethash.Ethash, *instant.Instant:
 	default:
 		t.Fatalf("unexpected consensus engine type: %T", engine)
 	}
@@ -133,7 +143,7 @@ func (b *testWorkerBackend) PostChainEvents(events []interface{}) {
 func newTestWorker(t *testing.T, chainConfig *params.ChainConfig, engine consensus.Engine, blocks int) (*worker, *testWorkerBackend) {
 	backend := newTestWorkerBackend(t, chainConfig, engine, blocks)
 	backend.txPool.AddLocals(pendingTxs)
-	w := newWorker(chainConfig, engine, backend, new(event.TypeMux), time.Second, params.GenesisGasLimit, params.GenesisGasLimit, nil)
+	w := newWorker(testConfig, chainConfig, engine, backend, new(event.TypeMux), nil)
 	w.setEtherbase(testBankAddress)
 	return w, backend
 }
@@ -230,6 +240,69 @@ func testEmptyWork(t *testing.T, chainConfig *params.ChainConfig, engine consens
 	}
 }
 
+func TestSealBlockInstant(t *testing.T) {
+	engine := instant.New()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, instantChainConfig, engine, 0)
+	defer w.close()
+
+	// seal seals a new block on demand and checks its contents
+	seal := func(number uint64, txs int) *types.Block {
+		block, err := w.sealBlock()
+		if err != nil {
+			t.Fatalf("block %d: failed to seal: %v", number, err)
+		}
+		if head := b.chain.CurrentBlock(); head.Hash() != block.Hash() {
+			t.Errorf("block %d: head mismatch: have %x, want %x", number, head.Hash(), block.Hash())
+		}
+		if block.NumberU64() != number {
+			t.Errorf("block %d: number mismatch: have %d, want %d", number, block.NumberU64(), number)
+		}
+		if len(block.Transactions()) != txs {
+			t.Errorf("block %d: transaction count mismatch: have %d, want %d", number, len(block.Transactions()), txs)
+		}
+		if block.Coinbase() != testBankAddress {
+			t.Errorf("block %d: coinbase mismatch: have %x, want %x", number, block.Coinbase(), testBankAddress)
+		}
+		return block
+	}
+	// Seal a block with the pending transaction, followed by an empty one, even
+	// though the miner is not running
+	seal(1, 1)
+	seal(2, 0)
+
+	// Move the chain into the future and ensure subsequent blocks follow suit
+	next := b.chain.CurrentBlock().Time().Uint64() + 3600
+	engine.SetNextTimestamp(next)
+
+	if block := seal(3, 0); block.Time().Uint64() != next {
+		t.Errorf("timestamp mismatch: have %d, want %d", block.Time(), next)
+	}
+	if block := seal(4, 0); block.Time().Uint64() != next+1 {
+		t.Errorf("timestamp mismatch: have %d, want %d", block.Time(), next+1)
+	}
+	// Rewind the chain and ensure the pool resets without reinjecting the dropped transaction
+	for pending, _ := b.txPool.Stats(); pending > 0; pending, _ = b.txPool.Stats() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := b.chain.SetHead(0); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	b.PostChainEvents([]interface{}{core.ChainHeadEvent{Block: b.chain.CurrentBlock()}})
+
+	timeout := time.NewTimer(time.Second)
+	defer timeout.Stop()
+	for b.txPool.State().GetNonce(testBankAddress) != 0 {
+		select {
+		case <-timeout.C:
+			t.Fatalf("transaction pool not reset")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	seal(1, 0)
+}
+
 func TestStreamUncleBlock(t *testing.T) {
 	ethash := ethash.NewFaker()
 	defer ethash.Close()
@@ -452,3 +525,142 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestTransactionBundles(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, 0)
+	defer w.close()
+
+	// Ensure worker has finished initialization
+	for {
+		b := w.pendingBlock()
+		if b != nil && b.NumberU64() == 1 {
+			break
+		}
+	}
+	// Bundles targeting already mined blocks must be rejected
+	if _, err := w.addBundle(types.Transactions{newTxs[0]}, 0); err != errBundleExpired {
+		t.Fatalf("expired bundle error mismatch: have %v, want %v", err, errBundleExpired)
+	}
+	// Schedule a bundle failing on its second transaction, which must be dropped
+	// without affecting the pool transaction from the same account
+	gapped, _ := types.SignTx(types.NewTransaction(5, testUserAddress, big.NewInt(1000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+	if _, err := w.addBundle(types.Transactions{pendingTxs[0], gapped}, 1); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	w.newWorkCh <- &newWorkReq{timestamp: time.Now().Unix()}
+	time.Sleep(100 * time.Millisecond)
+
+	block, state := w.pending()
+	if len(block.Transactions()) != 1 {
+		t.Errorf("transaction count mismatch: have %d, want %d", len(block.Transactions()), 1)
+	}
+	if balance := state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("account balance mismatch: have %d, want %d", balance, 1000)
+	}
+	// Schedule a bundle succeeding entirely, which must be included first
+	if _, err := w.addBundle(types.Transactions{pendingTxs[0], newTxs[0]}, 5); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	w.newWorkCh <- &newWorkReq{timestamp: time.Now().Unix()}
+	time.Sleep(100 * time.Millisecond)
+
+	block, state = w.pending()
+	if len(block.Transactions()) != 2 || block.Transactions()[1].Hash() != newTxs[0].Hash() {
+		t.Errorf("bundle not included: have %d transactions", len(block.Transactions()))
+	}
+	if balance := state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("account balance mismatch: have %d, want %d", balance, 2000)
+	}
+	// Bundles must expire once their target block is passed
+	if bundles := w.pendingBundles(2); len(bundles) != 1 {
+		t.Errorf("pending bundle count mismatch: have %d, want %d", len(bundles), 1)
+	}
+	// Bundles must be dropped once included in the chain
+	blocks, _ := core.GenerateChain(w.config, w.chain.CurrentBlock(), w.engine, b.db, 1, func(i int, gen *core.BlockGen) {
+		gen.AddTx(pendingTxs[0])
+		gen.AddTx(newTxs[0])
+	})
+	if _, err := w.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if block, _ := w.pending(); block.NumberU64() != 2 || len(block.Transactions()) != 0 {
+		t.Errorf("pending block mismatch: have #%d with %d transactions, want #2 with none", block.NumberU64(), len(block.Transactions()))
+	}
+	if bundles := w.pendingBundles(2); len(bundles) != 0 {
+		t.Errorf("included bundles retained: have %d", len(bundles))
+	}
+}
+
+// Tests that the logs of the bundles committed to the pending block are posted.
+func TestTransactionBundleLogs(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, 0)
+	defer w.close()
+
+	for {
+		b := w.pendingBlock()
+		if b != nil && b.NumberU64() == 1 {
+			break
+		}
+	}
+	sub := w.mux.Subscribe(core.PendingLogsEvent{})
+	defer sub.Unsubscribe()
+
+	// Bundle a contract creation emitting an empty log (PUSH1 0 PUSH1 0 LOG0)
+	create, _ := types.SignTx(types.NewContractCreation(1, nil, 100000, nil, common.FromHex("0x60006000a0")), types.HomesteadSigner{}, testBankKey)
+	if _, err := w.addBundle(types.Transactions{pendingTxs[0], create}, 1); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	w.newWorkCh <- &newWorkReq{timestamp: time.Now().Unix()}
+
+	select {
+	case ev := <-sub.Chan():
+		logs := ev.Data.(core.PendingLogsEvent).Logs
+		if len(logs) != 1 || logs[0].TxHash != create.Hash() {
+			t.Fatalf("pending logs mismatch: have %v, want one of %x", logs, create.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("bundle logs not posted")
+	}
+}
+
+// Tests that the transactions applied to the pending block while not mining go
+// through the ordering policy too, which accounts for the ones already included.
+func TestPendingTransactionsOrdered(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	backend := newTestWorkerBackend(t, ethashChainConfig, engine, 0)
+	backend.txPool.AddLocals(pendingTxs)
+
+	// Allow a single value transfer per sender and block
+	config := *testConfig
+	config.TxOrderer = NewQuotaOrderer(params.TxGas, NewPriceOrderer())
+
+	w := newWorker(&config, ethashChainConfig, engine, backend, new(event.TypeMux), nil)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	time.Sleep(100 * time.Millisecond)
+	if block, _ := w.pending(); block.NumberU64() != 1 || len(block.Transactions()) != 1 {
+		t.Fatalf("pending block mismatch: have #%d with %d transactions, want #1 with 1", block.NumberU64(), len(block.Transactions()))
+	}
+	// The next transaction of the sender exceeds its quota in the pending block
+	backend.txPool.AddLocals(newTxs)
+	time.Sleep(100 * time.Millisecond)
+
+	block, state := w.pending()
+	if len(block.Transactions()) != 1 {
+		t.Errorf("transaction count mismatch: have %d, want %d", len(block.Transactions()), 1)
+	}
+	if balance := state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("account balance mismatch: have %d, want %d", balance, 1000)
+	}
+}
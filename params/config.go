@@ -17,10 +17,12 @@
 package params
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // Genesis hashes to enforce below configs on.
@@ -111,16 +113,24 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	// AllInstantProtocolChanges contains every protocol change (EIPs) introduced
+	// and accepted by the Ethereum core developers into the instant-seal developer
+	// consensus.
+	//
+	// This configuration is intentionally not using keyed fields to force anyone
+	// adding flags to the config to also have to set these fields.
+	AllInstantProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(InstantConfig)}
+
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -129,13 +139,44 @@ var (
 // used to start light syncing from this checkpoint and avoid downloading the
 // entire header chain while still being able to securely access old headers/logs.
 type TrustedCheckpoint struct {
-	Name         string      `json:"-"`
+	Name         string      `json:"-" rlp:"-"`
 	SectionIndex uint64      `json:"sectionIndex"`
 	SectionHead  common.Hash `json:"sectionHead"`
 	CHTRoot      common.Hash `json:"chtRoot"`
 	BloomRoot    common.Hash `json:"bloomRoot"`
 }
 
+// Hash returns the hash of checkpoint's four key fields(index, sectionHead, chtRoot and bloomTrieRoot).
+func (c *TrustedCheckpoint) Hash() common.Hash {
+	buf := make([]byte, 8+3*common.HashLength)
+	binary.BigEndian.PutUint64(buf, c.SectionIndex)
+	copy(buf[8:], c.SectionHead.Bytes())
+	copy(buf[8+common.HashLength:], c.CHTRoot.Bytes())
+	copy(buf[8+2*common.HashLength:], c.BloomRoot.Bytes())
+	return crypto.Keccak256Hash(buf)
+}
+
+// HashEqual returns an indicator comparing the itself hash with given one.
+func (c *TrustedCheckpoint) HashEqual(hash common.Hash) bool {
+	if c.Empty() {
+		return hash == common.Hash{}
+	}
+	return c.Hash() == hash
+}
+
+// Empty returns an indicator whether the checkpoint is regarded as empty.
+func (c *TrustedCheckpoint) Empty() bool {
+	return c.SectionHead == (common.Hash{}) || c.CHTRoot == (common.Hash{}) || c.BloomRoot == (common.Hash{})
+}
+
+// CheckpointOracleConfig represents a set of checkpoint contract(which acts as an oracle)
+// config which used for light client checkpoint syncing.
+type CheckpointOracleConfig struct {
+	Address   common.Address   `json:"address"`
+	Signers   []common.Address `json:"signers"`
+	Threshold uint64           `json:"threshold"`
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
@@ -161,8 +202,9 @@ type ChainConfig struct {
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
-	Ethash *EthashConfig `json:"ethash,omitempty"`
-	Clique *CliqueConfig `json:"clique,omitempty"`
+	Ethash  *EthashConfig  `json:"ethash,omitempty"`
+	Clique  *CliqueConfig  `json:"clique,omitempty"`
+	Instant *InstantConfig `json:"instant,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -175,8 +217,17 @@ func (c *EthashConfig) String() string {
 
 // CliqueConfig is the consensus engine configs for proof-of-authority based sealing.
 type CliqueConfig struct {
-	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
-	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+	Period      uint64             `json:"period"`                // Number of seconds between blocks to enforce
+	Epoch       uint64             `json:"epoch"`                 // Epoch length to reset votes and checkpoint
+	Transitions []CliqueTransition `json:"transitions,omitempty"` // Signer set overrides scheduled at given blocks
+}
+
+// CliqueTransition is a signer set override of the proof-of-authority engine,
+// replacing the authorized signers (and discarding all pending votes) starting
+// with a given block. The signer set must not be empty, see CheckTransitions.
+type CliqueTransition struct {
+	Block   uint64           `json:"block"`   // Block number from which the signer set is authorized
+	Signers []common.Address `json:"signers"` // Signer set replacing the current one
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -184,6 +235,99 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// CheckTransitions verifies that the signer set overrides are well formed: each
+// must be scheduled for a distinct block after the genesis (whose signers are set
+// in its extra-data), and install a non-empty signer set without duplicates.
+func (c *CliqueConfig) CheckTransitions() error {
+	blocks := make(map[uint64]struct{})
+	for _, t := range c.Transitions {
+		if t.Block == 0 {
+			return fmt.Errorf("clique transition at genesis block")
+		}
+		if _, ok := blocks[t.Block]; ok {
+			return fmt.Errorf("duplicate clique transition at block %d", t.Block)
+		}
+		blocks[t.Block] = struct{}{}
+
+		if len(t.Signers) == 0 {
+			return fmt.Errorf("clique transition at block %d without signers", t.Block)
+		}
+		signers := make(map[common.Address]struct{})
+		for _, signer := range t.Signers {
+			if _, ok := signers[signer]; ok {
+				return fmt.Errorf("clique transition at block %d with duplicate signer %s", t.Block, signer.Hex())
+			}
+			signers[signer] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// TransitionSigners returns the signer set override scheduled for the given
+// block number, or nil if there is none.
+func (c *CliqueConfig) TransitionSigners(number uint64) []common.Address {
+	for _, t := range c.Transitions {
+		if t.Block == number {
+			return t.Signers
+		}
+	}
+	return nil
+}
+
+// IsTransition returns whether a signer set override is scheduled for the given
+// block number.
+func (c *CliqueConfig) IsTransition(number uint64) bool {
+	return c.TransitionSigners(number) != nil
+}
+
+// transitionMismatch returns the lowest block number at which the signer set
+// overrides of two configurations differ, or nil if they are equivalent.
+func (c *CliqueConfig) transitionMismatch(newcfg *CliqueConfig) *big.Int {
+	var lowest *big.Int
+	check := func(number uint64) {
+		if !signersEqual(c.TransitionSigners(number), newcfg.TransitionSigners(number)) {
+			if lowest == nil || lowest.Uint64() > number {
+				lowest = new(big.Int).SetUint64(number)
+			}
+		}
+	}
+	for _, t := range c.Transitions {
+		check(t.Block)
+	}
+	for _, t := range newcfg.Transitions {
+		check(t.Block)
+	}
+	return lowest
+}
+
+// signersEqual returns whether two signer lists contain the same addresses,
+// regardless of their order.
+func signersEqual(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[common.Address]int, len(a))
+	for _, signer := range a {
+		count[signer]++
+	}
+	for _, signer := range b {
+		if count[signer] == 0 {
+			return false
+		}
+		count[signer]--
+	}
+	return true
+}
+
+// InstantConfig is the consensus engine configs for developer chains, sealing
+// blocks instantly whenever requested.
+type InstantConfig struct{}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *InstantConfig) String() string {
+	return "instant"
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -192,6 +336,8 @@ func (c *ChainConfig) String() string {
 		engine = c.Ethash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.Instant != nil:
+		engine = c.Instant
 	default:
 		engine = "unknown"
 	}
@@ -317,6 +463,11 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if c.Clique != nil && newcfg.Clique != nil {
+		if number := c.Clique.transitionMismatch(newcfg.Clique); isForked(number, head) {
+			return newCompatError("clique signer transition", number, number)
+		}
+	}
 	return nil
 }
 
@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +72,23 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{Clique: &CliqueConfig{Transitions: []CliqueTransition{{Block: 10, Signers: []common.Address{{0x01}}}}}},
+			new:     &ChainConfig{Clique: &CliqueConfig{Transitions: []CliqueTransition{{Block: 10, Signers: []common.Address{{0x01}}}, {Block: 20, Signers: []common.Address{{0x02}}}}}},
+			head:    15,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Clique: &CliqueConfig{Transitions: []CliqueTransition{{Block: 10, Signers: []common.Address{{0x01}}}}}},
+			new:    &ChainConfig{Clique: &CliqueConfig{Transitions: []CliqueTransition{{Block: 10, Signers: []common.Address{{0x02}}}}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "clique signer transition",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +98,35 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckTransitions(t *testing.T) {
+	a, b := common.Address{0x01}, common.Address{0x02}
+
+	tests := []struct {
+		transitions []CliqueTransition
+		valid       bool
+	}{
+		{nil, true},
+		{[]CliqueTransition{{Block: 10, Signers: []common.Address{a}}, {Block: 20, Signers: []common.Address{a, b}}}, true},
+		{[]CliqueTransition{{Block: 10, Signers: []common.Address{}}}, false},
+		{[]CliqueTransition{{Block: 10}}, false},
+		{[]CliqueTransition{{Block: 10, Signers: []common.Address{a, a}}}, false},
+		{[]CliqueTransition{{Block: 10, Signers: []common.Address{a}}, {Block: 10, Signers: []common.Address{b}}}, false},
+		{[]CliqueTransition{{Block: 0, Signers: []common.Address{a}}}, false},
+	}
+	for i, tt := range tests {
+		err := (&CliqueConfig{Transitions: tt.transitions}).CheckTransitions()
+		if tt.valid && err != nil {
+			t.Errorf("test %d: valid transitions rejected: %v", i, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("test %d: invalid transitions accepted", i)
+		}
+	}
+	if signersEqual([]common.Address{a, b}, []common.Address{a, a}) {
+		t.Errorf("signer lists with differing duplicates reported equal")
+	}
+	if !signersEqual([]common.Address{a, b}, []common.Address{b, a}) {
+		t.Errorf("reordered signer lists reported different")
+	}
+}
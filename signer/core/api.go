@@ -49,6 +49,10 @@ type ExternalAPI interface {
 	SignTransaction(ctx context.Context, args SendTxArgs, methodSelector *string) (*ethapi.SignTransactionResult, error)
 	// Sign - request to sign the given data (plus prefix)
 	Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error)
+	// SignData - request to sign the given data, interpreted according to the content type
+	SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error)
+	// SignTypedData - request to sign the given EIP-712 typed data
+	SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data TypedData) (hexutil.Bytes, error)
 	// Export - request to export an account
 	Export(ctx context.Context, addr common.Address) (json.RawMessage, error)
 	// Import - request to import an account
@@ -93,6 +97,7 @@ type SignerAPI struct {
 	am         *accounts.Manager
 	UI         SignerUI
 	validator  *Validator
+	simulator  *Simulator
 	rejectMode bool
 }
 
@@ -142,6 +147,7 @@ type (
 	SignTxRequest struct {
 		Transaction SendTxArgs       `json:"transaction"`
 		Callinfo    []ValidationInfo `json:"call_info"`
+		Simulation  *Simulation      `json:"simulation,omitempty"`
 		Meta        Metadata         `json:"meta"`
 	}
 	// SignTxResponse result from SignTxRequest
@@ -170,11 +176,13 @@ type (
 		NewPassword string `json:"new_password"`
 	}
 	SignDataRequest struct {
-		Address common.MixedcaseAddress `json:"address"`
-		Rawdata hexutil.Bytes           `json:"raw_data"`
-		Message string                  `json:"message"`
-		Hash    hexutil.Bytes           `json:"hash"`
-		Meta    Metadata                `json:"meta"`
+		ContentType string                  `json:"content_type"`
+		Address     common.MixedcaseAddress `json:"address"`
+		Rawdata     hexutil.Bytes           `json:"raw_data"`
+		Message     string                  `json:"message"`
+		Messages    []*NameValueType        `json:"messages"`
+		Hash        hexutil.Bytes           `json:"hash"`
+		Meta        Metadata                `json:"meta"`
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -223,7 +231,10 @@ var ErrRequestDenied = errors.New("Request denied")
 // key that is generated when a new Account is created.
 // noUSB disables USB support that is required to support hardware devices such as
 // ledger and trezor.
-func NewSignerAPI(chainID int64, ksLocation string, noUSB bool, ui SignerUI, abidb *AbiDb, lightKDF bool, advancedMode bool) *SignerAPI {
+//
+// If a simulator is given, transactions are executed on its node and the outcome
+// is presented to the UI before approval.
+func NewSignerAPI(chainID int64, ksLocation string, noUSB bool, ui SignerUI, abidb *AbiDb, simulator *Simulator, lightKDF bool, advancedMode bool) *SignerAPI {
 	var (
 		backends []accounts.Backend
 		n, p     = keystore.StandardScryptN, keystore.StandardScryptP
@@ -254,7 +265,7 @@ func NewSignerAPI(chainID int64, ksLocation string, noUSB bool, ui SignerUI, abi
 			log.Debug("Trezor support enabled")
 		}
 	}
-	signer := &SignerAPI{big.NewInt(chainID), accounts.NewManager(backends...), ui, NewValidator(abidb), !advancedMode}
+	signer := &SignerAPI{big.NewInt(chainID), accounts.NewManager(backends...), ui, NewValidator(abidb), simulator, !advancedMode}
 	if !noUSB {
 		signer.startUSBListener()
 	}
@@ -466,10 +477,25 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 		}
 	}
 
+	// Preview the outcome of the transaction, if a node is available. Failures are
+	// only shown to the user, the node's view of the chain may well be outdated.
+	var sim *Simulation
+	if api.simulator != nil {
+		if sim, err = api.simulator.Simulate(ctx, &args); err != nil {
+			msgs.warn(fmt.Sprintf("Transaction simulation failed: %v", err))
+		} else if sim.Failed {
+			if sim.RevertReason != "" {
+				msgs.warn(fmt.Sprintf("Transaction reverts: %q", sim.RevertReason))
+			} else {
+				msgs.warn("Transaction reverts")
+			}
+		}
+	}
 	req := SignTxRequest{
 		Transaction: args,
 		Meta:        MetadataFromContext(ctx),
 		Callinfo:    msgs.Messages,
+		Simulation:  sim,
 	}
 	// Process approval
 	result, err = api.UI.ApproveTx(&req)
@@ -521,11 +547,45 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 // https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_sign
 func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
 	sighash, msg := SignHash(data)
+	req := &SignDataRequest{ContentType: accounts.MimetypeTextPlain, Address: addr, Rawdata: data, Message: msg, Hash: sighash, Meta: MetadataFromContext(ctx)}
+	return api.sign(addr, req, true)
+}
+
+// SignTypedData signs EIP-712 conformant typed data, calculating the signature for:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+//
+// The typed data is validated and the domain and message are presented to the
+// UI in a human readable form. If the domain specifies a chain id, it needs to
+// match the chain id the signer is configured with.
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, typedData TypedData) (hexutil.Bytes, error) {
+	if err := typedData.Validate(); err != nil {
+		return nil, err
+	}
+	if chainId := typedData.Domain.ChainId; chainId != nil && chainId.Cmp(api.chainID) != 0 {
+		return nil, fmt.Errorf("chain id mismatch: domain has %v, signer uses %v", chainId, api.chainID)
+	}
+	sighash, rawData, err := typedData.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	messages, err := typedData.Format()
+	if err != nil {
+		return nil, err
+	}
+	req := &SignDataRequest{ContentType: accounts.MimetypeTypedData, Address: addr, Rawdata: rawData, Messages: messages, Hash: sighash, Meta: MetadataFromContext(ctx)}
+	return api.sign(addr, req, true)
+}
+
+// sign asks the UI to approve the signing request, and signs the hash contained
+// in it with the requested account. If legacyV is set, the V value of the
+// signature is transformed to 27/28.
+func (api *SignerAPI) sign(addr common.MixedcaseAddress, req *SignDataRequest, legacyV bool) (hexutil.Bytes, error) {
 	// We make the request prior to looking up if we actually have the account, to prevent
 	// account-enumeration via the API
-	req := &SignDataRequest{Address: addr, Rawdata: data, Message: msg, Hash: sighash, Meta: MetadataFromContext(ctx)}
 	res, err := api.UI.ApproveSignData(req)
-
 	if err != nil {
 		return nil, err
 	}
@@ -539,12 +599,14 @@ func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 		return nil, err
 	}
 	// Assemble sign the data with the wallet
-	signature, err := wallet.SignHashWithPassphrase(account, res.Password, sighash)
+	signature, err := wallet.SignHashWithPassphrase(account, res.Password, req.Hash)
 	if err != nil {
 		api.UI.ShowError(err.Error())
 		return nil, err
 	}
-	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	if legacyV {
+		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	}
 	return signature, nil
 }
 
@@ -145,6 +145,7 @@ func setup(t *testing.T) (*SignerAPI, chan string) {
 			true,
 			ui,
 			db,
+			nil,
 			true, true)
 	)
 	return api, controller
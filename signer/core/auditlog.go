@@ -71,6 +71,22 @@ func (l *AuditLogger) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return b, e
 }
 
+func (l *AuditLogger) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
+	l.log.Info("SignData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "data", data, "content-type", contentType)
+	b, e := l.api.SignData(ctx, contentType, addr, data)
+	l.log.Info("SignData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
+func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data TypedData) (hexutil.Bytes, error) {
+	l.log.Info("SignTypedData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "data", data.String())
+	b, e := l.api.SignTypedData(ctx, addr, data)
+	l.log.Info("SignTypedData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
 func (l *AuditLogger) Export(ctx context.Context, addr common.Address) (json.RawMessage, error) {
 	l.log.Info("Export", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"addr", addr.Hex())
@@ -89,7 +105,9 @@ func (l *AuditLogger) Export(ctx context.Context, addr common.Address) (json.Raw
 //	return a, e
 //}
 
-func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+// NewAuditLog creates a logger emitting audit entries into the file at path, to
+// be shared by the AuditLogger and any other component auditing its decisions.
+func NewAuditLog(path string) (log.Logger, error) {
 	l := log.New("api", "signer")
 	handler, err := log.FileHandler(path, log.LogfmtFormat())
 	if err != nil {
@@ -97,5 +115,10 @@ func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
 	}
 	l.SetHandler(handler)
 	l.Info("Configured", "audit log", path)
-	return &AuditLogger{l, api}, nil
+	return l, nil
+}
+
+// NewAuditLogger wraps api, logging all requests and responses into the audit log.
+func NewAuditLogger(l log.Logger, api ExternalAPI) *AuditLogger {
+	return &AuditLogger{l, api}
 }
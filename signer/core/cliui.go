@@ -149,6 +149,9 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 		fmt.Println()
 
 	}
+	if request.Simulation != nil {
+		fmt.Printf("Transaction simulation:\n%v\n", request.Simulation)
+	}
 	fmt.Printf("\n")
 	showMetadata(request.Meta)
 	fmt.Printf("-------------------------------------------\n")
@@ -165,7 +168,15 @@ func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResp
 
 	fmt.Printf("-------- Sign data request--------------\n")
 	fmt.Printf("Account:  %s\n", request.Address.String())
-	fmt.Printf("message:  \n%q\n", request.Message)
+	fmt.Printf("content type: %v\n", request.ContentType)
+	if len(request.Messages) > 0 {
+		fmt.Printf("messages: \n")
+		for _, nvt := range request.Messages {
+			fmt.Printf("%v", nvt.Pprint(1))
+		}
+	} else {
+		fmt.Printf("message:  \n%q\n", request.Message)
+	}
 	fmt.Printf("raw data: \n%v\n", request.Rawdata)
 	fmt.Printf("message hash:  %v\n", request.Hash)
 	fmt.Printf("-------------------------------------------\n")
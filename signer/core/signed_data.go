@@ -0,0 +1,749 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// TypedData is a type to encapsulate EIP-712 typed messages
+type TypedData struct {
+	Types       Types            `json:"types"`
+	PrimaryType string           `json:"primaryType"`
+	Domain      TypedDataDomain  `json:"domain"`
+	Message     TypedDataMessage `json:"message"`
+}
+
+// Type is the inner type of an EIP-712 message
+type Type struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Types maps struct type names to their ordered list of fields
+type Types map[string][]Type
+
+// TypedDataMessage is the JSON representation of a struct value
+type TypedDataMessage map[string]interface{}
+
+// TypedDataDomain represents the domain part of an EIP-712 message
+type TypedDataDomain struct {
+	Name              string   `json:"name"`
+	Version           string   `json:"version"`
+	ChainId           *big.Int `json:"chainId"`
+	VerifyingContract string   `json:"verifyingContract"`
+	Salt              string   `json:"salt"`
+}
+
+// NameValueType is a very simple struct with Name, Value and Type. It's meant
+// for simple json structures used to communicate signing-info about typed data
+// with the UI.
+type NameValueType struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Typ   string      `json:"type"`
+}
+
+const eip712DomainType = "EIP712Domain"
+
+// cliqueSealLength is the number of extra-data suffix bytes reserved for the
+// signer seal of clique headers.
+const cliqueSealLength = 65
+
+// String implements the Stringer interface, returning the JSON representation.
+func (typedData TypedData) String() string {
+	s, err := json.Marshal(typedData)
+	if err == nil {
+		return string(s)
+	}
+	return err.Error()
+}
+
+var (
+	typedDataReferenceTypeRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	typedDataArrayRegexp         = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+	typedDataIntegerRegexp       = regexp.MustCompile(`^(u?int)([0-9]+)$`)
+	typedDataBytesRegexp         = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+// ValidatorData represents data signed for an intended validator, as defined by
+// version 0x00 of EIP-191
+type ValidatorData struct {
+	Address common.Address `json:"address"`
+	Message hexutil.Bytes  `json:"message"`
+}
+
+// SignData signs the hash of the provided data, but does so differently
+// depending on the content type specified:
+//
+//   - text/plain: the data is signed as a personal message (personal_sign)
+//   - data/validator: the data is signed for an intended validator (EIP-191 0x00)
+//   - application/x-clique-header: the data is an RLP encoded clique header
+//     without its seal, which is signed with the clique signature hash
+//
+// Note, signatures of clique headers have V in 0/1 form, as expected by clique.
+// All other signatures have V in 27/28 form for legacy reasons.
+func (api *SignerAPI) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
+	req, legacyV, err := api.determineSignatureFormat(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	req.Address = addr
+	req.Meta = MetadataFromContext(ctx)
+
+	return api.sign(addr, req, legacyV)
+}
+
+// determineSignatureFormat parses the data according to the content type and
+// assembles the signing request presented to the UI. It also returns whether
+// the V value of the signature should be shifted to 27/28.
+func (api *SignerAPI) determineSignatureFormat(contentType string, data interface{}) (*SignDataRequest, bool, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false, err
+	}
+	switch mediaType {
+	case accounts.MimetypeTextPlain:
+		// Plain personal message
+		blob, err := parseBytes(data)
+		if err != nil {
+			return nil, false, err
+		}
+		sighash, msg := SignHash(blob)
+		return &SignDataRequest{ContentType: mediaType, Rawdata: blob, Message: msg, Hash: sighash}, true, nil
+
+	case accounts.MimetypeTextWithValidator:
+		// Data with an intended validator
+		validatorData, err := UnmarshalValidatorData(data)
+		if err != nil {
+			return nil, false, err
+		}
+		sighash, msg := SignTextValidator(validatorData)
+		messages := []*NameValueType{
+			{Name: "validator", Value: validatorData.Address.Hex(), Typ: "address"},
+			{Name: "message", Value: validatorData.Message.String(), Typ: "bytes"},
+		}
+		return &SignDataRequest{ContentType: mediaType, Rawdata: []byte(msg), Messages: messages, Hash: sighash}, true, nil
+
+	case accounts.MimetypeClique:
+		// Clique header to seal
+		blob, err := parseBytes(data)
+		if err != nil {
+			return nil, false, err
+		}
+		header, err := decodeCliqueHeader(blob)
+		if err != nil {
+			return nil, false, err
+		}
+		sighash := clique.SigHash(header)
+		return &SignDataRequest{ContentType: mediaType, Rawdata: blob, Messages: formatCliqueHeader(header), Hash: sighash.Bytes()}, false, nil
+	}
+	return nil, false, fmt.Errorf("content type %q not supported", contentType)
+}
+
+// UnmarshalValidatorData converts the JSON representation of validator data into
+// its structured form. The data is either an object with an address and a message,
+// or a hex string consisting of the 20 byte address followed by the message.
+func UnmarshalValidatorData(data interface{}) (ValidatorData, error) {
+	if raw, ok := data.(map[string]interface{}); ok {
+		addr, ok := raw["address"].(string)
+		if !ok || !common.IsHexAddress(addr) {
+			return ValidatorData{}, fmt.Errorf("invalid validator address: %v", raw["address"])
+		}
+		message, err := parseBytes(raw["message"])
+		if err != nil {
+			return ValidatorData{}, err
+		}
+		return ValidatorData{Address: common.HexToAddress(addr), Message: message}, nil
+	}
+	blob, err := parseBytes(data)
+	if err != nil {
+		return ValidatorData{}, err
+	}
+	if len(blob) < common.AddressLength {
+		return ValidatorData{}, fmt.Errorf("validator data too short: %d bytes", len(blob))
+	}
+	return ValidatorData{Address: common.BytesToAddress(blob[:common.AddressLength]), Message: blob[common.AddressLength:]}, nil
+}
+
+// SignTextValidator signs the given message which can be further recovered
+// with the given validator, as defined by version 0x00 of EIP-191.
+//
+// The hash is calculated as
+//   keccak256("\x19\x00"${address}${data}).
+func SignTextValidator(validatorData ValidatorData) (hexutil.Bytes, string) {
+	msg := fmt.Sprintf("\x19\x00%s%s", string(validatorData.Address.Bytes()), string(validatorData.Message))
+	return crypto.Keccak256([]byte(msg)), msg
+}
+
+// decodeCliqueHeader decodes a clique header sent without its seal, and adds the
+// room for the seal back, so that the header can be hashed. Only the canonical
+// encoding is accepted, so the signed hash always matches the displayed header.
+func decodeCliqueHeader(blob []byte) (*types.Header, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return nil, fmt.Errorf("invalid clique header: %v", err)
+	}
+	extra := make([]byte, len(header.Extra)+cliqueSealLength)
+	copy(extra, header.Extra)
+	header.Extra = extra
+
+	if !bytes.Equal(clique.CliqueRLP(header), blob) {
+		return nil, errors.New("invalid clique header: non-canonical encoding")
+	}
+	return header, nil
+}
+
+// formatCliqueHeader converts the fields of a clique header relevant for sealing
+// into a human readable form.
+func formatCliqueHeader(header *types.Header) []*NameValueType {
+	vote := "none"
+	switch {
+	case header.Coinbase != (common.Address{}) && header.Nonce == types.BlockNonce{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}:
+		vote = "authorize " + header.Coinbase.Hex()
+	case header.Coinbase != (common.Address{}):
+		vote = "drop " + header.Coinbase.Hex()
+	}
+	return []*NameValueType{
+		{Name: "number", Value: header.Number.String(), Typ: "uint256"},
+		{Name: "parentHash", Value: header.ParentHash.Hex(), Typ: "bytes32"},
+		{Name: "time", Value: header.Time.String(), Typ: "uint256"},
+		{Name: "difficulty", Value: header.Difficulty.String(), Typ: "uint256"},
+		{Name: "gasLimit", Value: strconv.FormatUint(header.GasLimit, 10), Typ: "uint64"},
+		{Name: "gasUsed", Value: strconv.FormatUint(header.GasUsed, 10), Typ: "uint64"},
+		{Name: "stateRoot", Value: header.Root.Hex(), Typ: "bytes32"},
+		{Name: "transactionsRoot", Value: header.TxHash.Hex(), Typ: "bytes32"},
+		{Name: "extraData", Value: hexutil.Encode(header.Extra[:len(header.Extra)-cliqueSealLength]), Typ: "bytes"},
+		{Name: "vote", Value: vote, Typ: "string"},
+	}
+}
+
+// SignatureHash calculates the hash to sign for the typed data, as defined by
+// EIP-712:
+//
+//   keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+//
+// It returns both the hash and its preimage.
+func (typedData *TypedData) SignatureHash() (hexutil.Bytes, hexutil.Bytes, error) {
+	domainSeparator, err := typedData.HashStruct(eip712DomainType, typedData.Domain.Map())
+	if err != nil {
+		return nil, nil, err
+	}
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
+	return crypto.Keccak256(rawData), rawData, nil
+}
+
+// HashStruct generates a keccak256 hash of the encoding of the provided data
+func (typedData *TypedData) HashStruct(primaryType string, data TypedDataMessage) (hexutil.Bytes, error) {
+	encodedData, err := typedData.EncodeData(primaryType, data)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encodedData), nil
+}
+
+// Dependencies returns an array of custom types ordered by their hierarchical
+// reference tree, starting with the primary type itself
+func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
+	for _, dep := range found {
+		if dep == primaryType {
+			return found
+		}
+	}
+	if typedData.Types[primaryType] == nil {
+		return found
+	}
+	found = append(found, primaryType)
+	for _, field := range typedData.Types[primaryType] {
+		found = typedData.Dependencies(typedDataBaseType(field.Type), found)
+	}
+	return found
+}
+
+// EncodeType generates the following encoding:
+// `name ‖ "(" ‖ member₁ ‖ "," ‖ member₂ ‖ "," ‖ … ‖ memberₙ ")"`
+//
+// each member is written as `type ‖ " " ‖ name` encodings cascade down and are
+// sorted by name
+func (typedData *TypedData) EncodeType(primaryType string) hexutil.Bytes {
+	// Get dependencies primary first, then alphabetical
+	deps := typedData.Dependencies(primaryType, nil)
+	if len(deps) > 0 {
+		sort.Strings(deps[1:])
+	}
+	// Format as a string with fields
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.Bytes()
+}
+
+// TypeHash creates the keccak256 hash of the data
+func (typedData *TypedData) TypeHash(primaryType string) hexutil.Bytes {
+	return crypto.Keccak256(typedData.EncodeType(primaryType))
+}
+
+// EncodeData generates the following encoding:
+// `enc(value₁) ‖ enc(value₂) ‖ … ‖ enc(valueₙ)`
+//
+// each encoded member is 32-byte long
+func (typedData *TypedData) EncodeData(primaryType string, data map[string]interface{}) (hexutil.Bytes, error) {
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	// Ensure all values are covered by the type definition, nothing is silently dropped
+	for name := range data {
+		if !typedDataHasField(fields, name) {
+			return nil, fmt.Errorf("field %q is not declared in type %q", name, primaryType)
+		}
+	}
+	buffer := bytes.Buffer{}
+	buffer.Write(typedData.TypeHash(primaryType))
+
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for field %q of type %q", field.Name, primaryType)
+		}
+		encValue, err := typedData.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q of type %q: %v", field.Name, primaryType, err)
+		}
+		buffer.Write(encValue)
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeValue encodes a single member of a struct into its 32 byte representation.
+// Arrays and structs are hashed, atomic values are padded.
+func (typedData *TypedData) encodeValue(encType string, value interface{}) ([]byte, error) {
+	if match := typedDataArrayRegexp.FindStringSubmatch(encType); match != nil {
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value for type %s: %v", encType, value)
+		}
+		if match[2] != "" {
+			if length, _ := strconv.Atoi(match[2]); len(array) != length {
+				return nil, fmt.Errorf("invalid length for type %s: have %d", encType, len(array))
+			}
+		}
+		var buffer bytes.Buffer
+		for _, item := range array {
+			encItem, err := typedData.encodeValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(encItem)
+		}
+		return crypto.Keccak256(buffer.Bytes()), nil
+	}
+	if _, ok := typedData.Types[encType]; ok {
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value for type %s: %v", encType, value)
+		}
+		return typedData.HashStruct(encType, mapValue)
+	}
+	return encodePrimitiveValue(encType, value)
+}
+
+// encodePrimitiveValue encodes an atomic or dynamic value of one of the types
+// natively supported by EIP-712.
+func encodePrimitiveValue(encType string, value interface{}) ([]byte, error) {
+	switch encType {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address: %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case "bool":
+		boolValue, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool: %v", value)
+		}
+		if boolValue {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return math.PaddedBigBytes(common.Big0, 32), nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string: %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+	}
+	if match := typedDataBytesRegexp.FindStringSubmatch(encType); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != size {
+			return nil, fmt.Errorf("invalid length for %s: have %d bytes", encType, len(blob))
+		}
+		return common.RightPadBytes(blob, 32), nil
+	}
+	if typedDataIntegerRegexp.MatchString(encType) {
+		integer, err := parseInteger(encType, value)
+		if err != nil {
+			return nil, err
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(integer)), 32), nil
+	}
+	return nil, fmt.Errorf("unrecognized type %q", encType)
+}
+
+// parseBytes decodes a hex encoded byte blob from its JSON representation.
+func parseBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		blob, err := hexutil.Decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %q: %v", v, err)
+		}
+		return blob, nil
+	case []byte:
+		return v, nil
+	case hexutil.Bytes:
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid bytes: %v", value)
+}
+
+// maxSafeJSONInteger is the largest magnitude a JSON number may have to be sure
+// it was not rounded when decoded into a float64 (2^53).
+const maxSafeJSONInteger = 1 << 53
+
+// parseInteger converts a JSON number or a decimal or hex string into an integer,
+// verifying that it fits into the given int or uint type. Larger integers than a
+// JSON number can represent exactly must be given as strings.
+func parseInteger(encType string, value interface{}) (*big.Int, error) {
+	var integer *big.Int
+	switch v := value.(type) {
+	case *big.Int:
+		integer = v
+	case float64:
+		// JSON numbers lose precision from 2^53, the value may already be rounded
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("invalid integer %v for %s", v, encType)
+		}
+		if v >= maxSafeJSONInteger || v <= -maxSafeJSONInteger {
+			return nil, fmt.Errorf("integer %v too large for a JSON number, use a string for %s", v, encType)
+		}
+		integer = big.NewInt(int64(v))
+	case string:
+		var ok bool
+		if integer, ok = math.ParseBig256(v); !ok {
+			return nil, fmt.Errorf("invalid integer %q for %s", v, encType)
+		}
+	default:
+		return nil, fmt.Errorf("invalid integer %v for %s", value, encType)
+	}
+	match := typedDataIntegerRegexp.FindStringSubmatch(encType)
+	size, _ := strconv.Atoi(match[2])
+
+	if match[1] == "uint" {
+		if integer.Sign() < 0 || integer.BitLen() > size {
+			return nil, fmt.Errorf("integer %v out of range for %s", integer, encType)
+		}
+		return integer, nil
+	}
+	max := new(big.Int).Lsh(common.Big1, uint(size-1))
+	min := new(big.Int).Neg(max)
+	if integer.Cmp(min) < 0 || integer.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("integer %v out of range for %s", integer, encType)
+	}
+	return integer, nil
+}
+
+// Validate checks that the types are well formed, that the primary type and the
+// domain are present and that the message can be encoded.
+func (typedData *TypedData) Validate() error {
+	if err := typedData.Types.validate(); err != nil {
+		return err
+	}
+	if _, ok := typedData.Types[eip712DomainType]; !ok {
+		return fmt.Errorf("type %q is not defined", eip712DomainType)
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return fmt.Errorf("primary type %q is not defined", typedData.PrimaryType)
+	}
+	if err := typedData.Domain.validate(); err != nil {
+		return err
+	}
+	if _, err := typedData.EncodeData(eip712DomainType, typedData.Domain.Map()); err != nil {
+		return err
+	}
+	if _, err := typedData.EncodeData(typedData.PrimaryType, typedData.Message); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validate checks that all type definitions are well formed and that all types
+// referenced by the fields are either primitive or defined.
+func (t Types) validate() error {
+	for typeName, fields := range t {
+		if !typedDataReferenceTypeRegexp.MatchString(typeName) || isPrimitiveTypeValid(typeName) {
+			return fmt.Errorf("invalid type name %q", typeName)
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if field.Name == "" {
+				return fmt.Errorf("type %q has an unnamed field", typeName)
+			}
+			if seen[field.Name] {
+				return fmt.Errorf("type %q has duplicate field %q", typeName, field.Name)
+			}
+			seen[field.Name] = true
+
+			baseType := typedDataBaseType(field.Type)
+			if _, ok := t[baseType]; !ok && !isPrimitiveTypeValid(baseType) {
+				return fmt.Errorf("unknown type %q for field %q of type %q", field.Type, field.Name, typeName)
+			}
+		}
+	}
+	return nil
+}
+
+// isPrimitiveTypeValid checks if the type is one of the atomic or dynamic types
+// natively supported by EIP-712.
+func isPrimitiveTypeValid(primitiveType string) bool {
+	switch primitiveType {
+	case "address", "bool", "string", "bytes":
+		return true
+	}
+	if match := typedDataBytesRegexp.FindStringSubmatch(primitiveType); match != nil {
+		size, err := strconv.Atoi(match[1])
+		return err == nil && size >= 1 && size <= 32 && match[1][0] != '0'
+	}
+	if match := typedDataIntegerRegexp.FindStringSubmatch(primitiveType); match != nil {
+		size, err := strconv.Atoi(match[2])
+		return err == nil && size >= 8 && size <= 256 && size%8 == 0 && match[2][0] != '0'
+	}
+	return false
+}
+
+// typedDataBaseType strips all array dimensions from a type.
+func typedDataBaseType(encType string) string {
+	for {
+		match := typedDataArrayRegexp.FindStringSubmatch(encType)
+		if match == nil {
+			return encType
+		}
+		encType = match[1]
+	}
+}
+
+// typedDataHasField checks whether a field with the given name is declared.
+func typedDataHasField(fields []Type, name string) bool {
+	for _, field := range fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks if the domain has at least one field set, and that the set
+// fields are well formed.
+func (domain *TypedDataDomain) validate() error {
+	if domain.ChainId == nil && domain.Name == "" && domain.Version == "" && domain.VerifyingContract == "" && domain.Salt == "" {
+		return errors.New("domain is undefined")
+	}
+	if domain.VerifyingContract != "" && !common.IsHexAddress(domain.VerifyingContract) {
+		return fmt.Errorf("invalid verifying contract %q", domain.VerifyingContract)
+	}
+	return nil
+}
+
+// Map is a helper function to generate a map version of the domain, containing
+// only the fields that are set.
+func (domain *TypedDataDomain) Map() map[string]interface{} {
+	dataMap := map[string]interface{}{}
+	if domain.ChainId != nil {
+		dataMap["chainId"] = domain.ChainId
+	}
+	if len(domain.Name) > 0 {
+		dataMap["name"] = domain.Name
+	}
+	if len(domain.Version) > 0 {
+		dataMap["version"] = domain.Version
+	}
+	if len(domain.VerifyingContract) > 0 {
+		dataMap["verifyingContract"] = domain.VerifyingContract
+	}
+	if len(domain.Salt) > 0 {
+		dataMap["salt"] = domain.Salt
+	}
+	return dataMap
+}
+
+// Format returns a representation of the domain and the message which can be
+// easily displayed by a UI. The typed data is expected to be validated.
+func (typedData *TypedData) Format() ([]*NameValueType, error) {
+	domain, err := typedData.formatData(eip712DomainType, typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	message, err := typedData.formatData(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	return []*NameValueType{
+		{Name: eip712DomainType, Value: domain, Typ: "domain"},
+		{Name: typedData.PrimaryType, Value: message, Typ: "primary type"},
+	}, nil
+}
+
+// formatData converts the fields of a struct into a list of names, types and
+// human readable values, in the order of the type definition.
+func (typedData *TypedData) formatData(primaryType string, data map[string]interface{}) ([]*NameValueType, error) {
+	var output []*NameValueType
+	for _, field := range typedData.Types[primaryType] {
+		value, err := typedData.formatValue(field.Type, data[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, &NameValueType{Name: field.Name, Value: value, Typ: field.Type})
+	}
+	return output, nil
+}
+
+// formatValue converts a single value into its human readable form.
+func (typedData *TypedData) formatValue(encType string, value interface{}) (interface{}, error) {
+	if match := typedDataArrayRegexp.FindStringSubmatch(encType); match != nil {
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value for type %s: %v", encType, value)
+		}
+		output := make([]interface{}, len(array))
+		for i, item := range array {
+			formatted, err := typedData.formatValue(match[1], item)
+			if err != nil {
+				return nil, err
+			}
+			output[i] = formatted
+		}
+		return output, nil
+	}
+	if _, ok := typedData.Types[encType]; ok {
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value for type %s: %v", encType, value)
+		}
+		return typedData.formatData(encType, mapValue)
+	}
+	switch {
+	case encType == "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address: %v", value)
+		}
+		return common.HexToAddress(str).Hex(), nil
+
+	case typedDataIntegerRegexp.MatchString(encType):
+		integer, err := parseInteger(encType, value)
+		if err != nil {
+			return nil, err
+		}
+		return integer.String(), nil
+
+	case encType == "bytes" || typedDataBytesRegexp.MatchString(encType):
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Encode(blob), nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// Pprint returns a pretty-printed version of the name, type and value, with
+// nested structs and arrays indented by the given depth.
+func (nvt *NameValueType) Pprint(depth int) string {
+	output := bytes.Buffer{}
+	output.WriteString(strings.Repeat(" ", depth*2))
+	output.WriteString(fmt.Sprintf("%s [%s]: ", nvt.Name, nvt.Typ))
+	pprintValue(&output, nvt.Value, depth)
+	return output.String()
+}
+
+// pprintValue writes a formatted value into the output buffer.
+func pprintValue(output *bytes.Buffer, value interface{}, depth int) {
+	switch v := value.(type) {
+	case []*NameValueType:
+		output.WriteString("\n")
+		for _, field := range v {
+			output.WriteString(field.Pprint(depth + 1))
+		}
+	case []interface{}:
+		output.WriteString("\n")
+		for i, item := range v {
+			output.WriteString(strings.Repeat(" ", (depth+1)*2))
+			output.WriteString(fmt.Sprintf("%d: ", i))
+			pprintValue(output, item, depth+1)
+		}
+	default:
+		output.WriteString(fmt.Sprintf("%q\n", v))
+	}
+}
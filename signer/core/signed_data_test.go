@@ -0,0 +1,405 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// jsonTypedData is the example message of the EIP-712 specification.
+const jsonTypedData = `
+{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {
+      "name": "Cow",
+      "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+    },
+    "to": {
+      "name": "Bob",
+      "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"
+    },
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func parseTypedData(t *testing.T, blob string) TypedData {
+	var typedData TypedData
+	if err := json.Unmarshal([]byte(blob), &typedData); err != nil {
+		t.Fatalf("failed to unmarshal typed data: %v", err)
+	}
+	return typedData
+}
+
+// Tests the encoding and hashing steps against the test vectors of the EIP.
+func TestTypedDataVectors(t *testing.T) {
+	typedData := parseTypedData(t, jsonTypedData)
+	if err := typedData.Validate(); err != nil {
+		t.Fatalf("failed to validate typed data: %v", err)
+	}
+	if have, want := string(typedData.EncodeType("Mail")), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("encoded type mismatch: have %s, want %s", have, want)
+	}
+	if have, want := typedData.TypeHash("Mail").String(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; have != want {
+		t.Errorf("type hash mismatch: have %s, want %s", have, want)
+	}
+	encoded, err := typedData.EncodeData(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to encode message: %v", err)
+	}
+	if have, want := encoded.String(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2fc71e5fa27ff56c350aa531bc129ebdf613b772b6604664f5d8dbe21b85eb0c8cd54f074a4af31b4411ff6a60c9719dbd559c221c8ac3492d9d872b041d703d1b5aadf3154a261abdd9086fc627b61efca26ae5702701d05cd2305f7c52a2fc8"; have != want {
+		t.Errorf("encoded data mismatch: have %s, want %s", have, want)
+	}
+	hash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to hash message: %v", err)
+	}
+	if have, want := hash.String(), "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; have != want {
+		t.Errorf("message hash mismatch: have %s, want %s", have, want)
+	}
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	if have, want := domainSeparator.String(), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; have != want {
+		t.Errorf("domain separator mismatch: have %s, want %s", have, want)
+	}
+	sighash, rawData, err := typedData.SignatureHash()
+	if err != nil {
+		t.Fatalf("failed to calculate signature hash: %v", err)
+	}
+	if have, want := sighash.String(), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; have != want {
+		t.Errorf("signature hash mismatch: have %s, want %s", have, want)
+	}
+	if !bytes.Equal(crypto.Keccak256(rawData), sighash) {
+		t.Errorf("signature hash is not the hash of the raw data")
+	}
+	// Sign with the key of the sender, as done by the EIP
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	if addr := crypto.PubkeyToAddress(key.PublicKey); addr != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Fatalf("sender address mismatch: have %x", addr)
+	}
+	sig, err := crypto.Sign(sighash, key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if have, want := hexutil.Encode(sig[:32]), "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"; have != want {
+		t.Errorf("signature r mismatch: have %s, want %s", have, want)
+	}
+	if have, want := hexutil.Encode(sig[32:64]), "0x07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"; have != want {
+		t.Errorf("signature s mismatch: have %s, want %s", have, want)
+	}
+	if have, want := sig[64]+27, byte(28); have != want {
+		t.Errorf("signature v mismatch: have %d, want %d", have, want)
+	}
+}
+
+// Tests that malformed typed data is rejected during validation.
+func TestTypedDataValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*TypedData)
+		err    string
+	}{
+		{"missing domain type", func(td *TypedData) { delete(td.Types, "EIP712Domain") }, `type "EIP712Domain" is not defined`},
+		{"undefined primary type", func(td *TypedData) { td.PrimaryType = "Letter" }, `primary type "Letter" is not defined`},
+		{"undefined field type", func(td *TypedData) { td.Types["Person"][1].Type = "Wallet" }, `unknown type "Wallet"`},
+		{"invalid integer size", func(td *TypedData) { td.Types["EIP712Domain"][2].Type = "uint7" }, `unknown type "uint7"`},
+		{"duplicate field", func(td *TypedData) { td.Types["Person"][1].Name = "name" }, `duplicate field "name"`},
+		{"empty domain", func(td *TypedData) { td.Domain = TypedDataDomain{} }, "domain is undefined"},
+		{"undeclared domain field", func(td *TypedData) { td.Domain.Salt = "0x01" }, `field "salt" is not declared`},
+		{"missing field", func(td *TypedData) { delete(td.Message, "contents") }, `missing value for field "contents"`},
+		{"undeclared field", func(td *TypedData) { td.Message["cc"] = "Alice" }, `field "cc" is not declared`},
+		{"invalid address", func(td *TypedData) {
+			td.Message["to"].(map[string]interface{})["wallet"] = "0xbob"
+		}, "invalid address"},
+		{"invalid struct", func(td *TypedData) { td.Message["to"] = "Bob" }, "invalid value for type Person"},
+		{"negative unsigned", func(td *TypedData) { td.Domain.ChainId = big.NewInt(-1) }, "out of range for uint256"},
+	}
+	for _, tt := range tests {
+		typedData := parseTypedData(t, jsonTypedData)
+		tt.mutate(&typedData)
+
+		err := typedData.Validate()
+		if err == nil {
+			t.Errorf("%s: expected error, got none", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error mismatch: have %q, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// Tests the encoding of integers, fixed size bytes and arrays.
+func TestTypedDataEncodeValues(t *testing.T) {
+	typedData := TypedData{
+		Types: Types{
+			"Item": []Type{{Name: "id", Type: "int8"}},
+		},
+	}
+	tests := []struct {
+		typ   string
+		value interface{}
+		want  string
+		err   bool
+	}{
+		{typ: "uint8", value: float64(255), want: "0x00000000000000000000000000000000000000000000000000000000000000ff"},
+		{typ: "uint8", value: float64(256), err: true},
+		{typ: "uint8", value: float64(1.5), err: true},
+		{typ: "uint64", value: float64(1<<53 - 1), want: "0x000000000000000000000000000000000000000000000000001fffffffffffff"},
+		{typ: "uint64", value: float64(1 << 53), err: true},
+		{typ: "uint256", value: float64(1e30), err: true},
+		{typ: "int64", value: float64(-1 << 53), err: true},
+		{typ: "uint64", value: "9007199254740993", want: "0x0000000000000000000000000000000000000000000000000020000000000001"},
+		{typ: "uint256", value: "0x10", want: "0x0000000000000000000000000000000000000000000000000000000000000010"},
+		{typ: "int8", value: float64(-1), want: "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{typ: "int8", value: "-129", err: true},
+		{typ: "bool", value: true, want: "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{typ: "bytes4", value: "0xdeadbeef", want: "0xdeadbeef00000000000000000000000000000000000000000000000000000000"},
+		{typ: "bytes4", value: "0xdead", err: true},
+		{typ: "bytes", value: "0xdeadbeef", want: hexutil.Encode(crypto.Keccak256(common.FromHex("0xdeadbeef")))},
+		{typ: "uint8[2]", value: []interface{}{float64(1)}, err: true},
+		{typ: "uint8[]", value: []interface{}{float64(1), float64(2)}, want: hexutil.Encode(crypto.Keccak256(
+			common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32),
+		))},
+		{typ: "Item[]", value: []interface{}{map[string]interface{}{"id": float64(-1)}}, want: hexutil.Encode(crypto.Keccak256(
+			crypto.Keccak256(typedData.TypeHash("Item"), common.FromHex("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")),
+		))},
+	}
+	for i, tt := range tests {
+		enc, err := typedData.encodeValue(tt.typ, tt.value)
+		if tt.err {
+			if err == nil {
+				t.Errorf("test %d (%s): expected error, got %x", i, tt.typ, enc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d (%s): unexpected error: %v", i, tt.typ, err)
+			continue
+		}
+		if have := hexutil.Encode(enc); have != tt.want {
+			t.Errorf("test %d (%s): encoding mismatch: have %s, want %s", i, tt.typ, have, tt.want)
+		}
+	}
+}
+
+// Tests that typed data is presented to the UI in readable form.
+func TestTypedDataFormat(t *testing.T) {
+	typedData := parseTypedData(t, jsonTypedData)
+	messages, err := typedData.Format()
+	if err != nil {
+		t.Fatalf("failed to format typed data: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Name != "EIP712Domain" || messages[1].Name != "Mail" {
+		t.Fatalf("unexpected formatted sections: %v", messages)
+	}
+	mail := messages[1].Value.([]*NameValueType)
+	from := mail[0].Value.([]*NameValueType)
+	if have, want := from[1].Value, "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"; have != want {
+		t.Errorf("sender wallet mismatch: have %v, want %v", have, want)
+	}
+	domain := messages[0].Value.([]*NameValueType)
+	if have, want := domain[2].Value, "1"; have != want {
+		t.Errorf("chain id mismatch: have %v, want %v", have, want)
+	}
+	output := messages[1].Pprint(0)
+	for _, want := range []string{"Mail [primary type]", "contents [string]: \"Hello, Bob!\""} {
+		if !strings.Contains(output, want) {
+			t.Errorf("pretty print missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestSignTypedData(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+	typedData := parseTypedData(t, jsonTypedData)
+
+	control <- "No way"
+	sig, err := api.SignTypedData(context.Background(), a, typedData)
+	if sig != nil || err != ErrRequestDenied {
+		t.Errorf("Expected ErrRequestDenied, got %x %v", sig, err)
+	}
+	control <- "Y"
+	control <- "a_long_password"
+	sig, err = api.SignTypedData(context.Background(), a, typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != 65 {
+		t.Fatalf("Expected 65 byte signature (got %d bytes)", len(sig))
+	}
+	sighash, _, _ := typedData.SignatureHash()
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(sighash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != a.Address() {
+		t.Errorf("Signer mismatch: have %x, want %x", signer, a.Address())
+	}
+	// Typed data for another chain must be rejected before reaching the UI
+	typedData.Domain.ChainId = big.NewInt(2)
+	if _, err := api.SignTypedData(context.Background(), a, typedData); err == nil || !strings.Contains(err.Error(), "chain id mismatch") {
+		t.Errorf("Expected chain id mismatch, got %v", err)
+	}
+}
+
+// Tests that data of the supported content types is hashed as expected.
+func TestSignatureFormats(t *testing.T) {
+	api, _ := setup(t)
+
+	// Plain text is signed as a personal message
+	req, legacyV, err := api.determineSignatureFormat("text/plain; charset=utf-8", "0xaabbccdd")
+	if err != nil {
+		t.Fatalf("text/plain: unexpected error: %v", err)
+	}
+	if hash, _ := SignHash(common.FromHex("0xaabbccdd")); !bytes.Equal(req.Hash, hash) || !legacyV {
+		t.Errorf("text/plain: hash mismatch: have %x/%v, want %x/true", req.Hash, legacyV, hash)
+	}
+	// Validator data is hashed as defined by EIP-191, regardless of its form
+	validator := common.HexToAddress("0x1923f626bb8dc025849e00f99c25fe2b2f7fb0db")
+	want := crypto.Keccak256([]byte{0x19, 0x00}, validator.Bytes(), []byte("hello"))
+
+	for _, data := range []interface{}{
+		map[string]interface{}{"address": validator.Hex(), "message": hexutil.Encode([]byte("hello"))},
+		hexutil.Encode(append(validator.Bytes(), []byte("hello")...)),
+	} {
+		req, legacyV, err := api.determineSignatureFormat("data/validator", data)
+		if err != nil {
+			t.Fatalf("data/validator: unexpected error: %v", err)
+		}
+		if !bytes.Equal(req.Hash, want) || !legacyV {
+			t.Errorf("data/validator: hash mismatch: have %x/%v, want %x/true", req.Hash, legacyV, want)
+		}
+	}
+	// Clique headers are hashed with the clique signature hash
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Number:     big.NewInt(1337),
+		Difficulty: big.NewInt(2),
+		GasLimit:   4712388,
+		Time:       big.NewInt(1545000000),
+		Extra:      make([]byte, 32+65),
+	}
+	req, legacyV, err = api.determineSignatureFormat("application/x-clique-header", hexutil.Encode(clique.CliqueRLP(header)))
+	if err != nil {
+		t.Fatalf("clique: unexpected error: %v", err)
+	}
+	if hash := clique.SigHash(header); !bytes.Equal(req.Hash, hash.Bytes()) || legacyV {
+		t.Errorf("clique: hash mismatch: have %x/%v, want %x/false", req.Hash, legacyV, hash)
+	}
+	if len(req.Messages) == 0 || req.Messages[0].Name != "number" || req.Messages[0].Value != "1337" {
+		t.Errorf("clique: header not decoded for display: %v", req.Messages)
+	}
+	// Invalid data and unknown content types are rejected
+	failures := []struct {
+		contentType string
+		data        interface{}
+	}{
+		{"application/x-clique-header", "0xdeadbeef"},
+		{"application/x-clique-header", hexutil.Encode(append(clique.CliqueRLP(header), 0x00))},
+		{"data/validator", "0x1234"},
+		{"data/validator", map[string]interface{}{"address": "0xbob", "message": "0x"}},
+		{"text/plain", "hello"},
+		{"application/json", "0x"},
+	}
+	for i, tt := range failures {
+		if _, _, err := api.determineSignatureFormat(tt.contentType, tt.data); err == nil {
+			t.Errorf("test %d (%s): expected error", i, tt.contentType)
+		}
+	}
+}
+
+func TestSignCliqueHeader(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(2),
+		Time:       big.NewInt(1545000000),
+		Extra:      make([]byte, 32+65),
+	}
+	control <- "Y"
+	control <- "a_long_password"
+	sig, err := api.SignData(context.Background(), "application/x-clique-header", a, hexutil.Encode(clique.CliqueRLP(header)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clique expects V in 0/1 form, the header needs to be recoverable as is
+	if sig[64] > 1 {
+		t.Fatalf("Expected raw recovery id, got V=%d", sig[64])
+	}
+	pubkey, err := crypto.Ecrecover(clique.SigHash(header).Bytes(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	if signer != a.Address() {
+		t.Errorf("Signer mismatch: have %x, want %x", signer, a.Address())
+	}
+}
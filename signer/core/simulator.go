@@ -0,0 +1,206 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// transferTopic is the topic of the Transfer event of both ERC20 and ERC721 tokens.
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// revertSelector is the selector of the Error(string) revert reason.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+)
+
+// Simulation is the outcome of a transaction executed on top of the latest block
+// of a node, presented to the UI before approval.
+type Simulation struct {
+	Failed         bool             `json:"failed"`
+	RevertReason   string           `json:"revert_reason,omitempty"`
+	GasUsed        hexutil.Uint64   `json:"gas_used"`
+	Transfers      []*TokenTransfer `json:"transfers"`
+	BalanceChanges []*BalanceChange `json:"balance_changes"`
+}
+
+// TokenTransfer is a Transfer event emitted by an ERC20 or ERC721 token.
+type TokenTransfer struct {
+	Token   common.Address `json:"token"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`    // Amount of ERC20 tokens transferred
+	TokenID *hexutil.Big   `json:"token_id,omitempty"` // Identifier of the ERC721 token transferred
+}
+
+// BalanceChange is the ether balance of an account before and after a transaction.
+type BalanceChange struct {
+	Address common.Address `json:"address"`
+	Before  *hexutil.Big   `json:"before"`
+	After   *hexutil.Big   `json:"after"`
+}
+
+// String implements fmt.Stringer.
+func (s *Simulation) String() string {
+	var b bytes.Buffer
+	if s.Failed {
+		fmt.Fprintf(&b, "  * outcome : reverted")
+		if s.RevertReason != "" {
+			fmt.Fprintf(&b, " (%q)", s.RevertReason)
+		}
+		fmt.Fprintln(&b)
+	} else {
+		fmt.Fprintf(&b, "  * outcome : success\n")
+	}
+	fmt.Fprintf(&b, "  * gas used: %d\n", uint64(s.GasUsed))
+	for _, t := range s.Transfers {
+		if t.TokenID != nil {
+			fmt.Fprintf(&b, "  * token %s: NFT #%v from %s to %s\n", t.Token.Hex(), t.TokenID.ToInt(), t.From.Hex(), t.To.Hex())
+		} else {
+			fmt.Fprintf(&b, "  * token %s: %v from %s to %s\n", t.Token.Hex(), t.Value.ToInt(), t.From.Hex(), t.To.Hex())
+		}
+	}
+	for _, c := range s.BalanceChanges {
+		diff := new(big.Int).Sub(c.After.ToInt(), c.Before.ToInt())
+		fmt.Fprintf(&b, "  * balance %s: %v -> %v wei (%+d)\n", c.Address.Hex(), c.Before.ToInt(), c.After.ToInt(), diff)
+	}
+	return b.String()
+}
+
+// simulationResult is the raw simulation result returned by debug_simulateCall.
+type simulationResult struct {
+	Gas            hexutil.Uint64 `json:"gas"`
+	Failed         bool           `json:"failed"`
+	ReturnValue    hexutil.Bytes  `json:"returnValue"`
+	Logs           []*types.Log   `json:"logs"`
+	BalanceChanges map[common.Address]*struct {
+		Before *hexutil.Big `json:"before"`
+		After  *hexutil.Big `json:"after"`
+	} `json:"balanceChanges"`
+}
+
+// Simulator previews transactions by executing them on a node, which needs to
+// expose the debug API (debug_simulateCall).
+type Simulator struct {
+	client *rpc.Client
+}
+
+// NewSimulator connects to the node at the given endpoint, which is either an
+// IPC path or an HTTP or WebSocket URL.
+func NewSimulator(endpoint string) (*Simulator, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &Simulator{client: client}, nil
+}
+
+// Simulate executes the transaction on top of the latest block of the node,
+// returning its outcome along with the token transfers and ether balance
+// changes it would cause.
+func (s *Simulator) Simulate(ctx context.Context, args *SendTxArgs) (*Simulation, error) {
+	call := map[string]interface{}{
+		"from":     args.From.Address(),
+		"gas":      args.Gas,
+		"gasPrice": &args.GasPrice,
+		"value":    &args.Value,
+	}
+	if args.To != nil {
+		call["to"] = args.To.Address()
+	}
+	if args.Input != nil {
+		call["data"] = args.Input
+	} else if args.Data != nil {
+		call["data"] = args.Data
+	}
+	var res simulationResult
+	if err := s.client.CallContext(ctx, &res, "debug_simulateCall", call, "latest"); err != nil {
+		return nil, err
+	}
+	sim := &Simulation{
+		Failed:         res.Failed,
+		GasUsed:        res.Gas,
+		Transfers:      []*TokenTransfer{},
+		BalanceChanges: []*BalanceChange{},
+	}
+	if res.Failed {
+		sim.RevertReason = parseRevertReason(res.ReturnValue)
+	}
+	for _, log := range res.Logs {
+		if transfer := parseTransfer(log); transfer != nil {
+			sim.Transfers = append(sim.Transfers, transfer)
+		}
+	}
+	for addr, change := range res.BalanceChanges {
+		sim.BalanceChanges = append(sim.BalanceChanges, &BalanceChange{Address: addr, Before: change.Before, After: change.After})
+	}
+	sort.Slice(sim.BalanceChanges, func(i, j int) bool {
+		return bytes.Compare(sim.BalanceChanges[i].Address[:], sim.BalanceChanges[j].Address[:]) < 0
+	})
+	return sim, nil
+}
+
+// parseRevertReason decodes the reason string of a reverted call, returning the
+// empty string if the return data is not an Error(string).
+func parseRevertReason(ret []byte) string {
+	if len(ret) < 4 || !bytes.Equal(ret[:4], revertSelector) {
+		return ""
+	}
+	typ, err := abi.NewType("string", nil)
+	if err != nil {
+		return ""
+	}
+	var reason string
+	if err := (abi.Arguments{{Type: typ}}).Unpack(&reason, ret[4:]); err != nil {
+		return ""
+	}
+	return reason
+}
+
+// parseTransfer decodes an ERC20 or ERC721 Transfer event, returning nil for
+// any other log. The standards share the event signature, but ERC721 indexes
+// the token identifier, whereas ERC20 stores the amount in the data.
+func parseTransfer(log *types.Log) *TokenTransfer {
+	if len(log.Topics) < 3 || log.Topics[0] != transferTopic {
+		return nil
+	}
+	transfer := &TokenTransfer{
+		Token: log.Address,
+		From:  common.BytesToAddress(log.Topics[1][:]),
+		To:    common.BytesToAddress(log.Topics[2][:]),
+	}
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == 32:
+		transfer.Value = (*hexutil.Big)(new(big.Int).SetBytes(log.Data))
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		transfer.TokenID = (*hexutil.Big)(log.Topics[3].Big())
+	default:
+		return nil
+	}
+	return transfer
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// MockNode is a node exposing a canned debug_simulateCall result.
+type MockNode struct {
+	calls  []map[string]interface{}
+	result string
+}
+
+func (n *MockNode) SimulateCall(args map[string]interface{}, block string) (json.RawMessage, error) {
+	n.calls = append(n.calls, args)
+	return json.RawMessage(n.result), nil
+}
+
+func newTestSimulator(t *testing.T, result string) (*Simulator, *MockNode, func()) {
+	node := &MockNode{result: result}
+	server := rpc.NewServer()
+	if err := server.RegisterName("debug", node); err != nil {
+		t.Fatalf("failed to register node service: %v", err)
+	}
+	endpoint := httptest.NewServer(server)
+
+	sim, err := NewSimulator(endpoint.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to node: %v", err)
+	}
+	return sim, node, func() {
+		endpoint.Close()
+		server.Stop()
+	}
+}
+
+// Tests that simulation results are decoded into token transfers and sorted
+// balance changes.
+func TestSimulateTransfers(t *testing.T) {
+	var (
+		token  = "0x000000000000000000000000000000000000c0de"
+		from   = "0x000000000000000000000000000000000000000000000000000000000000aaaa"
+		to     = "0x000000000000000000000000000000000000000000000000000000000000bbbb"
+		result = `{
+			"gas": "0x5208",
+			"failed": false,
+			"returnValue": "0x",
+			"logs": [
+				{"address": "` + token + `", "topics": ["` + transferTopic.Hex() + `", "` + from + `", "` + to + `"], "data": "0x00000000000000000000000000000000000000000000000000000000000003e8", "blockNumber": "0x0", "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "transactionIndex": "0x0", "blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "logIndex": "0x0", "removed": false},
+				{"address": "` + token + `", "topics": ["` + transferTopic.Hex() + `", "` + from + `", "` + to + `", "0x000000000000000000000000000000000000000000000000000000000000002a"], "data": "0x", "blockNumber": "0x0", "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "transactionIndex": "0x0", "blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "logIndex": "0x1", "removed": false},
+				{"address": "` + token + `", "topics": ["` + from + `"], "data": "0x", "blockNumber": "0x0", "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "transactionIndex": "0x0", "blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "logIndex": "0x2", "removed": false}
+			],
+			"balanceChanges": {
+				"0x000000000000000000000000000000000000bbbb": {"before": "0x0", "after": "0x64"},
+				"0x000000000000000000000000000000000000aaaa": {"before": "0x3e8", "after": "0x384"}
+			}
+		}`
+	)
+	simulator, node, teardown := newTestSimulator(t, result)
+	defer teardown()
+
+	to2 := common.NewMixedcaseAddress(common.HexToAddress(token))
+	data := hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb}
+	args := &SendTxArgs{
+		From:  common.NewMixedcaseAddress(common.HexToAddress("0xaaaa")),
+		To:    &to2,
+		Gas:   21000,
+		Value: hexutil.Big(*big.NewInt(100)),
+		Data:  &data,
+	}
+	sim, err := simulator.Simulate(context.Background(), args)
+	if err != nil {
+		t.Fatalf("failed to simulate transaction: %v", err)
+	}
+	if len(node.calls) != 1 || node.calls[0]["data"] != "0xa9059cbb" || node.calls[0]["to"] != token {
+		t.Errorf("call arguments mismatch: %v", node.calls)
+	}
+	if sim.Failed || uint64(sim.GasUsed) != 21000 {
+		t.Errorf("outcome mismatch: failed %v, gas %d", sim.Failed, sim.GasUsed)
+	}
+	if len(sim.Transfers) != 2 {
+		t.Fatalf("transfer count mismatch: have %d, want 2", len(sim.Transfers))
+	}
+	if erc20 := sim.Transfers[0]; erc20.Value == nil || erc20.Value.ToInt().Int64() != 1000 || erc20.TokenID != nil || erc20.From != common.HexToAddress("0xaaaa") || erc20.To != common.HexToAddress("0xbbbb") {
+		t.Errorf("ERC20 transfer mismatch: %+v", erc20)
+	}
+	if erc721 := sim.Transfers[1]; erc721.TokenID == nil || erc721.TokenID.ToInt().Int64() != 42 || erc721.Value != nil {
+		t.Errorf("ERC721 transfer mismatch: %+v", erc721)
+	}
+	if len(sim.BalanceChanges) != 2 || sim.BalanceChanges[0].Address != common.HexToAddress("0xaaaa") || sim.BalanceChanges[1].After.ToInt().Int64() != 100 {
+		t.Errorf("balance changes mismatch: %v", sim.BalanceChanges)
+	}
+}
+
+// Tests that the revert reasons of failed transactions are decoded.
+func TestSimulateRevert(t *testing.T) {
+	reason := append(append(append(common.CopyBytes(revertSelector), math.PaddedBigBytes(big.NewInt(32), 32)...), math.PaddedBigBytes(big.NewInt(4), 32)...), common.RightPadBytes([]byte("nope"), 32)...)
+	result := `{"gas": "0x6000", "failed": true, "returnValue": "` + hexutil.Encode(reason) + `", "logs": [], "balanceChanges": {}}`
+
+	simulator, _, teardown := newTestSimulator(t, result)
+	defer teardown()
+
+	sim, err := simulator.Simulate(context.Background(), &SendTxArgs{From: common.NewMixedcaseAddress(common.Address{1})})
+	if err != nil {
+		t.Fatalf("failed to simulate transaction: %v", err)
+	}
+	if !sim.Failed || sim.RevertReason != "nope" {
+		t.Errorf("revert mismatch: failed %v, reason %q", sim.Failed, sim.RevertReason)
+	}
+	if reason := parseRevertReason([]byte{0xde, 0xad, 0xbe, 0xef}); reason != "" {
+		t.Errorf("unexpected reason for custom revert data: %q", reason)
+	}
+}
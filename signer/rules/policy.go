@@ -0,0 +1,336 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+// Policy is a declarative set of limits on the transactions that may be approved
+// without user interaction. Requests within the policy are passed on to the rule
+// engine, whereas requests violating it are always sent to the user for manual
+// approval. Unset limits are not enforced, so an empty policy permits everything.
+type Policy struct {
+	MaxGasPrice *math.HexOrDecimal256               `json:"maxGasPrice,omitempty"` // Maximum gas price of a transaction
+	DailyLimit  *math.HexOrDecimal256               `json:"dailyLimit,omitempty"`  // Maximum value an account may send per day
+	Recipients  map[common.Address]*RecipientPolicy `json:"recipients,omitempty"`  // Allowed recipients, nil permits any (including contract creation)
+}
+
+// RecipientPolicy is the set of limits on the transactions sent to a particular
+// recipient.
+type RecipientPolicy struct {
+	DailyLimit *math.HexOrDecimal256 `json:"dailyLimit,omitempty"` // Maximum value an account may send to the recipient per day
+	Methods    []hexutil.Bytes       `json:"methods,omitempty"`    // Allowed 4-byte method selectors, empty permits any call data
+}
+
+// ParsePolicy parses and validates a JSON encoded signing policy.
+func ParsePolicy(blob []byte) (*Policy, error) {
+	policy := new(Policy)
+	if err := json.Unmarshal(blob, policy); err != nil {
+		return nil, err
+	}
+	for addr, recipient := range policy.Recipients {
+		if recipient == nil {
+			return nil, fmt.Errorf("recipient %s: missing policy", addr.Hex())
+		}
+		for _, method := range recipient.Methods {
+			if len(method) != 4 {
+				return nil, fmt.Errorf("recipient %s: invalid method selector %x", addr.Hex(), method)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// spending is the value sent by an account on a particular day, persisted across
+// restarts in the policy storage.
+type spending struct {
+	Day        string                      `json:"day"`
+	Total      *big.Int                    `json:"total"`
+	Recipients map[common.Address]*big.Int `json:"recipients"`
+}
+
+// reservation is the value of a compliant transaction awaiting approval, which
+// counts towards the daily limits until the request is settled.
+type reservation struct {
+	from  common.Address
+	to    *common.Address
+	value *big.Int
+}
+
+// policyUI provides an implementation of SignerUI that checks transactions against
+// a declarative policy before handing them to the next handler, keeping track of
+// the value sent by each account per day.
+type policyUI struct {
+	core.SignerUI // The next handler, for requests within the policy
+
+	manual  core.SignerUI   // The handler for manual processing of policy violations
+	policy  *Policy         // The policy to enforce
+	storage storage.Storage // Storage for the daily spending counters
+	audit   log.Logger      // Audit log to record the policy decisions to
+
+	now     func() time.Time // Source of the current time, replaceable in tests
+	pending []*reservation   // Value reserved by compliant requests awaiting approval
+	lock    sync.Mutex       // Lock protecting the spending counters and reservations
+}
+
+// NewPolicyEvaluator creates a SignerUI enforcing the given policy. Transactions
+// within the policy are forwarded to next (usually the rule engine), violations
+// to manual. All other requests are forwarded to next unchanged.
+func NewPolicyEvaluator(next, manual core.SignerUI, policy *Policy, backend storage.Storage, audit log.Logger) *policyUI {
+	if audit == nil {
+		audit = log.New()
+		audit.SetHandler(log.DiscardHandler())
+	}
+	return &policyUI{
+		SignerUI: next,
+		manual:   manual,
+		policy:   policy,
+		storage:  backend,
+		audit:    audit,
+		now:      time.Now,
+	}
+}
+
+// ApproveTx checks the transaction against the policy, forwarding it to the next
+// handler if it complies, or to manual processing along with a warning if not.
+//
+// The value of a compliant transaction is reserved until its approval is decided,
+// so concurrent requests cannot jointly exceed the daily limits. The value of any
+// approved transaction is recorded before it is signed; if that fails, the request
+// is refused instead of signing a transaction the limits don't account for.
+func (p *policyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	var (
+		result core.SignTxResponse
+		err    error
+	)
+	reserved, violation := p.reserve(&request.Transaction)
+	if violation != nil {
+		log.Info("Transaction violates signing policy, going to manual", "err", violation)
+		p.audit.Info("Policy", "type", "violation", "tx", request.Transaction.String(), "err", violation)
+
+		request.Callinfo = append(request.Callinfo, core.ValidationInfo{
+			Typ:     core.WARN,
+			Message: fmt.Sprintf("Transaction violates signing policy: %v", violation),
+		})
+		result, err = p.manual.ApproveTx(request)
+	} else {
+		p.audit.Info("Policy", "type", "compliant", "tx", request.Transaction.String())
+		result, err = p.SignerUI.ApproveTx(request)
+	}
+	var approved *core.SendTxArgs
+	if err == nil && result.Approved {
+		approved = &result.Transaction
+	}
+	if err := p.settle(reserved, approved); err != nil {
+		log.Error("Failed to record spending, refusing transaction", "err", err)
+		p.audit.Info("Policy", "type", "failure", "tx", result.Transaction.String(), "err", err)
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: false}, fmt.Errorf("failed to record spending: %v", err)
+	}
+	return result, err
+}
+
+// reserve verifies that a transaction complies with the policy and, if so,
+// reserves its value towards the daily limits of its sender.
+func (p *policyUI) reserve(args *core.SendTxArgs) (*reservation, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.check(args); err != nil {
+		return nil, err
+	}
+	reserved := &reservation{
+		from:  args.From.Address(),
+		value: new(big.Int).Set(args.Value.ToInt()),
+	}
+	if args.To != nil {
+		to := args.To.Address()
+		reserved.to = &to
+	}
+	p.pending = append(p.pending, reserved)
+	return reserved, nil
+}
+
+// settle releases the reservation of a request whose approval has been decided
+// (if any), recording the value of the transaction if it was approved.
+func (p *policyUI) settle(reserved *reservation, approved *core.SendTxArgs) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i, r := range p.pending {
+		if r == reserved {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			break
+		}
+	}
+	if approved == nil {
+		return nil
+	}
+	return p.record(approved)
+}
+
+// check verifies that a transaction complies with the policy, taking into account
+// the value already sent by the sender today, as well as the value reserved by
+// requests still awaiting approval. The caller must hold the lock.
+func (p *policyUI) check(args *core.SendTxArgs) error {
+	if p.policy.MaxGasPrice != nil && args.GasPrice.ToInt().Cmp((*big.Int)(p.policy.MaxGasPrice)) > 0 {
+		return fmt.Errorf("gas price %v exceeds limit %v", args.GasPrice.ToInt(), (*big.Int)(p.policy.MaxGasPrice))
+	}
+	var recipient *RecipientPolicy
+	if p.policy.Recipients != nil {
+		if args.To == nil {
+			return fmt.Errorf("contract creation not permitted")
+		}
+		if recipient = p.policy.Recipients[args.To.Address()]; recipient == nil {
+			return fmt.Errorf("recipient %s not permitted", args.To.Address().Hex())
+		}
+		if len(recipient.Methods) > 0 {
+			if err := checkMethod(args, recipient.Methods); err != nil {
+				return err
+			}
+		}
+	}
+	if p.policy.DailyLimit == nil && (recipient == nil || recipient.DailyLimit == nil) {
+		return nil
+	}
+	from := args.From.Address()
+	spent, err := p.load(from)
+	if err != nil {
+		return err
+	}
+	for _, r := range p.pending {
+		if r.from == from {
+			spent.add(r.to, r.value)
+		}
+	}
+	value := args.Value.ToInt()
+	if p.policy.DailyLimit != nil {
+		if total := new(big.Int).Add(spent.Total, value); total.Cmp((*big.Int)(p.policy.DailyLimit)) > 0 {
+			return fmt.Errorf("daily limit %v exceeded, already sent or pending %v", (*big.Int)(p.policy.DailyLimit), spent.Total)
+		}
+	}
+	if recipient != nil && recipient.DailyLimit != nil {
+		sent := spent.Recipients[args.To.Address()]
+		if sent == nil {
+			sent = new(big.Int)
+		}
+		if total := new(big.Int).Add(sent, value); total.Cmp((*big.Int)(recipient.DailyLimit)) > 0 {
+			return fmt.Errorf("daily limit %v of recipient %s exceeded, already sent or pending %v", (*big.Int)(recipient.DailyLimit), args.To.Address().Hex(), sent)
+		}
+	}
+	return nil
+}
+
+// checkMethod verifies that the call data of a transaction invokes one of the
+// permitted methods.
+func checkMethod(args *core.SendTxArgs, methods []hexutil.Bytes) error {
+	var data []byte
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+	if len(data) < 4 {
+		return fmt.Errorf("call data without method selector not permitted")
+	}
+	for _, method := range methods {
+		if bytes.Equal(data[:4], method) {
+			return nil
+		}
+	}
+	return fmt.Errorf("method %x not permitted", data[:4])
+}
+
+// record adds the value of an approved transaction to the spending counters of
+// its sender. The caller must hold the lock.
+func (p *policyUI) record(args *core.SendTxArgs) error {
+	from := args.From.Address()
+
+	spent, err := p.load(from)
+	if err != nil {
+		return err
+	}
+	var to *common.Address
+	if args.To != nil {
+		addr := args.To.Address()
+		to = &addr
+	}
+	spent.add(to, args.Value.ToInt())
+
+	blob, err := json.Marshal(spent)
+	if err != nil {
+		return err
+	}
+	// The storage swallows write errors, so read the record back to make sure it
+	// was actually persisted
+	key := spendingKey(from)
+	p.storage.Put(key, string(blob))
+	if p.storage.Get(key) != string(blob) {
+		return errors.New("spending record not persisted")
+	}
+	p.audit.Info("Policy", "type", "spending", "from", from.Hex(), "value", args.Value.ToInt(), "day", spent.Day, "total", spent.Total)
+	return nil
+}
+
+// load retrieves the spending counters of an account for the current day.
+func (p *policyUI) load(from common.Address) (*spending, error) {
+	today := p.now().UTC().Format("2006-01-02")
+
+	spent := new(spending)
+	if blob := p.storage.Get(spendingKey(from)); blob != "" {
+		if err := json.Unmarshal([]byte(blob), spent); err != nil {
+			return nil, fmt.Errorf("corrupt spending record: %v", err)
+		}
+	}
+	if spent.Day != today || spent.Total == nil {
+		spent = &spending{Day: today, Total: new(big.Int)}
+	}
+	if spent.Recipients == nil {
+		spent.Recipients = make(map[common.Address]*big.Int)
+	}
+	return spent, nil
+}
+
+// add counts the value sent to a recipient (nil for contract creation).
+func (s *spending) add(to *common.Address, value *big.Int) {
+	s.Total.Add(s.Total, value)
+	if to != nil {
+		if s.Recipients[*to] == nil {
+			s.Recipients[*to] = new(big.Int)
+		}
+		s.Recipients[*to].Add(s.Recipients[*to], value)
+	}
+}
+
+// spendingKey is the storage key of the spending counters of an account.
+func spendingKey(from common.Address) string {
+	return "spending-" + strings.ToLower(from.Hex())
+}
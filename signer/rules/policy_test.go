@@ -0,0 +1,263 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+const testPolicy = `{
+	"maxGasPrice": "0x4a817c800",
+	"dailyLimit": "1000",
+	"recipients": {
+		"0x0000000000000000000000000000000000001111": {},
+		"0x0000000000000000000000000000000000002222": {
+			"dailyLimit": "300",
+			"methods": ["0xa9059cbb"]
+		}
+	}
+}`
+
+var (
+	policyKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	policyFrom   = crypto.PubkeyToAddress(policyKey.PublicKey)
+	policyPlain  = common.HexToAddress("0x0000000000000000000000000000000000001111")
+	policyToken  = common.HexToAddress("0x0000000000000000000000000000000000002222")
+)
+
+// newPolicyTx creates a signing request for a transaction from the test account.
+func newPolicyTx(to *common.Address, value int64, gasPrice int64, data []byte) *core.SignTxRequest {
+	args := core.SendTxArgs{
+		From:     common.NewMixedcaseAddress(policyFrom),
+		Gas:      21000,
+		GasPrice: hexutil.Big(*big.NewInt(gasPrice)),
+		Value:    hexutil.Big(*big.NewInt(value)),
+	}
+	if to != nil {
+		addr := common.NewMixedcaseAddress(*to)
+		args.To = &addr
+	}
+	if data != nil {
+		input := hexutil.Bytes(data)
+		args.Data = &input
+	}
+	return &core.SignTxRequest{Transaction: args}
+}
+
+// approvingUI is a handler approving all transactions unchanged. If entered is
+// set, each approval is announced on it and then blocks until released.
+type approvingUI struct {
+	dummyUI
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (u *approvingUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	if u.entered != nil {
+		u.entered <- struct{}{}
+		<-u.release
+	}
+	return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+}
+
+// failingStorage is a storage silently dropping all writes.
+type failingStorage struct{}
+
+func (failingStorage) Put(key, value string) {}
+func (failingStorage) Get(key string) string { return "" }
+
+func newTestPolicy(t *testing.T, backend storage.Storage) (*policyUI, *dummyUI, *dummyUI) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	next, manual := new(dummyUI), new(dummyUI)
+	return NewPolicyEvaluator(next, manual, policy, backend, nil), next, manual
+}
+
+// Tests that invalid policies are rejected.
+func TestPolicyParse(t *testing.T) {
+	if _, err := ParsePolicy([]byte(`{"recipients": {"0x0000000000000000000000000000000000001111": {"methods": ["0xa9059c"]}}}`)); err == nil {
+		t.Errorf("short method selector accepted")
+	}
+	if _, err := ParsePolicy([]byte(`{"recipients": {"0x0000000000000000000000000000000000001111": null}}`)); err == nil {
+		t.Errorf("missing recipient policy accepted")
+	}
+	if _, err := ParsePolicy([]byte(`{"dailyLimit": "lots"}`)); err == nil {
+		t.Errorf("invalid daily limit accepted")
+	}
+	policy, err := ParsePolicy([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("empty policy rejected: %v", err)
+	}
+	evaluator := NewPolicyEvaluator(new(dummyUI), new(dummyUI), policy, storage.NewEphemeralStorage(), nil)
+	if err := evaluator.check(&newPolicyTx(nil, 1000000, 1000000000000, nil).Transaction); err != nil {
+		t.Errorf("empty policy enforced limits: %v", err)
+	}
+}
+
+// Tests that compliant transactions are forwarded to the next handler, whereas
+// violations are sent for manual approval along with a warning.
+func TestPolicyForwarding(t *testing.T) {
+	tests := []struct {
+		request   *core.SignTxRequest
+		compliant bool
+	}{
+		{newPolicyTx(&policyPlain, 100, 1, nil), true},
+		{newPolicyTx(&policyToken, 100, 1, common.FromHex("0xa9059cbb00")), true},
+		{newPolicyTx(&policyPlain, 100, 20000000001, nil), false},                  // gas price too high
+		{newPolicyTx(&common.Address{}, 100, 1, nil), false},                       // unknown recipient
+		{newPolicyTx(nil, 0, 1, common.FromHex("0x6000")), false},                  // contract creation
+		{newPolicyTx(&policyToken, 100, 1, common.FromHex("0x095ea7b300")), false}, // method not permitted
+		{newPolicyTx(&policyToken, 100, 1, nil), false},                            // method missing
+		{newPolicyTx(&policyToken, 301, 1, common.FromHex("0xa9059cbb")), false},   // recipient limit
+		{newPolicyTx(&policyPlain, 1001, 1, nil), false},                           // daily limit
+	}
+	for i, tt := range tests {
+		evaluator, next, manual := newTestPolicy(t, storage.NewEphemeralStorage())
+		evaluator.ApproveTx(tt.request)
+
+		if tt.compliant {
+			if len(next.calls) != 1 || len(manual.calls) != 0 {
+				t.Errorf("test %d: compliant transaction not forwarded: next %v, manual %v", i, next.calls, manual.calls)
+			}
+			continue
+		}
+		if len(next.calls) != 0 || len(manual.calls) != 1 {
+			t.Errorf("test %d: violation not sent to manual approval: next %v, manual %v", i, next.calls, manual.calls)
+		}
+		if n := len(tt.request.Callinfo); n != 1 || tt.request.Callinfo[0].Typ != core.WARN {
+			t.Errorf("test %d: violation warning missing: %v", i, tt.request.Callinfo)
+		}
+	}
+}
+
+// Tests that the value of approved transactions is counted towards the daily
+// limits, that the counters persist across restarts, and that they are reset on
+// the next day.
+func TestPolicyDailyLimit(t *testing.T) {
+	backend := storage.NewEphemeralStorage()
+	now := time.Date(2018, 11, 1, 23, 0, 0, 0, time.UTC)
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	next, manual := new(approvingUI), new(approvingUI)
+	evaluator := NewPolicyEvaluator(next, manual, policy, backend, nil)
+	evaluator.now = func() time.Time { return now }
+
+	if res, err := evaluator.ApproveTx(newPolicyTx(&policyToken, 250, 1, common.FromHex("0xa9059cbb"))); err != nil || !res.Approved {
+		t.Fatalf("compliant transaction not approved: %v", err)
+	}
+	if res, err := evaluator.ApproveTx(newPolicyTx(&policyPlain, 600, 1, nil)); err != nil || !res.Approved {
+		t.Fatalf("compliant transaction not approved: %v", err)
+	}
+	// Rejected transactions must not count towards the limits
+	denier, _, _ := newTestPolicy(t, backend)
+	denier.now = func() time.Time { return now }
+	denier.ApproveTx(newPolicyTx(&policyPlain, 100, 1, nil))
+
+	// Restart the evaluator and check the limits against the persisted counters
+	evaluator, _, _ = newTestPolicy(t, backend)
+	evaluator.now = func() time.Time { return now }
+
+	if err := evaluator.check(&newPolicyTx(&policyToken, 50, 1, common.FromHex("0xa9059cbb")).Transaction); err != nil {
+		t.Errorf("transaction within recipient limit rejected: %v", err)
+	}
+	if err := evaluator.check(&newPolicyTx(&policyToken, 51, 1, common.FromHex("0xa9059cbb")).Transaction); err == nil {
+		t.Errorf("transaction exceeding recipient limit accepted")
+	}
+	if err := evaluator.check(&newPolicyTx(&policyPlain, 150, 1, nil).Transaction); err != nil {
+		t.Errorf("transaction within daily limit rejected: %v", err)
+	}
+	if err := evaluator.check(&newPolicyTx(&policyPlain, 151, 1, nil).Transaction); err == nil {
+		t.Errorf("transaction exceeding daily limit accepted")
+	}
+	if len(evaluator.pending) != 0 || len(denier.pending) != 0 {
+		t.Errorf("reservations not released: %d, %d", len(evaluator.pending), len(denier.pending))
+	}
+	// Move to the next day and check that the limits are reset
+	now = now.Add(time.Hour)
+	if err := evaluator.check(&newPolicyTx(&policyPlain, 1000, 1, nil).Transaction); err != nil {
+		t.Errorf("transaction within reset daily limit rejected: %v", err)
+	}
+}
+
+// Tests that concurrent requests cannot jointly exceed the daily limits: the value
+// of a request awaiting approval is reserved, and released if it is rejected.
+func TestPolicyConcurrentLimit(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	next := &approvingUI{entered: make(chan struct{}), release: make(chan struct{})}
+	manual := new(dummyUI)
+	evaluator := NewPolicyEvaluator(next, manual, policy, storage.NewEphemeralStorage(), nil)
+
+	// Start approving a transaction within the limit, but block before deciding
+	done := make(chan error)
+	go func() {
+		res, err := evaluator.ApproveTx(newPolicyTx(&policyPlain, 900, 1, nil))
+		if err == nil && !res.Approved {
+			err = core.ErrRequestDenied
+		}
+		done <- err
+	}()
+	<-next.entered
+
+	// A second transaction is only within the limit on its own, so must go to manual
+	evaluator.ApproveTx(newPolicyTx(&policyPlain, 900, 1, nil))
+	if len(manual.calls) != 1 {
+		t.Fatalf("concurrent transaction exceeding limit not sent to manual: %v", manual.calls)
+	}
+	next.release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatalf("pending transaction not approved: %v", err)
+	}
+	if err := evaluator.check(&newPolicyTx(&policyPlain, 101, 1, nil).Transaction); err == nil {
+		t.Errorf("transaction exceeding daily limit accepted after approval")
+	}
+	// A rejected request must release its reservation
+	evaluator.SignerUI = new(dummyUI)
+	evaluator.ApproveTx(newPolicyTx(&policyPlain, 100, 1, nil))
+	if err := evaluator.check(&newPolicyTx(&policyPlain, 100, 1, nil).Transaction); err != nil {
+		t.Errorf("reservation of rejected transaction not released: %v", err)
+	}
+}
+
+// Tests that approved transactions are refused if their value cannot be recorded.
+func TestPolicyStorageFailure(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	evaluator := NewPolicyEvaluator(new(approvingUI), new(approvingUI), policy, failingStorage{}, nil)
+
+	res, err := evaluator.ApproveTx(newPolicyTx(&policyPlain, 100, 1, nil))
+	if err == nil || res.Approved {
+		t.Errorf("unrecorded transaction approved: %v", err)
+	}
+}
@@ -656,3 +656,62 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func TestSignTypedData(t *testing.T) {
+
+	js := `function ApproveSignData(r){
+    if(r.messages.length == 2 && r.messages[0].name == "EIP712Domain"){
+        var domain = r.messages[0].value;
+        for(var i = 0; i < domain.length; i++){
+            if(domain[i].name == "name" && domain[i].value == "Ether Mail"){
+                return "Approve"
+            }
+        }
+        return "Reject"
+    }
+    // Otherwise goes to manual processing
+}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Errorf("Couldn't create evaluator %v", err)
+		return
+	}
+	typedData := core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": []core.Type{{Name: "name", Type: "string"}},
+			"Greeting":     []core.Type{{Name: "text", Type: "string"}},
+		},
+		PrimaryType: "Greeting",
+		Domain:      core.TypedDataDomain{Name: "Ether Mail"},
+		Message:     core.TypedDataMessage{"text": "Hello"},
+	}
+	approve := func(typedData core.TypedData) bool {
+		hash, raw, err := typedData.SignatureHash()
+		if err != nil {
+			t.Fatalf("Failed to hash typed data: %v", err)
+		}
+		messages, err := typedData.Format()
+		if err != nil {
+			t.Fatalf("Failed to format typed data: %v", err)
+		}
+		addr, _ := mixAddr("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+		resp, err := r.ApproveSignData(&core.SignDataRequest{
+			Address:  *addr,
+			Messages: messages,
+			Hash:     hash,
+			Meta:     core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+			Rawdata:  raw,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return resp.Approved
+	}
+	if !approve(typedData) {
+		t.Errorf("Expected approved")
+	}
+	typedData.Domain.Name = "Ether Spam"
+	if approve(typedData) {
+		t.Errorf("Expected rejected")
+	}
+}
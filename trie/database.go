@@ -24,6 +24,7 @@ import (
 
 	"github.com/allegro/bigcache"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -64,11 +65,16 @@ type DatabaseReader interface {
 	Has(key []byte) (bool, error)
 }
 
+// NodeFetcher is a callback to retrieve a trie node (or contract code) missing
+// from the local database from a remote source, blocking until it's available.
+type NodeFetcher func(hash common.Hash) ([]byte, error)
+
 // Database is an intermediate write layer between the trie data structures and
 // the disk database. The aim is to accumulate trie writes in-memory and only
 // periodically flush a couple tries to disk, garbage collecting the remainder.
 type Database struct {
-	diskdb ethdb.Database // Persistent storage for matured trie nodes
+	diskdb  ethdb.Database // Persistent storage for matured trie nodes
+	fetcher NodeFetcher    // Optional retriever for nodes missing from disk
 
 	cleans  *bigcache.BigCache          // GC friendly memory cache of clean node RLPs
 	dirties map[common.Hash]*cachedNode // Data and references relationships of dirty nodes
@@ -378,7 +384,10 @@ func (db *Database) node(hash common.Hash, cachegen uint16) node {
 	// Content unavailable in memory, attempt to retrieve from disk
 	enc, err := db.diskdb.Get(hash[:])
 	if err != nil || enc == nil {
-		return nil
+		if enc = db.fetch(hash); enc == nil {
+			return nil
+		}
+		return mustDecodeNode(hash[:], enc, cachegen)
 	}
 	if db.cleans != nil {
 		db.cleans.Set(string(hash[:]), enc)
@@ -415,10 +424,48 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 			memcacheCleanMissMeter.Mark(1)
 			memcacheCleanWriteMeter.Mark(int64(len(enc)))
 		}
+	} else if fetched := db.fetch(hash); fetched != nil {
+		return fetched, nil
 	}
 	return enc, err
 }
 
+// SetFetcher sets a callback to retrieve the nodes missing from the database
+// from a remote source, or removes it if nil.
+func (db *Database) SetFetcher(fetcher NodeFetcher) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.fetcher = fetcher
+}
+
+// fetch retrieves a node missing from the database via the fetcher, if one is
+// set. Fetched nodes are only cached in memory, never persisted, since a trie
+// sync assumes that the entire subtrie of any node on disk is present too.
+func (db *Database) fetch(hash common.Hash) []byte {
+	db.lock.RLock()
+	fetcher := db.fetcher
+	db.lock.RUnlock()
+
+	if fetcher == nil {
+		return nil
+	}
+	enc, err := fetcher(hash)
+	if err != nil {
+		log.Debug("Failed to fetch missing trie node", "hash", hash, "err", err)
+		return nil
+	}
+	if crypto.Keccak256Hash(enc) != hash {
+		log.Warn("Fetched trie node hash mismatch", "hash", hash)
+		return nil
+	}
+	if db.cleans != nil {
+		db.cleans.Set(string(hash[:]), enc)
+		memcacheCleanWriteMeter.Mark(int64(len(enc)))
+	}
+	return enc
+}
+
 // preimage retrieves a cached trie node pre-image from memory. If it cannot be
 // found cached, the method queries the persistent database for the content.
 func (db *Database) preimage(hash common.Hash) ([]byte, error) {
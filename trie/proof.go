@@ -18,6 +18,7 @@ package trie
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -116,7 +117,7 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (valu
 		if err != nil {
 			return nil, i, fmt.Errorf("bad proof node %d: %v", i, err)
 		}
-		keyrest, cld := get(n, key)
+		keyrest, cld := get(n, key, true)
 		switch cld := cld.(type) {
 		case nil:
 			// The trie doesn't contain the key.
@@ -130,7 +131,10 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (valu
 	}
 }
 
-func get(tn node, key []byte) ([]byte, node) {
+// get returns the child of the given node along the key path, along with the
+// remaining part of the key. If skipResolved is set, already resolved children
+// are traversed, stopping only at hash nodes, values or missing paths.
+func get(tn node, key []byte, skipResolved bool) ([]byte, node) {
 	for {
 		switch n := tn.(type) {
 		case *shortNode:
@@ -139,9 +143,15 @@ func get(tn node, key []byte) ([]byte, node) {
 			}
 			tn = n.Val
 			key = key[len(n.Key):]
+			if !skipResolved {
+				return key, tn
+			}
 		case *fullNode:
 			tn = n.Children[key[0]]
 			key = key[1:]
+			if !skipResolved {
+				return key, tn
+			}
 		case hashNode:
 			return key, n
 		case nil:
@@ -153,3 +163,436 @@ func get(tn node, key []byte) ([]byte, node) {
 		}
 	}
 }
+
+// proofToPath converts a Merkle proof into a trie node path, resolving all the
+// nodes along the path of key from the proof and leaving the rest of the trie
+// as hash nodes. If root is non-nil, the path is merged into the given partial
+// trie instead of starting from scratch.
+//
+// If allowNonExistent is set, the proof may also be an absence proof of key.
+func proofToPath(rootHash common.Hash, root node, key []byte, proofDb DatabaseReader, allowNonExistent bool) (node, []byte, error) {
+	// resolveNode retrieves and resolves a trie node from the Merkle proof
+	resolveNode := func(hash common.Hash) (node, error) {
+		buf, _ := proofDb.Get(hash[:])
+		if buf == nil {
+			return nil, fmt.Errorf("proof node (hash %064x) missing", hash)
+		}
+		n, err := decodeNode(hash[:], buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %v", err)
+		}
+		return n, err
+	}
+	// If the root node is empty, resolve it first. The root node must always
+	// be included in the proof.
+	if root == nil {
+		n, err := resolveNode(rootHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		root = n
+	}
+	var (
+		err           error
+		child, parent node
+		keyrest       []byte
+		valnode       []byte
+	)
+	key, parent = keybytesToHex(key), root
+	for {
+		keyrest, child = get(parent, key, false)
+		switch cld := child.(type) {
+		case nil:
+			// The trie doesn't contain the key. It's possible the proof is an
+			// absence proof, but at least all resolved nodes are proven to be
+			// correct, which is enough to prove the range.
+			if allowNonExistent {
+				return root, nil, nil
+			}
+			return nil, nil, errors.New("the node is not contained in trie")
+		case *shortNode:
+			key, parent = keyrest, child // Already resolved
+			continue
+		case *fullNode:
+			key, parent = keyrest, child // Already resolved
+			continue
+		case hashNode:
+			child, err = resolveNode(common.BytesToHash(cld))
+			if err != nil {
+				return nil, nil, err
+			}
+		case valueNode:
+			valnode = cld
+		}
+		// Link the parent and the resolved child
+		switch pnode := parent.(type) {
+		case *shortNode:
+			pnode.Val = child
+		case *fullNode:
+			pnode.Children[key[0]] = child
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", pnode, pnode))
+		}
+		if len(valnode) > 0 {
+			return root, valnode, nil // The whole path is resolved
+		}
+		key, parent = keyrest, child
+	}
+}
+
+// errEmptyRange is returned by unsetInternal if there are no trie nodes between
+// the two edge paths to remove.
+var errEmptyRange = errors.New("empty range")
+
+// unsetInternal removes all internal node references (hash nodes and embedded
+// nodes) between the two edge paths of a partial trie constructed by proofToPath.
+// The removed parts are expected to be refilled with the leaves of the range.
+//
+// All the visited nodes are marked dirty, since their content might change. It
+// can happen that some full nodes are left with a single child, which is not a
+// valid trie shape, but if the range is valid, the missing children are filled
+// in anyway. The left edge key is expected to be less than the right one.
+//
+// The returned flag reports whether the entire trie needs to be unset.
+func unsetInternal(n node, left []byte, right []byte) (bool, error) {
+	left, right = keybytesToHex(left), keybytesToHex(right)
+
+	// Step down to the fork point. It's either a short node, if the key of the
+	// left or right path doesn't match it, or a full node, where both paths may
+	// point to non-existent keys.
+	var (
+		pos    = 0
+		parent node
+
+		// Fork indicators: 0 means no fork, -1 means the path is less than the
+		// short node's key, 1 means it is greater
+		shortForkLeft, shortForkRight int
+	)
+findFork:
+	for {
+		switch rn := (n).(type) {
+		case *shortNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			// If either the left or right path doesn't match the short node,
+			// stop here, the fork point is the short node
+			if len(left)-pos < len(rn.Key) {
+				shortForkLeft = bytes.Compare(left[pos:], rn.Key)
+			} else {
+				shortForkLeft = bytes.Compare(left[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if len(right)-pos < len(rn.Key) {
+				shortForkRight = bytes.Compare(right[pos:], rn.Key)
+			} else {
+				shortForkRight = bytes.Compare(right[pos:pos+len(rn.Key)], rn.Key)
+			}
+			if shortForkLeft != 0 || shortForkRight != 0 {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Val, pos+len(rn.Key)
+		case *fullNode:
+			rn.flags = nodeFlag{dirty: true}
+
+			// If either the left or right path points to a nil child, or they
+			// diverge, stop here, the fork point is the full node
+			leftnode, rightnode := rn.Children[left[pos]], rn.Children[right[pos]]
+			if leftnode == nil || rightnode == nil || leftnode != rightnode {
+				break findFork
+			}
+			parent = n
+			n, pos = rn.Children[left[pos]], pos+1
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", n, n))
+		}
+	}
+	switch rn := n.(type) {
+	case *shortNode:
+		// There are five possible scenarios:
+		// - both paths are less than the short node's key => no valid range
+		// - both paths are greater than the short node's key => no valid range
+		// - left path is less and right path is greater => unset the short node entirely
+		// - left path points into the short node, but the right path is greater
+		// - right path points into the short node, but the left path is less
+		if shortForkLeft == -1 && shortForkRight == -1 {
+			return false, errEmptyRange
+		}
+		if shortForkLeft == 1 && shortForkRight == 1 {
+			return false, errEmptyRange
+		}
+		if shortForkLeft != 0 && shortForkRight != 0 {
+			// If the fork point is the root node, unset the entire trie
+			if parent == nil {
+				return true, nil
+			}
+			parent.(*fullNode).Children[left[pos-1]] = nil
+			return false, nil
+		}
+		// Only one path points to a non-existent key
+		if shortForkRight != 0 {
+			if _, ok := rn.Val.(valueNode); ok {
+				// If the fork point is the root node, unset the entire trie
+				if parent == nil {
+					return true, nil
+				}
+				parent.(*fullNode).Children[left[pos-1]] = nil
+				return false, nil
+			}
+			return false, unset(rn, rn.Val, left[pos:], len(rn.Key), false)
+		}
+		if shortForkLeft != 0 {
+			if _, ok := rn.Val.(valueNode); ok {
+				// If the fork point is the root node, unset the entire trie
+				if parent == nil {
+					return true, nil
+				}
+				parent.(*fullNode).Children[right[pos-1]] = nil
+				return false, nil
+			}
+			return false, unset(rn, rn.Val, right[pos:], len(rn.Key), true)
+		}
+		return false, nil
+	case *fullNode:
+		// Unset all the internal nodes between the two paths in the fork point
+		for i := left[pos] + 1; i < right[pos]; i++ {
+			rn.Children[i] = nil
+		}
+		if err := unset(rn, rn.Children[left[pos]], left[pos:], 1, false); err != nil {
+			return false, err
+		}
+		if err := unset(rn, rn.Children[right[pos]], right[pos:], 1, true); err != nil {
+			return false, err
+		}
+		return false, nil
+	default:
+		panic(fmt.Sprintf("%T: invalid node: %v", n, n))
+	}
+}
+
+// unset removes all internal node references on one side of a path, the left
+// side if removeLeft is set, the right side otherwise. If the path exists, the
+// nodes along it are unset in the given direction. If it doesn't, and the fork
+// point is a full node, there's nothing to do. If the fork point is a short node,
+// its entire branch is unset if it lies within the range, kept otherwise.
+func unset(parent node, child node, key []byte, pos int, removeLeft bool) error {
+	switch cld := child.(type) {
+	case *fullNode:
+		if removeLeft {
+			for i := 0; i < int(key[pos]); i++ {
+				cld.Children[i] = nil
+			}
+		} else {
+			for i := key[pos] + 1; i < 16; i++ {
+				cld.Children[i] = nil
+			}
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Children[key[pos]], key, pos+1, removeLeft)
+	case *shortNode:
+		if len(key[pos:]) < len(cld.Key) || !bytes.Equal(cld.Key, key[pos:pos+len(cld.Key)]) {
+			// Found the fork point, it's a non-existent branch. Unset it if the
+			// short node lies within the range, keep it with its cached hash if
+			// it doesn't. The parent must be a full node.
+			if removeLeft {
+				if bytes.Compare(cld.Key, key[pos:]) < 0 {
+					parent.(*fullNode).Children[key[pos-1]] = nil
+				}
+			} else {
+				if bytes.Compare(cld.Key, key[pos:]) > 0 {
+					parent.(*fullNode).Children[key[pos-1]] = nil
+				}
+			}
+			return nil
+		}
+		if _, ok := cld.Val.(valueNode); ok {
+			parent.(*fullNode).Children[key[pos-1]] = nil
+			return nil
+		}
+		cld.flags = nodeFlag{dirty: true}
+		return unset(cld, cld.Val, key, pos+len(cld.Key), removeLeft)
+	case nil:
+		// The node is a missing child of the fork point full node, there's
+		// nothing to remove
+		return nil
+	default:
+		return fmt.Errorf("%T: unexpected node on the edge path", cld) // hashNode, valueNode
+	}
+}
+
+// hasRightElement returns whether there are any trie entries to the right of
+// the given path. The path may point to an existent or non-existent key, but
+// it must be fully resolved.
+func hasRightElement(node node, key []byte) bool {
+	pos, key := 0, keybytesToHex(key)
+	for node != nil {
+		switch rn := node.(type) {
+		case *fullNode:
+			for i := key[pos] + 1; i < 16; i++ {
+				if rn.Children[i] != nil {
+					return true
+				}
+			}
+			node, pos = rn.Children[key[pos]], pos+1
+		case *shortNode:
+			if len(key)-pos < len(rn.Key) || !bytes.Equal(rn.Key, key[pos:pos+len(rn.Key)]) {
+				return bytes.Compare(rn.Key, key[pos:]) > 0
+			}
+			node, pos = rn.Val, pos+len(rn.Key)
+		case valueNode:
+			return false // The whole path is resolved
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", node, node)) // hashNode
+		}
+	}
+	return false
+}
+
+// VerifyRangeProof checks whether the given sorted run of leaves is exactly the
+// complete set of trie entries between firstKey and lastKey (inclusive), in the
+// trie with the given root hash. The proof must contain the Merkle proofs of the
+// two edge keys, either of which may be an absence proof. The edge keys must be
+// of the same length and firstKey can only equal lastKey for a single leaf.
+//
+// Besides the regular case, the following ranges can be verified:
+//
+// - All elements: the proof is nil and the leaves must be the entire trie.
+//
+// - Single element: the edge keys are the same as the only leaf.
+//
+// - Zero elements: the edge proofs must prove that no entries exist between the
+//   edge keys. If lastKey is nil, there must be no entries from firstKey onward.
+//
+// Apart from the validity of the range, the returned flag reports whether there
+// are more entries in the trie beyond the proven range.
+func VerifyRangeProof(rootHash common.Hash, firstKey []byte, lastKey []byte, keys [][]byte, values [][]byte, proofDb DatabaseReader) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("inconsistent proof data, keys: %d, values: %d", len(keys), len(values))
+	}
+	// Ensure the received batch is monotonically increasing and has no deletions
+	for i := 0; i < len(keys)-1; i++ {
+		if bytes.Compare(keys[i], keys[i+1]) >= 0 {
+			return false, errors.New("range is not monotonically increasing")
+		}
+	}
+	for _, value := range values {
+		if len(value) == 0 {
+			return false, errors.New("range contains deletion")
+		}
+	}
+	// Special case, there is no edge proof at all. The given range is expected
+	// to be the whole leaf set of the trie.
+	if proofDb == nil {
+		tr := &Trie{db: NewDatabase(ethdb.NewMemDatabase())}
+		for i, key := range keys {
+			tr.Update(key, values[i])
+		}
+		if have, want := tr.Hash(), rootHash; have != want {
+			return false, fmt.Errorf("invalid proof, want hash %x, got %x", want, have)
+		}
+		return false, nil // No more elements
+	}
+	// Ensure the leaves are all within the edge keys
+	if len(keys) > 0 {
+		if bytes.Compare(keys[0], firstKey) < 0 {
+			return false, errors.New("range starts before the first edge key")
+		}
+		if lastKey != nil && bytes.Compare(keys[len(keys)-1], lastKey) > 0 {
+			return false, errors.New("range ends after the last edge key")
+		}
+	}
+	// Special case, there is an edge proof but zero key/value pairs. Ensure there
+	// are no entries in the range (or after the first key, if it's unbounded).
+	if len(keys) == 0 {
+		root, val, err := proofToPath(rootHash, nil, firstKey, proofDb, true)
+		if err != nil {
+			return false, err
+		}
+		if val != nil {
+			return false, errors.New("more entries available")
+		}
+		if lastKey == nil || bytes.Equal(firstKey, lastKey) {
+			if hasRightElement(root, firstKey) {
+				return false, errors.New("more entries available")
+			}
+			return false, nil
+		}
+		if bytes.Compare(firstKey, lastKey) > 0 || len(firstKey) != len(lastKey) {
+			return false, errors.New("invalid edge keys")
+		}
+		if root, val, err = proofToPath(rootHash, root, lastKey, proofDb, true); err != nil {
+			return false, err
+		}
+		if val != nil {
+			return false, errors.New("more entries available")
+		}
+		more := hasRightElement(root, lastKey)
+
+		// Removing the nodes between the edges must not change the trie
+		empty, err := unsetInternal(root, firstKey, lastKey)
+		if err == errEmptyRange {
+			return more, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		tr := &Trie{root: root, db: NewDatabase(ethdb.NewMemDatabase())}
+		if empty {
+			tr.root = nil
+		}
+		if tr.Hash() != rootHash {
+			return false, errors.New("more entries available")
+		}
+		return more, nil
+	}
+	// Special case, there is only one element and the two edge keys are the same.
+	// In this case, two edge paths can't be constructed, handle it here.
+	if len(keys) == 1 && bytes.Equal(firstKey, lastKey) {
+		root, val, err := proofToPath(rootHash, nil, firstKey, proofDb, false)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(firstKey, keys[0]) {
+			return false, errors.New("correct proof but invalid key")
+		}
+		if !bytes.Equal(val, values[0]) {
+			return false, errors.New("correct proof but invalid data")
+		}
+		return hasRightElement(root, firstKey), nil
+	}
+	// In all other cases two edge paths are required, check the edge keys first
+	if lastKey == nil || bytes.Compare(firstKey, lastKey) >= 0 {
+		return false, errors.New("invalid edge keys")
+	}
+	if len(firstKey) != len(lastKey) {
+		return false, errors.New("inconsistent edge keys")
+	}
+	// Convert the edge proofs to edge trie paths, merging the second into the
+	// first. Absence proofs are allowed for both.
+	root, _, err := proofToPath(rootHash, nil, firstKey, proofDb, true)
+	if err != nil {
+		return false, err
+	}
+	root, _, err = proofToPath(rootHash, root, lastKey, proofDb, true)
+	if err != nil {
+		return false, err
+	}
+	// Remove all the internal references between the edges and refill them from
+	// the leaves. The resulting trie must be the same as the original one.
+	empty, err := unsetInternal(root, firstKey, lastKey)
+	if err != nil {
+		return false, err
+	}
+	tr := &Trie{root: root, db: NewDatabase(ethdb.NewMemDatabase())}
+	if empty {
+		tr.root = nil
+	}
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, values[i]); err != nil {
+			return false, fmt.Errorf("invalid range leaf %x: %v", key, err)
+		}
+	}
+	if have := tr.Hash(); have != rootHash {
+		return false, fmt.Errorf("invalid proof, want hash %x, got %x", rootHash, have)
+	}
+	return hasRightElement(tr.root, keys[len(keys)-1]), nil
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -143,6 +144,378 @@ func TestMissingKeyProof(t *testing.T) {
 	}
 }
 
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// sortedEntries returns the entries of a random trie sorted by key.
+func sortedEntries(vals map[string]*kv) entrySlice {
+	var entries entrySlice
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(entries)
+	return entries
+}
+
+// proveRange creates the Merkle proofs of the two edge keys of a range.
+func proveRange(trie *Trie, first, last []byte) *ethdb.MemDatabase {
+	proof := ethdb.NewMemDatabase()
+	if err := trie.Prove(first, 0, proof); err != nil {
+		panic(err)
+	}
+	if last != nil {
+		if err := trie.Prove(last, 0, proof); err != nil {
+			panic(err)
+		}
+	}
+	return proof
+}
+
+// splitEntries returns the keys and values of a set of entries.
+func splitEntries(entries entrySlice) ([][]byte, [][]byte) {
+	var keys, vals [][]byte
+	for _, entry := range entries {
+		keys = append(keys, entry.k)
+		vals = append(vals, entry.v)
+	}
+	return keys, vals
+}
+
+// Tests that random consecutive ranges of a trie can be verified with the edge
+// proofs of their first and last leaves.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		proof := proveRange(trie, entries[start].k, entries[end-1].k)
+		keys, vals := splitEntries(entries[start:end])
+
+		more, err := VerifyRangeProof(trie.Hash(), keys[0], keys[len(keys)-1], keys, vals, proof)
+		if err != nil {
+			t.Fatalf("case %d(%d->%d): expected no error, got %v", i, start, end-1, err)
+		}
+		if want := end < len(entries); more != want {
+			t.Fatalf("case %d(%d->%d): more entries mismatch: have %v, want %v", i, start, end-1, more, want)
+		}
+	}
+}
+
+// Tests that random consecutive ranges of a trie can be verified with absence
+// proofs of keys just outside the range.
+func TestRangeProofWithNonExistentProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		// Edge keys wrapping around the key space are replaced with its bounds
+		first := decreaseKey(common.CopyBytes(entries[start].k))
+		if bytes.Compare(first, entries[start].k) > 0 {
+			first = make([]byte, 32)
+		}
+		if start != 0 && bytes.Equal(first, entries[start-1].k) {
+			continue
+		}
+		last := increaseKey(common.CopyBytes(entries[end-1].k))
+		if bytes.Compare(last, entries[end-1].k) < 0 {
+			last = bytes.Repeat([]byte{0xff}, 32)
+		}
+		if end != len(entries) && bytes.Equal(last, entries[end].k) {
+			continue
+		}
+		proof := proveRange(trie, first, last)
+		keys, vals := splitEntries(entries[start:end])
+
+		more, err := VerifyRangeProof(trie.Hash(), first, last, keys, vals, proof)
+		if err != nil {
+			t.Fatalf("case %d(%d->%d): expected no error, got %v", i, start, end-1, err)
+		}
+		if want := end < len(entries); more != want {
+			t.Fatalf("case %d(%d->%d): more entries mismatch: have %v, want %v", i, start, end-1, more, want)
+		}
+	}
+	// Special case, two edge proofs for the two edge keys of the key space
+	proof := proveRange(trie, make([]byte, 32), bytes.Repeat([]byte{0xff}, 32))
+	keys, values := splitEntries(entries)
+	if _, err := VerifyRangeProof(trie.Hash(), make([]byte, 32), bytes.Repeat([]byte{0xff}, 32), keys, values, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// Tests that ranges with leaves outside of the proven edges are rejected.
+func TestRangeProofWithInvalidNonExistentProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	// Case 1: the first edge key is greater than the first leaf
+	start, end := 100, 200
+	first := increaseKey(common.CopyBytes(entries[start].k))
+	proof := proveRange(trie, first, entries[end-1].k)
+	keys, values := splitEntries(entries[start:end])
+	if _, err := VerifyRangeProof(trie.Hash(), first, keys[len(keys)-1], keys, values, proof); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	// Case 2: the last edge key is less than the last leaf
+	last := decreaseKey(common.CopyBytes(entries[end-1].k))
+	proof = proveRange(trie, entries[start].k, last)
+	if _, err := VerifyRangeProof(trie.Hash(), keys[0], last, keys, values, proof); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+// Tests that single leaf ranges can be verified with both existence and absence
+// edge proofs.
+func TestOneElementRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	// One element with an existence proof
+	start := 1000
+	proof := proveRange(trie, entries[start].k, nil)
+	if _, err := VerifyRangeProof(trie.Hash(), entries[start].k, entries[start].k, [][]byte{entries[start].k}, [][]byte{entries[start].v}, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// One element with a left absence proof
+	first := decreaseKey(common.CopyBytes(entries[start].k))
+	proof = proveRange(trie, first, entries[start].k)
+	if _, err := VerifyRangeProof(trie.Hash(), first, entries[start].k, [][]byte{entries[start].k}, [][]byte{entries[start].v}, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// One element with a right absence proof
+	last := increaseKey(common.CopyBytes(entries[start].k))
+	proof = proveRange(trie, entries[start].k, last)
+	if _, err := VerifyRangeProof(trie.Hash(), entries[start].k, last, [][]byte{entries[start].k}, [][]byte{entries[start].v}, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// One element with two absence proofs
+	proof = proveRange(trie, first, last)
+	if _, err := VerifyRangeProof(trie.Hash(), first, last, [][]byte{entries[start].k}, [][]byte{entries[start].v}, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Test the mini trie with only a single element
+	tinyTrie := new(Trie)
+	entry := &kv{randBytes(32), randBytes(20), false}
+	tinyTrie.Update(entry.k, entry.v)
+
+	first = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000").Bytes()
+	last = entry.k
+	proof = proveRange(tinyTrie, first, last)
+	if _, err := VerifyRangeProof(tinyTrie.Hash(), first, last, [][]byte{entry.k}, [][]byte{entry.v}, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// Tests that the entire trie can be verified without any proofs, and that
+// it can also be verified with edge proofs of the extreme keys.
+func TestAllElementsProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	keys, values := splitEntries(entries)
+
+	if _, err := VerifyRangeProof(trie.Hash(), nil, nil, keys, values, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), nil, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("expected error for incomplete trie, got nil")
+	}
+	proof := proveRange(trie, keys[0], keys[len(keys)-1])
+	if _, err := VerifyRangeProof(trie.Hash(), keys[0], keys[len(keys)-1], keys, values, proof); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// Tests that empty ranges are only accepted if the trie indeed has no entries
+// within the proven edges.
+func TestEmptyRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	// Unbounded empty range after the last entry
+	first := increaseKey(common.CopyBytes(entries[len(entries)-1].k))
+	proof := proveRange(trie, first, nil)
+	if more, err := VerifyRangeProof(trie.Hash(), first, nil, nil, nil, proof); err != nil || more {
+		t.Fatalf("expected valid empty range, got more %v, err %v", more, err)
+	}
+	// Unbounded empty range before an existing entry
+	first = decreaseKey(common.CopyBytes(entries[len(entries)-2].k))
+	proof = proveRange(trie, first, nil)
+	if _, err := VerifyRangeProof(trie.Hash(), first, nil, nil, nil, proof); err == nil {
+		t.Fatalf("expected error for non-empty range, got nil")
+	}
+	// Bounded empty ranges between two consecutive entries
+	for i := 0; i < 100; i++ {
+		pos := mrand.Intn(len(entries) - 1)
+
+		first := increaseKey(common.CopyBytes(entries[pos].k))
+		last := decreaseKey(common.CopyBytes(entries[pos+1].k))
+		if bytes.Compare(first, last) > 0 {
+			continue
+		}
+		proof := proveRange(trie, first, last)
+		if more, err := VerifyRangeProof(trie.Hash(), first, last, nil, nil, proof); err != nil || !more {
+			t.Fatalf("case %d: expected valid empty range, got more %v, err %v", i, more, err)
+		}
+		// Extending the range over the next entry must fail
+		last = increaseKey(common.CopyBytes(entries[pos+1].k))
+		if pos+2 < len(entries) && bytes.Equal(last, entries[pos+2].k) {
+			continue
+		}
+		proof = proveRange(trie, first, last)
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, nil, nil, proof); err == nil {
+			t.Fatalf("case %d: expected error for non-empty range, got nil", i)
+		}
+	}
+}
+
+// Tests that tampered ranges are rejected: modified keys or values, added or
+// removed leaves and shuffled ordering.
+func TestBadRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+		proof := proveRange(trie, entries[start].k, entries[end-1].k)
+
+		keys, vals := splitEntries(entries[start:end])
+		keys = append([][]byte{}, keys...)
+		vals = append([][]byte{}, vals...)
+		var first, last = keys[0], keys[len(keys)-1]
+
+		testcase := mrand.Intn(6)
+		index := mrand.Intn(end - start)
+		switch testcase {
+		case 0:
+			// Modified key
+			keys[index] = randBytes(32) // In theory it can't be same
+		case 1:
+			// Modified value
+			vals[index] = randBytes(20) // In theory it can't be same
+		case 2:
+			// Gapped entry slice
+			if index == 0 || index == end-start-1 {
+				continue
+			}
+			keys = append(keys[:index], keys[index+1:]...)
+			vals = append(vals[:index], vals[index+1:]...)
+		case 3:
+			// Out of order
+			index2 := mrand.Intn(end - start)
+			if index2 == index {
+				continue
+			}
+			keys[index], keys[index2] = keys[index2], keys[index]
+			vals[index], vals[index2] = vals[index2], vals[index]
+		case 4:
+			// Set random key to nil, do nothing
+			keys[index] = nil
+		case 5:
+			// Set random value to nil, deletion
+			vals[index] = nil
+		}
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, vals, proof); err == nil {
+			t.Fatalf("%d Case %d index %d range: (%d->%d) expect error, got nil", i, testcase, index, start, end-1)
+		}
+	}
+}
+
+// Tests that random ranges of random small tries, whose keys share long common
+// prefixes, are verified iff the delivered leaves are exactly the range contents.
+func TestRangeProofFuzz(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		// Create a small trie with keys from a tiny alphabet to force short nodes
+		var (
+			trie = new(Trie)
+			vals = make(map[string]*kv)
+			n    = mrand.Intn(32) + 1
+		)
+		for len(vals) < n {
+			key := make([]byte, 4)
+			for j := range key {
+				key[j] = byte(mrand.Intn(3)) << 4
+			}
+			value := &kv{key, randBytes(mrand.Intn(40) + 1), false}
+			trie.Update(value.k, value.v)
+			vals[string(key)] = value
+		}
+		entries := sortedEntries(vals)
+
+		// Pick random edges, possibly non-existent, and collect the range contents
+		first, last := make([]byte, 4), make([]byte, 4)
+		for j := range first {
+			first[j], last[j] = byte(mrand.Intn(3))<<4, byte(mrand.Intn(3))<<4
+		}
+		if bytes.Compare(first, last) > 0 {
+			first, last = last, first
+		}
+		var inside entrySlice
+		more := false
+		for _, entry := range entries {
+			if bytes.Compare(entry.k, first) >= 0 && bytes.Compare(entry.k, last) <= 0 {
+				inside = append(inside, entry)
+			}
+			if bytes.Compare(entry.k, last) > 0 {
+				more = true
+			}
+		}
+		keys, values := splitEntries(inside)
+		if len(keys) == 1 && bytes.Equal(first, last) {
+			// Single element ranges are only provable with equal edges
+		} else if bytes.Equal(first, last) {
+			continue
+		}
+		proof := proveRange(trie, first, last)
+		have, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proof)
+		if err != nil {
+			t.Fatalf("case %d: range %x-%x of %d entries: expected no error, got %v", i, first, last, len(entries), err)
+		}
+		if len(keys) > 0 && have != more {
+			t.Fatalf("case %d: range %x-%x: more entries mismatch: have %v, want %v", i, first, last, have, more)
+		}
+		// Dropping any of the leaves must invalidate the range
+		if len(keys) > 1 {
+			drop := mrand.Intn(len(keys))
+			keys = append(append([][]byte{}, keys[:drop]...), keys[drop+1:]...)
+			values = append(append([][]byte{}, values[:drop]...), values[drop+1:]...)
+			if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proof); err == nil {
+				t.Fatalf("case %d: range %x-%x: expected error for missing leaf, got nil", i, first, last)
+			}
+		}
+	}
+}
+
+// increaseKey returns the key incremented by one, wrapping around on overflow.
+func increaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]++
+		if key[i] != 0x0 {
+			break
+		}
+	}
+	return key
+}
+
+// decreaseKey returns the key decremented by one, wrapping around on underflow.
+func decreaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]--
+		if key[i] != 0xff {
+			break
+		}
+	}
+	return key
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {